@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/generators"
+)
+
+func runGenerate(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	name := fs.String("generator", "", "Reference generator ("+strings.Join(generators.Names, ",")+")")
+	seedHex := fs.String("seed", "", "Seed as a hex integer; the generator's default seed if empty")
+	bits := fs.Int("bits", 1000000, "Number of bits to generate (multiple of 8)")
+	out := fs.String("out", "", "Also write the generated sequence (packed bytes) to this file")
+	outJSON := fs.Bool("json", false, "Print JSON output instead of table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("missing -generator (one of %s)", strings.Join(generators.Names, ", "))
+	}
+
+	seed, err := parseSeed(*seedHex)
+	if err != nil {
+		return err
+	}
+	if seed == nil {
+		seed = generators.DefaultSeed(*name)
+	}
+
+	start := time.Now()
+	bitstream, err := generators.Generate(*name, seed, *bits)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if *out != "" {
+		if err := os.WriteFile(*out, bitstream, 0o600); err != nil {
+			return fmt.Errorf("write sequence: %w", err)
+		}
+	}
+
+	report := &suiteReport{Source: *name, Seed: hex.EncodeToString(seed)}
+	return testSequence(stdout, report, bitstream, start, *outJSON)
+}
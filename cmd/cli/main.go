@@ -0,0 +1,67 @@
+// Package main is the command-line interface for the NIST SP 800-22 test suite
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// errChecksFailed signals that a command ran but its checks did not pass.
+var errChecksFailed = errors.New("checks failed")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		printUsage(stdout)
+		return errors.New("missing command")
+	}
+
+	switch args[0] {
+	case "selftest":
+		return runSelfTest(ctx, args[1:], stdout)
+	case "generate":
+		return runGenerate(ctx, args[1:], stdout)
+	case "source":
+		return runSource(ctx, args[1:], stdout)
+	case "tests":
+		return runListTests(args[1:], stdout)
+	case "audit-verify":
+		return runAuditVerify(args[1:], stdout)
+	case "help", "-h", "-help", "--help":
+		printUsage(stdout)
+		return nil
+	default:
+		printUsage(stdout)
+		return fmt.Errorf("unknown command: %q", args[0])
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, `Usage: nist-sp800-22-cli <command> [flags]
+
+Commands:
+  selftest   Run the known-answer tests against the NIST sample data sets (Appendix B)
+  generate   Run the test suite on the output of a reference generator (Appendix D)
+  source     Run the test suite on bits read from a host source (crypto/rand, math/rand/v2, device)
+  tests      List the registered tests and their parameters
+  audit-verify
+             Check the hash chain of the server's audit log files
+  help       Show this help
+
+Run 'nist-sp800-22-cli <command> -h' for command flags.
+`)
+}
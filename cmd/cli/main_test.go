@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/audit"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/generators"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/selftest"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/sources"
+)
+
+func TestRunUsage(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(context.Background(), nil, &out); err == nil {
+		t.Error("expected error for missing command")
+	}
+	if !strings.Contains(out.String(), "Usage:") {
+		t.Errorf("expected usage output, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := run(context.Background(), []string{"help"}, &out); err != nil {
+		t.Errorf("unexpected error for help: %v", err)
+	}
+
+	if err := run(context.Background(), []string{"bogus"}, &out); err == nil {
+		t.Error("expected error for unknown command")
+	}
+}
+
+func TestRunSelfTest(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"selftest", "-datasets", selftest.DatasetSqrt2}, &out); err != nil {
+		t.Fatalf("selftest failed: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "Self-test PASSED") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
+
+func TestRunSelfTestJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"selftest", "-datasets", selftest.DatasetSqrt2, "-json"}, &out); err != nil {
+		t.Fatalf("selftest failed: %v", err)
+	}
+
+	var report selftest.Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if !report.Passed || len(report.Checks) == 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestRunSelfTestErrors(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"selftest", "-datasets", "unknown"}, &out); err == nil {
+		t.Error("expected error for unknown dataset")
+	}
+	if err := run(context.Background(), []string{"selftest", "-bogus"}, &out); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+}
+
+func TestRunSelfTestMismatch(t *testing.T) {
+	orig := selfTestRun
+	defer func() { selfTestRun = orig }()
+
+	selfTestRun = func(ctx context.Context, datasets ...string) (*selftest.Report, error) {
+		return &selftest.Report{
+			Checks: []selftest.Check{{Dataset: "pi", Test: "runs", Expected: 0.419268, PValue: 0.4, AbsDiff: 0.019268}},
+		}, nil
+	}
+
+	var out bytes.Buffer
+	err := run(context.Background(), []string{"selftest"}, &out)
+	if !errors.Is(err, errChecksFailed) {
+		t.Fatalf("expected errChecksFailed, got %v", err)
+	}
+	if !strings.Contains(out.String(), "MISMATCH") || !strings.Contains(out.String(), "Self-test FAILED") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
+
+func TestRunGenerate(t *testing.T) {
+	orig := runAllTests
+	defer func() { runAllTests = orig }()
+
+	var gotBits int
+	runAllTests = func(bitstream []byte) ([]nist.TestResult, error) {
+		gotBits = len(bitstream) * 8
+		return []nist.TestResult{{Name: "frequency_monobit", PValue: 0.5, Passed: true}}, nil
+	}
+
+	path := filepath.Join(t.TempDir(), "lcg.bin")
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"generate", "-generator", generators.LCG, "-bits", "4096", "-out", path}, &out); err != nil {
+		t.Fatalf("generate failed: %v\n%s", err, out.String())
+	}
+	if gotBits != 4096 || !strings.Contains(out.String(), "Test suite PASSED") {
+		t.Fatalf("unexpected output (%d bits): %s", gotBits, out.String())
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read sequence file: %v", err)
+	}
+	want, _ := generators.Generate(generators.LCG, nil, 4096)
+	if !bytes.Equal(written, want) {
+		t.Fatal("sequence file does not match generator output")
+	}
+}
+
+func TestRunGenerateSubTests(t *testing.T) {
+	orig := runAllTests
+	defer func() { runAllTests = orig }()
+
+	// One of 148 templates below Alpha is expected by chance and a skipped test is not
+	// a failure, although both report passed = false
+	tails := make([]nist.Tail, 148)
+	tails[0] = nist.Tail{Kind: nist.ErfcTail, X: 2}
+	runAllTests = func(bitstream []byte) ([]nist.TestResult, error) {
+		return []nist.TestResult{
+			{Name: "non_overlapping_template", PValue: tails[0].PValue(), Passed: false, Tails: tails},
+			{Name: "random_excursions", PValue: -1, Passed: false},
+		}, nil
+	}
+
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"generate", "-generator", generators.LCG, "-bits", "4096"}, &out); err != nil {
+		t.Fatalf("generate failed: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "Test suite PASSED") || !strings.Contains(out.String(), "skipped") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
+
+func TestRunGenerateJSONFailure(t *testing.T) {
+	orig := runAllTests
+	defer func() { runAllTests = orig }()
+
+	runAllTests = func(bitstream []byte) ([]nist.TestResult, error) {
+		return []nist.TestResult{{Name: "linear_complexity", PValue: 0, Passed: false}}, nil
+	}
+
+	var out bytes.Buffer
+	err := run(context.Background(), []string{"generate", "-generator", generators.XOR, "-seed", "0x1234", "-bits", "4096", "-json"}, &out)
+	if !errors.Is(err, errChecksFailed) {
+		t.Fatalf("expected errChecksFailed, got %v", err)
+	}
+
+	var report suiteReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if report.Passed || report.Seed != "1234" || report.Source != generators.XOR {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestRunGenerateErrors(t *testing.T) {
+	tests := [][]string{
+		{"generate"},
+		{"generate", "-generator", "unknown"},
+		{"generate", "-generator", generators.LCG, "-seed", "xyz"},
+		{"generate", "-generator", generators.LCG, "-bits", "12"},
+		{"generate", "-generator", generators.LCG, "-bits", "1024"},
+		{"generate", "-bogus"},
+	}
+
+	for _, args := range tests {
+		var out bytes.Buffer
+		if err := run(context.Background(), args, &out); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestRunSource(t *testing.T) {
+	orig := runAllTests
+	defer func() { runAllTests = orig }()
+
+	var got []byte
+	runAllTests = func(bitstream []byte) ([]nist.TestResult, error) {
+		got = bitstream
+		return []nist.TestResult{{Name: "frequency_monobit", PValue: 0.5, Passed: true}}, nil
+	}
+
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"source", "-source", sources.ChaCha8, "-seed", "0x2a", "-bits", "4096"}, &out); err != nil {
+		t.Fatalf("source failed: %v\n%s", err, out.String())
+	}
+	want, _ := sources.Read(context.Background(), sources.Spec{Name: sources.ChaCha8, Seed: []byte{0x2a}}, 4096)
+	if !bytes.Equal(got, want) {
+		t.Fatal("tested sequence does not match the seeded source")
+	}
+	if !strings.Contains(out.String(), "Source chacha8, seed 2a, 4096 bits") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "bits.bin")
+	if err := os.WriteFile(path, bytes.Repeat([]byte{0x5a}, 512), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	out.Reset()
+	if err := run(context.Background(), []string{"source", "-source", sources.File, "-path", path, "-bits", "4096", "-json"}, &out); err != nil {
+		t.Fatalf("source failed: %v", err)
+	}
+	var report suiteReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if report.Source != sources.File+":"+path || report.Bits != 4096 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestRunSourceErrors(t *testing.T) {
+	tests := [][]string{
+		{"source"},
+		{"source", "-source", "unknown"},
+		{"source", "-source", sources.PCG, "-seed", "zz"},
+		{"source", "-source", sources.File},
+		{"source", "-source", sources.CryptoRand, "-bits", "1024"},
+		{"source", "-bogus"},
+	}
+
+	for _, args := range tests {
+		var out bytes.Buffer
+		if err := run(context.Background(), args, &out); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestRunListTests(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"tests"}, &out); err != nil {
+		t.Fatalf("tests failed: %v", err)
+	}
+	for _, name := range nist.DefaultRegistry.Names() {
+		if !strings.Contains(out.String(), name) {
+			t.Errorf("missing test %s in output", name)
+		}
+	}
+	if !strings.Contains(out.String(), "block_length") {
+		t.Errorf("expected parameter listing, got %s", out.String())
+	}
+
+	if err := run(context.Background(), []string{"tests", "-bogus"}, &out); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+}
+
+func TestRunAuditVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := audit.Open(audit.Config{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if err := l.Write(audit.Record{RequestID: id, Verdict: "pass"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"audit-verify", path}, &out); err != nil {
+		t.Fatalf("audit-verify failed: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "2 records verified") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, bytes.Replace(raw, []byte(`"b"`), []byte(`"c"`), 1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	err = run(context.Background(), []string{"audit-verify", path}, &out)
+	if !errors.Is(err, errChecksFailed) || !strings.Contains(out.String(), "hash chain broken") {
+		t.Fatalf("expected broken chain, got %v: %s", err, out.String())
+	}
+
+	if err := run(context.Background(), []string{"audit-verify"}, &out); err == nil {
+		t.Error("expected error without files")
+	}
+	if err := run(context.Background(), []string{"audit-verify", filepath.Join(t.TempDir(), "missing")}, &out); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
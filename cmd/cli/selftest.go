@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/selftest"
+)
+
+// selfTestRun is a variable to allow mocking in tests
+var selfTestRun = selftest.Run
+
+func runSelfTest(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	datasets := fs.String("datasets", "", "Comma-separated data sets to check ("+strings.Join(selftest.Datasets, ",")+"); all if empty")
+	outJSON := fs.Bool("json", false, "Print JSON output instead of table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var names []string
+	if *datasets != "" {
+		names = strings.Split(*datasets, ",")
+	}
+
+	report, err := selfTestRun(ctx, names...)
+	if err != nil {
+		return err
+	}
+
+	if *outJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode report: %w", err)
+		}
+	} else {
+		printSelfTestReport(stdout, report)
+	}
+
+	if !report.Passed {
+		return fmt.Errorf("self-test: %d of %d %w", len(report.Failed()), len(report.Checks), errChecksFailed)
+	}
+	return nil
+}
+
+func printSelfTestReport(w io.Writer, report *selftest.Report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATASET\tTEST\tEXPECTED\tP-VALUE\tDIFF\tSTATUS")
+	for _, c := range report.Checks {
+		status := "ok"
+		if !c.Passed {
+			status = "MISMATCH"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.6f\t%.6f\t%.1e\t%s\n", c.Dataset, c.Test, c.Expected, c.PValue, c.AbsDiff, status)
+	}
+	tw.Flush()
+
+	verdict := "PASSED"
+	if !report.Passed {
+		verdict = "FAILED"
+	}
+	fmt.Fprintf(w, "\nSelf-test %s: %d checks, tolerance %.0e, %s\n",
+		verdict, len(report.Checks), selftest.Tolerance, report.Duration.Round(time.Millisecond))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/sources"
+)
+
+func runSource(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("source", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	name := fs.String("source", "", "Random source ("+strings.Join(sources.Names, ",")+")")
+	seedHex := fs.String("seed", "", "Seed as a hex integer (pcg: up to 16 bytes, chacha8: up to 32 bytes)")
+	path := fs.String("path", "", "File or character device read by the file source (e.g. /dev/urandom)")
+	bits := fs.Int("bits", 1000000, "Number of bits to read (multiple of 8)")
+	outJSON := fs.Bool("json", false, "Print JSON output instead of table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("missing -source (one of %s)", strings.Join(sources.Names, ", "))
+	}
+
+	seed, err := parseSeed(*seedHex)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	bitstream, err := sources.Read(ctx, sources.Spec{Name: *name, Seed: seed, Path: *path}, *bits)
+	if err != nil {
+		return err
+	}
+
+	label := *name
+	if *path != "" {
+		label += ":" + *path
+	}
+	report := &suiteReport{Source: label, Seed: strings.TrimPrefix(*seedHex, "0x")}
+	return testSequence(stdout, report, bitstream, start, *outJSON)
+}
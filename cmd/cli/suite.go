@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+)
+
+// runAllTests is a variable to allow mocking in tests
+var runAllTests = nist.RunAllTests
+
+// suiteReport is the JSON output of the generate and source commands
+type suiteReport struct {
+	Source   string            `json:"source"`
+	Seed     string            `json:"seed,omitempty"`
+	Bits     int               `json:"bits"`
+	Passed   bool              `json:"passed"`
+	Failed   []string          `json:"failed,omitempty"`
+	Results  []nist.TestResult `json:"results"`
+	Duration time.Duration     `json:"duration"`
+}
+
+// testSequence runs the test battery on bitstream and prints the report. It returns
+// errChecksFailed if any test failed by the proportion of its passing sub-tests (see
+// nist.SubTestsFailed), the verdict of the gRPC service;
+// skipped tests do not fail.
+func testSequence(stdout io.Writer, report *suiteReport, bitstream []byte, start time.Time, outJSON bool) error {
+	results, err := runAllTests(bitstream)
+	if err != nil {
+		return err
+	}
+
+	report.Bits = len(bitstream) * 8
+	report.Results = results
+	report.Failed = nil
+	for _, r := range results {
+		if r.Failed() {
+			report.Failed = append(report.Failed, r.Name)
+		}
+	}
+	report.Passed = nist.SuitePassed(results)
+	report.Duration = time.Since(start)
+
+	if outJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode report: %w", err)
+		}
+	} else {
+		printSuiteReport(stdout, report)
+	}
+
+	if !report.Passed {
+		return fmt.Errorf("%s: %w (%d of %d tests)", report.Source, errChecksFailed, len(report.Failed), len(results))
+	}
+	return nil
+}
+
+func printSuiteReport(w io.Writer, report *suiteReport) {
+	if report.Seed != "" {
+		fmt.Fprintf(w, "Source %s, seed %s, %d bits\n\n", report.Source, report.Seed, report.Bits)
+	} else {
+		fmt.Fprintf(w, "Source %s, %d bits\n\n", report.Source, report.Bits)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEST\tP-VALUE\tSTATUS\tWARNING")
+	for _, r := range report.Results {
+		status := "pass"
+		switch {
+		case r.PValue < 0:
+			status = "skipped"
+		case r.Failed():
+			status = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%.6f\t%s\t%s\n", r.Name, r.PValue, status, r.Warning)
+	}
+	tw.Flush()
+
+	verdict := "PASSED"
+	if !report.Passed {
+		verdict = "FAILED"
+	}
+	fmt.Fprintf(w, "\nTest suite %s: %d tests, %s\n", verdict, len(report.Results), report.Duration.Round(time.Millisecond))
+}
+
+// parseSeed decodes a hex integer such as "0x1f" or "1f"; an empty string yields nil.
+func parseSeed(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	s = strings.TrimPrefix(s, "0x")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	seed, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed: %w", err)
+	}
+	return seed, nil
+}
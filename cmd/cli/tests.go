@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+)
+
+func runListTests(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("tests", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEST\tMIN BITS\tPARAMETER\tDEFAULT\tRANGE\tDESCRIPTION")
+	for _, t := range nist.DefaultRegistry.Tests() {
+		params := t.Params()
+		if len(params) == 0 {
+			fmt.Fprintf(tw, "%s\t%d\t-\t\t\t\n", t.Name(), t.MinBits())
+			continue
+		}
+		for i, p := range params {
+			name, minBits := "", ""
+			if i == 0 {
+				name, minBits = t.Name(), fmt.Sprint(t.MinBits())
+			}
+			bounds := fmt.Sprintf("%d-%d", p.Min, p.Max)
+			if p.Max == 0 {
+				bounds = fmt.Sprintf(">= %d", p.Min)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", name, minBits, p.Name, p.Default, bounds, p.Description)
+		}
+	}
+	return tw.Flush()
+}
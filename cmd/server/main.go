@@ -6,12 +6,16 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"path"
+	"slices"
 	"syscall"
 	"time"
 
@@ -23,42 +27,88 @@ import (
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/audit"
 	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/config"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/drift"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/metrics"
 	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/middleware"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/notify"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/rotate"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/selftest"
 	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/service"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/tracing"
 	pb "github.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb"
 )
 
 func main() {
-	if err := run(context.Background()); err != nil {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML configuration file (default $CONFIG_FILE)")
+	flag.Parse()
+
+	if err := run(context.Background(), *configPath); err != nil {
 		log.Fatal().Err(err).Msg("Application failed")
 	}
 }
 
-func run(ctx context.Context) error {
+// run starts the service with the configuration file at configPath, which may be empty
+func run(ctx context.Context, configPath string) error {
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	// Setup logging
-	setupLogging(cfg.LogLevel)
+	closeLog, err := setupLogging(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure logging: %w", err)
+	}
+	defer closeLog()
 
 	log.Info().
 		Int("grpc_port", cfg.GRPCPort).
 		Int("metrics_port", cfg.MetricsPort).
 		Str("log_level", cfg.LogLevel).
+		Str("log_format", cfg.LogFormat).
+		Str("config_file", configPath).
 		Bool("auth_enabled", cfg.AuthEnabled).
+		Bool("selftest_enabled", cfg.SelfTestEnabled).
+		Bool("sources_enabled", cfg.SourcesEnabled).
+		Str("tracing_exporter", cfg.TracingExporter).
 		Msg("Starting NIST Statistical Test Service")
 
+	// OpenTelemetry tracing (opt-in)
+	shutdownTracing, err := tracing.Setup(ctx, tracingConfig(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to configure tracing: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Failed to flush traces")
+		}
+	}()
+
+	// Known-answer self-test gate (health reports NOT_SERVING until it passes)
+	healthServer := health.NewServer()
+	var monitor *selftest.Monitor
+	var selfTestStatus func() selftest.Status
+	if cfg.SelfTestEnabled {
+		monitor, err = newSelfTestMonitor(cfg, healthServer)
+		if err != nil {
+			return fmt.Errorf("failed to configure self-test: %w", err)
+		}
+		selfTestStatus = monitor.Status
+	}
+
 	// Start Prometheus metrics server
 	metricsLn, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.MetricsPort))
 	if err != nil {
 		return fmt.Errorf("failed to create metrics listener: %w", err)
 	}
-	metricsSrv := startMetricsServer(metricsLn)
+	metricsSrv := startMetricsServer(metricsLn, selfTestStatus)
 	defer metricsSrv.Close()
 
 	// Create gRPC listener
@@ -67,12 +117,28 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("failed to create gRPC listener: %w", err)
 	}
 
-	unaryInterceptors, err := buildUnaryInterceptors(cfg)
+	auditLog, err := openAuditLog(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if auditLog != nil {
+		defer auditLog.Close()
+	}
+
+	unaryInterceptors, admission, err := buildUnaryInterceptors(cfg, auditLog)
 	if err != nil {
 		return fmt.Errorf("failed to configure gRPC server: %w", err)
 	}
 
-	grpcServer, err := runGRPCServer(cfg, unaryInterceptors)
+	notifier, err := openNotifier(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure notifications: %w", err)
+	}
+	if notifier != nil {
+		defer notifier.Close()
+	}
+
+	grpcServer, nistServer, err := runGRPCServer(cfg, unaryInterceptors, healthServer, auditLog, notifier)
 	if err != nil {
 		return fmt.Errorf("failed to create gRPC server: %w", err)
 	}
@@ -82,6 +148,20 @@ func run(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	if monitor != nil {
+		go monitor.Start(ctx)
+	}
+
+	// Reload the non-listener settings on SIGHUP
+	r := &reloader{
+		path:      configPath,
+		running:   cfg,
+		admission: admission,
+		service:   nistServer,
+		notifier:  notifier,
+	}
+	go r.watch(ctx)
+
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -109,15 +189,42 @@ func run(ctx context.Context) error {
 	return nil
 }
 
-// setupLogging configures the zerolog logger
-func setupLogging(level string) {
-	// Pretty logging for development
-	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out:        os.Stderr,
-		TimeFormat: time.RFC3339,
-	})
+// setupLogging configures the zerolog logger: console or JSON output on stderr, a JSON copy
+// in the rotated LOG_FILE and sampling of debug lines. The returned function closes the file.
+func setupLogging(cfg *config.Config) (func() error, error) {
+	var stderr io.Writer = os.Stderr
+	if cfg.LogFormat != "json" {
+		// Pretty logging for development
+		stderr = zerolog.ConsoleWriter{
+			Out:        os.Stderr,
+			TimeFormat: time.RFC3339,
+		}
+	}
+
+	out, closeLog := stderr, func() error { return nil }
+	if cfg.LogFile != "" {
+		file, err := rotate.Open(cfg.LogFile, cfg.LogFileMaxSizeMB, cfg.LogFileMaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+		out, closeLog = zerolog.MultiLevelWriter(stderr, file), file.Close
+	}
+
+	logger := zerolog.New(out).With().Timestamp().Logger()
+	if cfg.LogDebugSample > 1 {
+		logger = logger.Sample(zerolog.LevelSampler{
+			DebugSampler: &zerolog.BasicSampler{N: uint32(cfg.LogDebugSample)}, //nolint:gosec // validated non-negative
+		})
+	}
+	log.Logger = logger
+
+	setLogLevel(cfg.LogLevel)
 
-	// Set log level
+	return closeLog, nil
+}
+
+// setLogLevel sets the global log level, info if level is not known
+func setLogLevel(level string) {
 	switch level {
 	case "debug":
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
@@ -132,8 +239,68 @@ func setupLogging(level string) {
 	}
 }
 
-// startMetricsServer starts the Prometheus metrics HTTP server
-func startMetricsServer(ln net.Listener) *http.Server {
+// newSelfTestMonitor creates the known-answer self-test monitor
+func newSelfTestMonitor(cfg *config.Config, healthServer *health.Server) (*selftest.Monitor, error) {
+	monitor, err := selftest.NewMonitor(cfg.SelfTestDatasets, cfg.SelfTestInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.SelfTestStatus.Set(-1)
+
+	monitor.OnResult(selfTestListener(cfg, healthServer))
+
+	return monitor, nil
+}
+
+// selfTestListener propagates a self-test outcome to the gRPC health service, metrics and logs
+func selfTestListener(cfg *config.Config, healthServer *health.Server) selftest.Listener {
+	return func(status selftest.Status, report *selftest.Report, err error) {
+		servingStatus := healthpb.HealthCheckResponse_NOT_SERVING
+		if status == selftest.StatusPassed {
+			servingStatus = healthpb.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus("", servingStatus)
+
+		if err != nil {
+			metrics.SelfTestStatus.Set(0)
+			metrics.SelfTestRunsTotal.WithLabelValues("error").Inc()
+			log.Error().
+				Err(err).
+				Msg("Known-answer self-test could not run; reporting NOT_SERVING")
+			return
+		}
+
+		metrics.RecordSelfTest(report.Passed, report.Duration.Seconds())
+
+		if report.Passed {
+			log.Info().
+				Strs("datasets", cfg.SelfTestDatasets).
+				Int("checks", len(report.Checks)).
+				Dur("duration", report.Duration).
+				Msg("Known-answer self-test passed")
+			return
+		}
+
+		for _, c := range report.Failed() {
+			log.Error().
+				Str("dataset", c.Dataset).
+				Str("test", c.Test).
+				Float64("expected_p_value", c.Expected).
+				Float64("p_value", c.PValue).
+				Float64("abs_diff", c.AbsDiff).
+				Msg("Known-answer self-test mismatch")
+		}
+		log.Error().
+			Int("failed_checks", len(report.Failed())).
+			Msg("Known-answer self-test failed; reporting NOT_SERVING")
+	}
+}
+
+// startMetricsServer starts the Prometheus metrics HTTP server.
+// selfTestStatus may be nil when the self-test gate is disabled; otherwise /health
+// reports 503 until the self-test has passed, like the gRPC health service.
+func startMetricsServer(ln net.Listener, selfTestStatus func() selftest.Status) *http.Server {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
 
@@ -142,11 +309,21 @@ func startMetricsServer(ln net.Listener) *http.Server {
 			"status":  "healthy",
 			"version": service.Version,
 		}
+		code := http.StatusOK
+
+		if selfTestStatus != nil {
+			status := selfTestStatus()
+			resp["self_test"] = status.String()
+			if status != selftest.StatusPassed {
+				resp["status"] = "unhealthy"
+				code = http.StatusServiceUnavailable
+			}
+		}
 
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			log.Error().Err(err).Msg("failed to write health response")
-			http.Error(w, "failed to encode health response", http.StatusInternalServerError)
 		}
 	})
 
@@ -171,40 +348,163 @@ func startMetricsServer(ln net.Listener) *http.Server {
 	return srv
 }
 
-// runGRPCServer creates and configures the gRPC server
-func runGRPCServer(cfg *config.Config, unaryInterceptors []grpc.UnaryServerInterceptor) (*grpc.Server, error) {
-	serverOpts, err := buildGRPCServerOptions(cfg, unaryInterceptors)
+// openAuditLog opens the audit trail configured by AUDIT_LOG_PATH, or returns nil if it is disabled
+func openAuditLog(cfg *config.Config) (*audit.Log, error) {
+	if cfg.AuditLogPath == "" {
+		return nil, nil
+	}
+
+	auditLog, err := audit.Open(audit.Config{
+		Path:       cfg.AuditLogPath,
+		MaxSizeMB:  cfg.AuditLogMaxSizeMB,
+		MaxBackups: cfg.AuditLogMaxBackups,
+		HashChain:  cfg.AuditLogHashChain,
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	log.Info().
+		Str("path", cfg.AuditLogPath).
+		Bool("hash_chain", cfg.AuditLogHashChain).
+		Msg("Audit log enabled")
+	return auditLog, nil
+}
+
+// openNotifier starts the dispatcher of the configured notifiers, or returns nil if
+// notifications are disabled
+func openNotifier(cfg *config.Config) (*notify.Dispatcher, error) {
+	if !cfg.NotificationsEnabled() {
+		return nil, nil
+	}
+
+	rules, err := notify.ParseRules(cfg.NotifyRules, cfg.NotifyRunMinFailures)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifiers []notify.Notifier
+	if cfg.NotifyWebhookURL != "" {
+		if cfg.NotifyWebhookSecret == "" {
+			log.Warn().Msg("NOTIFY_WEBHOOK_SECRET is not set, webhook notifications are not signed")
+		}
+		notifiers = append(notifiers, notify.NewWebhook(cfg.NotifyWebhookURL, cfg.NotifyWebhookSecret, cfg.NotifyWebhookMaxRetries, notify.DefaultWebhookBackoff))
+	}
+	if cfg.NotifyFile != "" {
+		w, err := notify.OpenFile(cfg.NotifyFile)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, w)
+	}
+
+	log.Info().
+		Bool("webhook", cfg.NotifyWebhookURL != "").
+		Str("file", cfg.NotifyFile).
+		Strs("rules", cfg.NotifyRules).
+		Msg("Notifications enabled")
+	return notify.NewDispatcher(rules, cfg.NotifyTimeout, notifiers...), nil
+}
+
+// runGRPCServer creates and configures the gRPC server; auditLog and notifier may be nil
+func runGRPCServer(cfg *config.Config, unaryInterceptors []grpc.UnaryServerInterceptor, healthServer *health.Server, auditLog *audit.Log, notifier *notify.Dispatcher) (*grpc.Server, *service.Server, error) {
+	serverOpts, err := buildGRPCServerOptions(cfg, unaryInterceptors)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	grpcServer := grpc.NewServer(serverOpts...)
 
 	// Register NIST SP 800-22 service
-	nistServer := service.NewServer()
+	serviceOpts := []service.Option{
+		service.WithDFTMemoryLimit(int64(cfg.DFTMemoryLimitMB) << 20),
+		service.WithUniformityWindow(cfg.MetricsUniformityWindow),
+	}
+	if cfg.SourcesEnabled {
+		serviceOpts = append(serviceOpts, service.WithSourceTesting(cfg.SourcesAllowedPaths))
+		log.Warn().
+			Strs("allowed_paths", cfg.SourcesAllowedPaths).
+			Msg("Host source testing enabled")
+	}
+	if cfg.PrecisePValuesEnabled {
+		serviceOpts = append(serviceOpts, service.WithPrecisePValues())
+	}
+	if auditLog != nil {
+		serviceOpts = append(serviceOpts, service.WithAuditLog(auditLog))
+	}
+	if notifier != nil {
+		serviceOpts = append(serviceOpts, service.WithNotifications(notifier))
+	}
+	if cfg.DriftWindow > 0 {
+		serviceOpts = append(serviceOpts, service.WithDriftMonitor(drift.NewMonitor(cfg.DriftWindow, cfg.DriftMaxSources)))
+	}
+	nistServer := service.NewServer(serviceOpts...)
 	pb.RegisterSp80022TestServiceServer(grpcServer, nistServer)
 
-	// Register health check service
-	healthServer := health.NewServer()
+	// Register health check service; with the self-test gate enabled it reports
+	// NOT_SERVING until the first known-answer self-test has passed
 	healthpb.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	if cfg.SelfTestEnabled {
+		healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	} else {
+		healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	}
 
 	// Register reflection for grpcurl
 	reflection.Register(grpcServer)
 
-	return grpcServer, nil
+	return grpcServer, nistServer, nil
 }
 
-func buildUnaryInterceptors(cfg *config.Config) ([]grpc.UnaryServerInterceptor, error) {
+// tracingShutdownTimeout bounds the export of pending spans at shutdown
+const tracingShutdownTimeout = 5 * time.Second
+
+// tracingConfig returns the tracing settings of cfg
+func tracingConfig(cfg *config.Config) tracing.Config {
+	return tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingOTLPEndpoint,
+		Insecure:    cfg.TracingOTLPInsecure,
+		SampleRatio: cfg.TracingSampleRatio,
+	}
+}
+
+// healthMethods bypass authentication and admission control
+var healthMethods = []string{
+	"/grpc.health.v1.Health/Check",
+	"/grpc.health.v1.Health/Watch",
+}
+
+// buildUnaryInterceptors returns the interceptor chain and the admission controller in it;
+// authorization denials are written to auditLog, which may be nil
+func buildUnaryInterceptors(cfg *config.Config, auditLog *audit.Log) ([]grpc.UnaryServerInterceptor, *middleware.Admission, error) {
 	interceptors := []grpc.UnaryServerInterceptor{
 		middleware.UnaryRequestIDInterceptor(),
 		loggingInterceptor,
 	}
 
-	if !cfg.AuthEnabled {
-		return interceptors, nil
+	if cfg.AuthEnabled {
+		authInterceptor, err := buildAuthInterceptor(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		authzInterceptor, err := buildAuthorizationInterceptor(cfg, auditLog)
+		if err != nil {
+			return nil, nil, err
+		}
+		interceptors = append(interceptors, authInterceptor, authzInterceptor)
+	}
+
+	// Admission control runs after authentication so that callers are rate limited by token subject
+	admissionCfg, err := admissionConfig(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
+	admission := middleware.NewAdmission(admissionCfg)
+	return append(interceptors, admission.Intercept), admission, nil
+}
 
+func buildAuthInterceptor(cfg *config.Config) (grpc.UnaryServerInterceptor, error) {
 	validatorBuilder := grpcserver.NewValidatorBuilder(cfg.AuthIssuer, cfg.AuthAudience)
 	if cfg.AuthJWKSURL != "" {
 		validatorBuilder = validatorBuilder.WithJWKSURL(cfg.AuthJWKSURL)
@@ -221,21 +521,108 @@ func buildUnaryInterceptors(cfg *config.Config) ([]grpc.UnaryServerInterceptor,
 		Str("jwks_url", cfg.AuthJWKSURL).
 		Msg("gRPC authentication enabled")
 
-	authInterceptor := grpcserver.UnaryServerInterceptor(
+	return grpcserver.UnaryServerInterceptor(
 		validator,
-		grpcserver.WithExemptMethods(
-			"/grpc.health.v1.Health/Check",
-			"/grpc.health.v1.Health/Watch",
-		),
-	)
+		grpcserver.WithExemptMethods(healthMethods...),
+	), nil
+}
+
+// buildAuthorizationInterceptor enforces AUTH_METHOD_SCOPES, whose RPC names are resolved
+// against the service descriptor, and writes denials to auditLog
+func buildAuthorizationInterceptor(cfg *config.Config, auditLog *audit.Log) (grpc.UnaryServerInterceptor, error) {
+	methodScopes, err := cfg.MethodScopes()
+	if err != nil {
+		return nil, err
+	}
+
+	desc := pb.Sp80022TestService_ServiceDesc
+	scopes := make(map[string][]string, len(methodScopes))
+	for method, accepted := range methodScopes {
+		log.Info().
+			Str("method", method).
+			Strs("scopes", accepted).
+			Msg("RPC restricted to scopes")
+		if method == middleware.AnyMethod {
+			scopes[method] = accepted
+			continue
+		}
+		if !slices.ContainsFunc(desc.Methods, func(m grpc.MethodDesc) bool { return m.MethodName == method }) {
+			return nil, fmt.Errorf("invalid AUTH_METHOD_SCOPES: unknown RPC %q", method)
+		}
+		scopes["/"+desc.ServiceName+"/"+method] = accepted
+	}
+
+	return middleware.UnaryAuthorizationInterceptor(scopes, auditDenial(auditLog), healthMethods...), nil
+}
+
+// auditDenial returns a recorder that writes calls rejected for missing scopes to the
+// audit trail, so that denials are covered by its hash chain, or nil without a trail
+func auditDenial(auditLog *audit.Log) middleware.DenialRecorder {
+	if auditLog == nil {
+		return nil
+	}
+	return func(ctx context.Context, method string, err error) {
+		rec := audit.Record{
+			Time:      time.Now().UTC(),
+			RequestID: middleware.GetRequestID(ctx),
+			Identity:  middleware.Identity(ctx),
+			Method:    path.Base(method),
+			Verdict:   "denied",
+			Error:     status.Convert(err).Message(),
+		}
+		if err := auditLog.Write(rec); err != nil {
+			middleware.Logger(ctx).Error().
+				Err(err).
+				Msg("Audit log write failed")
+		}
+	}
+}
+
+// admissionConfig returns the admission control settings of cfg
+func admissionConfig(cfg *config.Config) (middleware.AdmissionConfig, error) {
+	overrides, err := cfg.RateLimitOverrides()
+	if err != nil {
+		return middleware.AdmissionConfig{}, err
+	}
+	limits := make(map[string]middleware.RateLimit, len(overrides))
+	for identity, limit := range overrides {
+		limits[identity] = middleware.RateLimit{Rate: limit.RPS, Burst: limit.Burst}
+	}
+
+	log.Info().
+		Int("max_inflight_bits", cfg.AdmissionMaxInFlightBits).
+		Float64("rate_limit_rps", cfg.RateLimitRPS).
+		Int("rate_limit_burst", cfg.RateLimitBurst).
+		Int("rate_limit_identities", len(limits)).
+		Msg("Admission control configured")
+
+	return middleware.AdmissionConfig{
+		MaxInFlightBits: int64(cfg.AdmissionMaxInFlightBits),
+		DefaultBits:     service.DefaultGeneratedBits,
+		DatasetBits:     selftest.ReferenceBits,
+		DefaultDatasets: len(selftest.Datasets),
+		ExtraBits:       preciseBits,
+		RateLimit:       middleware.RateLimit{Rate: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst},
+		Limits:          limits,
+		ExemptMethods:   healthMethods,
+	}, nil
+}
 
-	return append(interceptors, authInterceptor), nil
+// preciseBits weights a request for precise p-values by service.PreciseBits
+func preciseBits(req interface{}) int64 {
+	if r, ok := req.(*pb.Sp80022TestRequest); ok && r.GetConfig().GetPrecisePValues() {
+		return service.PreciseBits
+	}
+	return 0
 }
 
 func buildGRPCServerOptions(cfg *config.Config, unaryInterceptors []grpc.UnaryServerInterceptor) ([]grpc.ServerOption, error) {
 	opts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(unaryInterceptors...),
 	}
+	if tracingConfig(cfg).Enabled() {
+		opts = append(opts, tracing.ServerOption())
+	}
 
 	if !cfg.TLSEnabled {
 		return opts, nil
@@ -287,7 +674,7 @@ func tlsVersionString(version uint16) string {
 	}
 }
 
-// loggingInterceptor logs all gRPC requests with request ID
+// loggingInterceptor logs all gRPC requests with the request logger of the context
 func loggingInterceptor(
 	ctx context.Context,
 	req interface{},
@@ -296,25 +683,21 @@ func loggingInterceptor(
 ) (interface{}, error) {
 	start := time.Now()
 
-	// Get request ID from context
-	requestID := middleware.GetRequestID(ctx)
-
 	// Call the handler
 	resp, err := handler(ctx, req)
 
-	// Log the request
+	// Log the request, tagged with the request ID by the request logger
 	duration := time.Since(start)
+	logger := middleware.Logger(ctx)
 
 	if err != nil {
-		log.Error().
+		logger.Error().
 			Err(err).
-			Str("request_id", requestID).
 			Str("method", info.FullMethod).
 			Dur("duration", duration).
 			Msg("gRPC request failed")
 	} else {
-		log.Debug().
-			Str("request_id", requestID).
+		logger.Debug().
 			Str("method", info.FullMethod).
 			Dur("duration", duration).
 			Msg("gRPC request completed")
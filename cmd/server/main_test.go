@@ -2,24 +2,36 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/audit"
 	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/config"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/selftest"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/service"
+	pb "github.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb"
 	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 func TestSetupLogging(t *testing.T) {
-	origLevel := zerolog.GlobalLevel()
-	defer zerolog.SetGlobalLevel(origLevel)
+	origLevel, origLogger := zerolog.GlobalLevel(), zlog.Logger
+	defer func() { zerolog.SetGlobalLevel(origLevel); zlog.Logger = origLogger }()
 
 	tests := []struct {
 		level    string
@@ -34,7 +46,11 @@ func TestSetupLogging(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.level, func(t *testing.T) {
-			setupLogging(tt.level)
+			closeLog, err := setupLogging(&config.Config{LogLevel: tt.level})
+			if err != nil {
+				t.Fatalf("setupLogging failed: %v", err)
+			}
+			defer closeLog()
 			if zerolog.GlobalLevel() != tt.expected {
 				t.Errorf("expected level %v, got %v", tt.expected, zerolog.GlobalLevel())
 			}
@@ -42,11 +58,64 @@ func TestSetupLogging(t *testing.T) {
 	}
 }
 
+func TestSetupLoggingFile(t *testing.T) {
+	origLevel, origLogger := zerolog.GlobalLevel(), zlog.Logger
+	defer func() { zerolog.SetGlobalLevel(origLevel); zlog.Logger = origLogger }()
+
+	path := filepath.Join(t.TempDir(), "service.log")
+	closeLog, err := setupLogging(&config.Config{LogLevel: "debug", LogFormat: "json", LogFile: path, LogDebugSample: 10})
+	if err != nil {
+		t.Fatalf("setupLogging failed: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		zlog.Debug().Int("i", i).Msg("sampled")
+	}
+	zlog.Info().Str("request_id", "req-1").Msg("kept")
+	if err := closeLog(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var debug, info int
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		if _, ok := entry["time"]; !ok {
+			t.Errorf("log line %q has no timestamp", line)
+		}
+		switch entry["level"] {
+		case "debug":
+			debug++
+		case "info":
+			info++
+			if entry["request_id"] != "req-1" {
+				t.Errorf("unexpected info line %q", line)
+			}
+		}
+	}
+	if debug != 2 || info != 1 {
+		t.Errorf("got %d debug and %d info lines, want 2 sampled debug lines and 1 info line", debug, info)
+	}
+
+	t.Run("unwritable file", func(t *testing.T) {
+		if _, err := setupLogging(&config.Config{LogLevel: "info", LogFile: filepath.Join(t.TempDir(), "missing", "service.log")}); err == nil {
+			t.Fatal("expected error for a log file in a missing directory")
+		}
+	})
+}
+
 func TestLoggingInterceptor(t *testing.T) {
 	// Setup
-	origLevel := zerolog.GlobalLevel()
-	defer zerolog.SetGlobalLevel(origLevel)
-	setupLogging("debug")
+	origLevel, origLogger := zerolog.GlobalLevel(), zlog.Logger
+	defer func() { zerolog.SetGlobalLevel(origLevel); zlog.Logger = origLogger }()
+	if _, err := setupLogging(&config.Config{LogLevel: "debug"}); err != nil {
+		t.Fatal(err)
+	}
 	ctx := context.Background()
 	req := "test request"
 	info := &grpc.UnaryServerInfo{
@@ -72,7 +141,7 @@ func TestStartMetricsServer(t *testing.T) {
 	// No defer ln.Close() here, server will close it
 
 	// Run in goroutine
-	srv := startMetricsServer(ln)
+	srv := startMetricsServer(ln, nil)
 	defer srv.Close()
 
 	// Poll health endpoint instead of sleeping blindly
@@ -97,12 +166,12 @@ func TestRunGRPCServer(t *testing.T) {
 	ln := mustListen(t)
 	defer ln.Close()
 
-	interceptors, err := buildUnaryInterceptors(&config.Config{})
+	interceptors, _, err := buildUnaryInterceptors(&config.Config{}, nil)
 	if err != nil {
 		t.Fatalf("failed to build interceptors: %v", err)
 	}
 
-	srv, err := runGRPCServer(&config.Config{}, interceptors)
+	srv, _, err := runGRPCServer(&config.Config{}, interceptors, health.NewServer(), nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create gRPC server: %v", err)
 	}
@@ -144,7 +213,7 @@ func TestRun(t *testing.T) {
 	// Run in goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		errChan <- run(ctx)
+		errChan <- run(ctx, "")
 	}()
 
 	// Give it a moment to start
@@ -168,7 +237,7 @@ func TestRunConfigError(t *testing.T) {
 	os.Setenv("GRPC_PORT", "-1")
 	defer os.Unsetenv("GRPC_PORT")
 
-	if err := run(context.Background()); err == nil {
+	if err := run(context.Background(), ""); err == nil {
 		t.Error("expected error for invalid config, got nil")
 	}
 }
@@ -183,7 +252,7 @@ func TestRunPortInUse(t *testing.T) {
 	defer os.Unsetenv("GRPC_PORT")
 
 	// run should fail to bind gRPC port
-	if err := run(context.Background()); err == nil {
+	if err := run(context.Background(), ""); err == nil {
 		t.Error("expected error for port in use, got nil")
 	}
 }
@@ -223,7 +292,7 @@ func TestRunSignal(t *testing.T) {
 	// Run in goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		errChan <- run(context.Background())
+		errChan <- run(context.Background(), "")
 	}()
 
 	// Give it a moment to start
@@ -249,6 +318,261 @@ func TestRunSignal(t *testing.T) {
 	}
 }
 
+func TestHealthEndpointReflectsSelfTest(t *testing.T) {
+	tests := []struct {
+		status   selftest.Status
+		wantCode int
+	}{
+		{selftest.StatusPending, http.StatusServiceUnavailable},
+		{selftest.StatusPassed, http.StatusOK},
+		{selftest.StatusFailed, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status.String(), func(t *testing.T) {
+			ln := mustListen(t)
+			status := tt.status
+			srv := startMetricsServer(ln, func() selftest.Status { return status })
+			defer srv.Close()
+
+			var resp *http.Response
+			var err error
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				resp, err = http.Get(fmt.Sprintf("http://%s/health", ln.Addr().String()))
+				if err == nil {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("failed to get health: %v", err)
+				}
+				time.Sleep(25 * time.Millisecond)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, resp.StatusCode)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode health response: %v", err)
+			}
+			if body["self_test"] != tt.status.String() {
+				t.Fatalf("expected self_test=%s, got %q", tt.status, body["self_test"])
+			}
+		})
+	}
+}
+
+func TestSelfTestListener(t *testing.T) {
+	cfg := &config.Config{SelfTestEnabled: true, SelfTestDatasets: []string{"e"}}
+	healthServer := health.NewServer()
+	listener := selfTestListener(cfg, healthServer)
+
+	check := func(want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("health check failed: %v", err)
+		}
+		if resp.Status != want {
+			t.Fatalf("expected %s, got %s", want, resp.Status)
+		}
+	}
+
+	listener(selftest.StatusPassed, &selftest.Report{Passed: true}, nil)
+	check(healthpb.HealthCheckResponse_SERVING)
+
+	listener(selftest.StatusFailed, &selftest.Report{
+		Checks: []selftest.Check{{Dataset: "e", Test: "runs", Expected: 0.561917, PValue: 0.5, AbsDiff: 0.061917}},
+	}, nil)
+	check(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	listener(selftest.StatusPassed, &selftest.Report{Passed: true}, nil)
+	listener(selftest.StatusFailed, nil, fmt.Errorf("boom"))
+	check(healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestNewSelfTestMonitor(t *testing.T) {
+	healthServer := health.NewServer()
+
+	if _, err := newSelfTestMonitor(&config.Config{SelfTestDatasets: []string{"unknown"}}, healthServer); err == nil {
+		t.Error("expected error for unknown dataset")
+	}
+
+	monitor, err := newSelfTestMonitor(&config.Config{SelfTestDatasets: []string{"e"}, SelfTestInterval: time.Hour}, healthServer)
+	if err != nil {
+		t.Fatalf("newSelfTestMonitor failed: %v", err)
+	}
+	if monitor.Status() != selftest.StatusPending {
+		t.Fatalf("expected pending status, got %s", monitor.Status())
+	}
+}
+
+func TestRunGRPCServerSelfTestGate(t *testing.T) {
+	healthServer := health.NewServer()
+	cfg := &config.Config{SelfTestEnabled: true}
+
+	srv, _, err := runGRPCServer(cfg, nil, healthServer, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create gRPC server: %v", err)
+	}
+	defer srv.Stop()
+
+	resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING before the self-test ran, got %s", resp.Status)
+	}
+}
+
+func TestBuildUnaryInterceptorsAdmission(t *testing.T) {
+	interceptors, _, err := buildUnaryInterceptors(&config.Config{AdmissionMaxInFlightBits: 1000, RateLimitRPS: 1, RateLimitBurst: 1}, nil)
+	if err != nil {
+		t.Fatalf("failed to build interceptors: %v", err)
+	}
+	if len(interceptors) != 3 {
+		t.Fatalf("expected request ID, logging and admission interceptors, got %d", len(interceptors))
+	}
+
+	if _, _, err := buildUnaryInterceptors(&config.Config{RateLimitIdentities: "ci"}, nil); err == nil {
+		t.Fatal("expected error for malformed RATE_LIMIT_IDENTITIES")
+	}
+}
+
+func TestPreciseBits(t *testing.T) {
+	precise := &pb.Sp80022TestRequest{Config: &pb.Sp80022TestConfig{PrecisePValues: true}}
+	if got := preciseBits(precise); got != service.PreciseBits {
+		t.Errorf("preciseBits = %d, want %d", got, service.PreciseBits)
+	}
+	for _, req := range []interface{}{&pb.Sp80022TestRequest{}, &pb.GenerateAndTestRequest{}} {
+		if got := preciseBits(req); got != 0 {
+			t.Errorf("preciseBits(%T) = %d, want 0", req, got)
+		}
+	}
+}
+
+func TestBuildAuthorizationInterceptor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(audit.Config{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	interceptor, err := buildAuthorizationInterceptor(&config.Config{AuthMethodScopes: "TestSource=nist:admin,*=nist:read"}, auditLog)
+	if err != nil {
+		t.Fatalf("failed to build authorization interceptor: %v", err)
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	for method, want := range map[string]codes.Code{
+		pb.Sp80022TestService_TestSource_FullMethodName:   codes.PermissionDenied,
+		pb.Sp80022TestService_RunTestSuite_FullMethodName: codes.PermissionDenied,
+		"/grpc.health.v1.Health/Check":                    codes.OK,
+	} {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		if got := status.Code(err); got != want {
+			t.Errorf("%s: got %s, want %s", method, got, want)
+		}
+	}
+
+	// Both denials are in the hash chain of the audit trail
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _, err := audit.Verify(strings.NewReader(string(raw)), ""); err != nil || n != 2 {
+		t.Fatalf("Verify = %d, %v; want 2 chained records", n, err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var rec audit.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Verdict != "denied" || (rec.Method != "TestSource" && rec.Method != "RunTestSuite") || !strings.Contains(rec.Error, "requires one of the scopes") {
+			t.Errorf("unexpected denial record: %+v", rec)
+		}
+	}
+
+	if _, err := buildAuthorizationInterceptor(&config.Config{AuthMethodScopes: "DeleteHistory=nist:admin"}, nil); err == nil {
+		t.Fatal("expected error for unknown RPC")
+	}
+}
+
+func TestOpenAuditLog(t *testing.T) {
+	auditLog, err := openAuditLog(&config.Config{})
+	if err != nil || auditLog != nil {
+		t.Fatalf("expected no audit log when disabled, got %v, %v", auditLog, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err = openAuditLog(&config.Config{AuditLogPath: path, AuditLogHashChain: true})
+	if err != nil {
+		t.Fatalf("openAuditLog failed: %v", err)
+	}
+	defer auditLog.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("audit log not created: %v", err)
+	}
+
+	if _, err := openAuditLog(&config.Config{AuditLogPath: filepath.Join(path, "sub", "audit.jsonl")}); err == nil {
+		t.Fatal("expected error for an unwritable path")
+	}
+}
+
+func TestOpenNotifier(t *testing.T) {
+	notifier, err := openNotifier(&config.Config{})
+	if err != nil || notifier != nil {
+		t.Fatalf("expected no notifier when disabled, got %v, %v", notifier, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	cfg := &config.Config{
+		NotifyWebhookURL:     "http://127.0.0.1:1/hook",
+		NotifyFile:           path,
+		NotifyTimeout:        time.Second,
+		NotifyRules:          []string{"run_failed"},
+		NotifyRunMinFailures: 1,
+	}
+	notifier, err = openNotifier(cfg)
+	if err != nil {
+		t.Fatalf("openNotifier failed: %v", err)
+	}
+	if err := notifier.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("notification file not created: %v", err)
+	}
+
+	cfg.NotifyFile = filepath.Join(path, "sub", "notifications.jsonl")
+	if _, err := openNotifier(cfg); err == nil {
+		t.Fatal("expected error for an unwritable notification file")
+	}
+}
+
+func TestBuildGRPCServerOptionsTracing(t *testing.T) {
+	for _, tt := range []struct {
+		exporter string
+		want     int
+	}{
+		{"none", 1},
+		{"stdout", 2},
+	} {
+		t.Run(tt.exporter, func(t *testing.T) {
+			opts, err := buildGRPCServerOptions(&config.Config{TracingExporter: tt.exporter}, nil)
+			if err != nil {
+				t.Fatalf("buildGRPCServerOptions failed: %v", err)
+			}
+			if len(opts) != tt.want {
+				t.Errorf("got %d server options, want %d", len(opts), tt.want)
+			}
+		})
+	}
+}
+
 func mustListen(t *testing.T) net.Listener {
 	t.Helper()
 	ln, err := net.Listen("tcp", ":0")
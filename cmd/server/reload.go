@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/config"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/middleware"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/notify"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/service"
+)
+
+// reloader applies the reloadable settings of the configuration to the running server.
+// Listeners, TLS, authentication and the other settings reported by
+// config.Config.RestartRequired keep their startup values until the next restart.
+type reloader struct {
+	path    string
+	running *config.Config
+
+	admission *middleware.Admission
+	service   *service.Server
+	// notifier is nil when notifications are disabled
+	notifier *notify.Dispatcher
+}
+
+// watch reloads the configuration on every SIGHUP until ctx is done
+func (r *reloader) watch(ctx context.Context) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-sigChan:
+			if err := r.reload(); err != nil {
+				log.Error().
+					Err(err).
+					Str("config_file", r.path).
+					Msg("Configuration reload failed, keeping the current settings")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reload loads and validates the configuration and applies its reloadable settings;
+// nothing is applied if it is invalid
+func (r *reloader) reload() error {
+	next, err := config.Load(r.path)
+	if err != nil {
+		return err
+	}
+	admissionCfg, err := admissionConfig(next)
+	if err != nil {
+		return err
+	}
+	var rules notify.Rules
+	if r.notifier != nil {
+		if rules, err = notify.ParseRules(next.NotifyRules, next.NotifyRunMinFailures); err != nil {
+			return fmt.Errorf("invalid NOTIFY_RULES: %w", err)
+		}
+	}
+
+	if changed := r.running.RestartRequired(next); len(changed) > 0 {
+		log.Warn().
+			Strs("settings", changed).
+			Msg("Changed settings take effect after a restart")
+	}
+
+	setLogLevel(next.LogLevel)
+	r.admission.Reconfigure(admissionCfg)
+	r.service.SetDFTMemoryLimit(int64(next.DFTMemoryLimitMB) << 20)
+	r.service.SetSourceTesting(next.SourcesEnabled, next.SourcesAllowedPaths)
+	r.service.SetPrecisePValues(next.PrecisePValuesEnabled)
+	if r.notifier != nil {
+		r.notifier.SetRules(rules)
+	}
+
+	log.Info().
+		Str("config_file", r.path).
+		Str("log_level", next.LogLevel).
+		Bool("sources_enabled", next.SourcesEnabled).
+		Msg("Configuration reloaded")
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/config"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/middleware"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/notify"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/service"
+)
+
+func TestReload(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("log_level: info\nselftest_enabled: false\nnotify_file: stdout\n")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	setLogLevel(cfg.LogLevel)
+
+	admissionCfg, err := admissionConfig(cfg)
+	if err != nil {
+		t.Fatalf("admissionConfig failed: %v", err)
+	}
+	notifier := notify.NewDispatcher(notify.Rules{RunMinFailures: 1}, time.Second)
+	defer notifier.Close()
+	r := &reloader{
+		path:      path,
+		running:   cfg,
+		admission: middleware.NewAdmission(admissionCfg),
+		service:   service.NewServer(),
+		notifier:  notifier,
+	}
+
+	write("log_level: warn\nselftest_enabled: false\nnotify_file: stdout\nnotify_rules: [source_fail]\ngrpc_port: 9000\n")
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Errorf("log level = %s, want warn", zerolog.GlobalLevel())
+	}
+	if notifier.Dispatch(notify.Event{Kind: notify.KindRunFailed, FailedTests: []string{"runs"}}) {
+		t.Error("notification rules not reloaded")
+	}
+
+	// An invalid file is rejected as a whole
+	write("log_level: debug\nselftest_enabled: false\nnotify_file: stdout\nrate_limit_rps: -1\n")
+	if err := r.reload(); err == nil {
+		t.Fatal("expected error for an invalid configuration")
+	}
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Errorf("log level changed to %s by an invalid configuration", zerolog.GlobalLevel())
+	}
+}
@@ -0,0 +1,239 @@
+// Package audit writes an append-only JSON-lines trail of the analyses run by the service.
+// Each line is one Record. With hash chaining every line carries the SHA-256 of the
+// previous line's hash and its own content, so removing, reordering or editing a line
+// breaks the chain and is detected by Verify.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/rotate"
+)
+
+// Stdout is the Config.Path that writes the trail to standard output.
+const Stdout = "stdout"
+
+// Record is one audit entry.
+type Record struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	// Identity is the caller: JWT subject, client certificate CN or peer address.
+	Identity string `json:"identity"`
+	Method   string `json:"method"`
+	// Source labels the random source the input came from, if known.
+	Source      string `json:"source,omitempty"`
+	InputSHA256 string `json:"input_sha256"`
+	InputBits   int    `json:"input_bits"`
+	// Parameters holds the request parameters as protobuf JSON.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// Verdict is "pass" if the run passed by nist.SuitePassed, "fail" otherwise, "error"
+	// if the tests could not be run and "denied" if the caller lacked the scopes of the
+	// method.
+	Verdict  string   `json:"verdict"`
+	PassRate float64  `json:"pass_rate"`
+	Results  []Result `json:"results,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	PrevHash string   `json:"prev_hash,omitempty"`
+	// Hash is set by Write when hash chaining is enabled and is always the last field.
+	Hash string `json:"hash,omitempty"`
+}
+
+// Result holds the outcome of one test.
+type Result struct {
+	Test   string  `json:"test"`
+	PValue float64 `json:"p_value"`
+	// PValues holds the sub-test p-values of tests with more than one.
+	PValues []float64 `json:"p_values,omitempty"`
+	Passed  bool      `json:"passed"`
+	Skipped bool      `json:"skipped,omitempty"`
+}
+
+// Config configures a Log.
+type Config struct {
+	// Path is the file the trail is appended to, or Stdout.
+	Path string
+	// MaxSizeMB rotates the file once it would grow beyond this size; 0 disables
+	// rotation. Rotated files are renamed to Path.1, Path.2, ... with Path.1 the newest.
+	MaxSizeMB int
+	// MaxBackups is the number of rotated files kept, older ones are removed; 0 keeps
+	// all of them.
+	MaxBackups int
+	// HashChain adds the prev_hash and hash fields to every record.
+	HashChain bool
+}
+
+// Log appends records to the audit trail. It is safe for concurrent use.
+type Log struct {
+	cfg Config
+
+	mu   sync.Mutex
+	w    io.Writer
+	file *rotate.File
+	last string
+}
+
+// Open opens the audit trail for appending. When hash chaining is enabled on an existing
+// file, the chain continues from its last record.
+func Open(cfg Config) (*Log, error) {
+	l := &Log{cfg: cfg}
+	if cfg.Path == Stdout {
+		l.w = os.Stdout
+		return l, nil
+	}
+
+	if cfg.HashChain {
+		last, err := lastHash(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		l.last = last
+	}
+	f, err := rotate.Open(cfg.Path, cfg.MaxSizeMB, cfg.MaxBackups)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	l.file, l.w = f, f
+	return l, nil
+}
+
+// Write appends a record, rotating the file first if it would exceed MaxSizeMB.
+func (l *Log) Write(r Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r.PrevHash, r.Hash = "", ""
+	if l.cfg.HashChain {
+		r.PrevHash = l.last
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encode audit record: %w", err)
+	}
+	var hash string
+	if l.cfg.HashChain {
+		hash = chainHash(r.PrevHash, line)
+		line = append(line[:len(line)-1], fmt.Sprintf(`,"hash":%q}`, hash)...)
+	}
+	line = append(line, '\n')
+
+	if _, err := l.w.Write(line); err != nil {
+		return fmt.Errorf("write audit record: %w", err)
+	}
+	if l.file != nil {
+		if err := l.file.Sync(); err != nil {
+			return fmt.Errorf("sync audit log: %w", err)
+		}
+	}
+	l.last = hash
+	return nil
+}
+
+// Close closes the audit file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// hashSuffix matches the hash field that Write appends to a chained record.
+var hashSuffix = regexp.MustCompile(`,"hash":"([0-9a-f]{64})"}$`)
+
+// chainHash returns the hex SHA-256 of the previous hash followed by the record without
+// its hash field.
+func chainHash(prev string, record []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prev))
+	h.Write(record)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks the hash chain of the records read from r, which must be the lines of
+// one or more audit files concatenated from oldest to newest. prev is the hash the
+// first record must follow; if empty, the first record's prev_hash is trusted, as after
+// the oldest backups were removed by rotation. It returns the number of records and the
+// hash of the last one.
+func Verify(r io.Reader, prev string) (int, string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+
+	n := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		n++
+
+		m := hashSuffix.FindSubmatchIndex(line)
+		if m == nil {
+			return n, prev, fmt.Errorf("record %d: no hash", n)
+		}
+		hash := string(line[m[2]:m[3]])
+		record := append(bytes.Clone(line[:m[0]]), '}')
+
+		var rec Record
+		if err := json.Unmarshal(record, &rec); err != nil {
+			return n, prev, fmt.Errorf("record %d: %w", n, err)
+		}
+		if n > 1 || prev != "" {
+			if rec.PrevHash != prev {
+				return n, prev, fmt.Errorf("record %d (request %s): prev_hash does not match the preceding record", n, rec.RequestID)
+			}
+		}
+		if chainHash(rec.PrevHash, record) != hash {
+			return n, prev, fmt.Errorf("record %d (request %s): content does not match its hash", n, rec.RequestID)
+		}
+		prev = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return n, prev, err
+	}
+	return n, prev, nil
+}
+
+// lastHash returns the hash of the last record in the file at path, or "" if the file
+// does not exist or is empty.
+func lastHash(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // path from configuration
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	var last []byte
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read audit log: %w", err)
+	}
+	if last == nil {
+		return "", nil
+	}
+	m := hashSuffix.FindSubmatch(last)
+	if m == nil {
+		return "", fmt.Errorf("audit log %s: last record has no hash; hash chaining cannot continue", path)
+	}
+	return string(m[1]), nil
+}
@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRecord(i int) Record {
+	return Record{
+		Time:        time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		RequestID:   fmt.Sprintf("req-%d", i),
+		Identity:    "alice",
+		Method:      "RunTestSuite",
+		Source:      "trng-1",
+		InputSHA256: strings.Repeat("ab", 32),
+		InputBits:   1000000,
+		Parameters:  json.RawMessage(`{"tests": ["runs"]}`),
+		Verdict:     "pass",
+		PassRate:    1,
+		Results:     []Result{{Test: "runs", PValue: 0.5, Passed: true}},
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestWriteRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Config{Path: path})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := l.Write(testRecord(i)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(readFile(t, path))), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(lines[2]), &rec); err != nil {
+		t.Fatalf("line is not a record: %v", err)
+	}
+	if rec.RequestID != "req-2" || rec.Source != "trng-1" || string(rec.Parameters) != `{"tests":["runs"]}` || rec.Hash != "" || rec.PrevHash != "" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+func TestHashChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Config{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := l.Write(testRecord(i)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	l.Close()
+
+	// Reopening continues the chain.
+	l, err = Open(Config{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if err := l.Write(testRecord(2)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	l.Close()
+
+	raw := readFile(t, path)
+	n, last, err := Verify(bytes.NewReader(raw), "")
+	if err != nil || n != 3 {
+		t.Fatalf("Verify = %d, %v; want 3 valid records", n, err)
+	}
+	var rec Record
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if err := json.Unmarshal([]byte(lines[2]), &rec); err != nil || rec.Hash != last || rec.PrevHash == "" {
+		t.Fatalf("last record %+v does not carry hash %s", rec, last)
+	}
+
+	tampered := map[string]string{
+		"edited":    strings.Replace(string(raw), `"verdict":"pass"`, `"verdict":"fail"`, 1),
+		"removed":   lines[0] + "\n" + lines[2] + "\n",
+		"reordered": lines[1] + "\n" + lines[0] + "\n" + lines[2] + "\n",
+		"unhashed":  string(raw) + `{"request_id":"forged"}` + "\n",
+	}
+	for name, content := range tampered {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := Verify(strings.NewReader(content), ""); err == nil {
+				t.Error("tampering not detected")
+			}
+		})
+	}
+
+	t.Run("expected start", func(t *testing.T) {
+		if _, _, err := Verify(strings.NewReader(lines[1]+"\n"+lines[2]), ""); err != nil {
+			t.Errorf("suffix of the chain rejected: %v", err)
+		}
+		if _, _, err := Verify(strings.NewReader(lines[1]+"\n"+lines[2]), strings.Repeat("0", 64)); err == nil {
+			t.Error("wrong start hash not detected")
+		}
+	})
+}
+
+func TestOpenRejectsUnchainedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte(`{"request_id":"plain"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(Config{Path: path, HashChain: true}); err == nil {
+		t.Fatal("expected error when continuing a chain without hashes")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	l, err := Open(Config{Path: path, MaxSizeMB: 1, MaxBackups: 2, HashChain: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer l.Close()
+
+	// Records of about 100 KB rotate every 10 records.
+	rec := testRecord(0)
+	rec.Error = strings.Repeat("x", 100000)
+	for i := 0; i < 35; i++ {
+		rec.RequestID = fmt.Sprintf("req-%d", i)
+		if err := l.Write(rec); err != nil {
+			t.Fatalf("Write %d failed: %v", i, err)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups, stat %s.3: %v", path, err)
+	}
+	var all bytes.Buffer
+	for _, name := range []string{path + ".2", path + ".1", path} {
+		raw := readFile(t, name)
+		if len(raw) > 1<<20 {
+			t.Errorf("%s has %d bytes, limit is 1 MB", name, len(raw))
+		}
+		all.Write(raw)
+	}
+	n, _, err := Verify(&all, "")
+	if err != nil {
+		t.Fatalf("chain broken across rotated files: %v", err)
+	}
+	if n != 25 {
+		t.Errorf("got %d records in the kept files, want 25", n)
+	}
+}
@@ -3,9 +3,13 @@ package config
 import (
 	"crypto/tls"
 	"fmt"
-	"os"
+	"math"
+	"net/url"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config holds all service configuration
@@ -23,35 +27,161 @@ type Config struct {
 
 	// Metrics server configuration
 	MetricsPort int
+	// MetricsUniformityWindow is the number of submissions per test and source whose sub-test
+	// p-values are evaluated by the uniformity gauge
+	MetricsUniformityWindow int
 
 	// Logging configuration
-	LogLevel string
+	LogLevel  string
+	LogFormat string
+	// LogFile receives a JSON copy of the log, rotated by size; empty disables it
+	LogFile           string
+	LogFileMaxSizeMB  int
+	LogFileMaxBackups int
+	// LogDebugSample keeps one of every N debug lines, 0 keeps all
+	LogDebugSample int
 
 	// Authentication configuration
 	AuthEnabled  bool
 	AuthIssuer   string
 	AuthAudience string
 	AuthJWKSURL  string
+	// AuthMethodScopes maps RPC names to the scopes accepted for them
+	AuthMethodScopes string
+
+	// Known-answer self-test gate
+	SelfTestEnabled  bool
+	SelfTestInterval time.Duration
+	SelfTestDatasets []string
+
+	// Admin-only testing of host random sources (TestSource RPC)
+	SourcesEnabled      bool
+	SourcesAllowedPaths []string
+
+	// Memory ceiling of the Spectral (DFT) test per request
+	DFTMemoryLimitMB int
+
+	// Arbitrary-precision p-values on request (precise_p_values)
+	PrecisePValuesEnabled bool
+
+	// Admission control: in-flight bit budget and per-identity token buckets
+	AdmissionMaxInFlightBits int
+	RateLimitRPS             float64
+	RateLimitBurst           int
+	RateLimitIdentities      string
+
+	// Audit trail of analyses: file path or "stdout", empty disables it
+	AuditLogPath       string
+	AuditLogMaxSizeMB  int
+	AuditLogMaxBackups int
+	AuditLogHashChain  bool
+
+	// OpenTelemetry tracing: exporter (none, otlp or stdout), collector and sampling
+	TracingExporter     string
+	TracingOTLPEndpoint string
+	TracingOTLPInsecure bool
+	TracingSampleRatio  float64
+
+	// Drift detection: submissions kept per source label (0 disables it) and number of
+	// source labels tracked
+	DriftWindow     int
+	DriftMaxSources int
+
+	// Notifications of failed runs and drift state changes: HMAC-signed webhook and
+	// JSON-lines file or "stdout"; both empty disables notifications
+	NotifyWebhookURL        string
+	NotifyWebhookSecret     string
+	NotifyWebhookMaxRetries int
+	NotifyFile              string
+	NotifyTimeout           time.Duration
+	NotifyRules             []string
+	NotifyRunMinFailures    int
+}
+
+// RateLimit is the token bucket of one identity: RPS requests per second up to Burst at once.
+type RateLimit struct {
+	RPS   float64
+	Burst int
 }
 
-// Load reads configuration from environment variables
-func Load() (*Config, error) {
+// Load reads the configuration from the YAML or TOML file at path, if path is not
+// empty, with environment variables taking precedence over the file
+func Load(path string) (*Config, error) {
+	s := &source{}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s.file = values
+	}
+
 	cfg := &Config{
-		GRPCPort:      getEnvInt("GRPC_PORT", 9090),
-		TLSEnabled:    getEnvBool("TLS_ENABLED", false),
-		TLSCertFile:   getEnvString("TLS_CERT_FILE", ""),
-		TLSKeyFile:    getEnvString("TLS_KEY_FILE", ""),
-		TLSCAFile:     getEnvString("TLS_CA_FILE", ""),
-		TLSClientAuth: getEnvString("TLS_CLIENT_AUTH", "none"),
-		TLSMinVersion: getEnvString("TLS_MIN_VERSION", "1.2"),
-		MetricsPort:   getEnvInt("METRICS_PORT", 9091),
-		LogLevel:      getEnvString("LOG_LEVEL", "info"),
-		AuthEnabled:   getEnvBool("AUTH_ENABLED", false),
-		AuthIssuer:    getEnvString("AUTH_ISSUER", ""),
-		AuthAudience:  getEnvString("AUTH_AUDIENCE", ""),
-		AuthJWKSURL:   getEnvString("AUTH_JWKS_URL", ""),
+		GRPCPort:      s.getInt("GRPC_PORT", 9090),
+		TLSEnabled:    s.getBool("TLS_ENABLED", false),
+		TLSCertFile:   s.getString("TLS_CERT_FILE", ""),
+		TLSKeyFile:    s.getString("TLS_KEY_FILE", ""),
+		TLSCAFile:     s.getString("TLS_CA_FILE", ""),
+		TLSClientAuth: s.getString("TLS_CLIENT_AUTH", "none"),
+		TLSMinVersion: s.getString("TLS_MIN_VERSION", "1.2"),
+		MetricsPort:   s.getInt("METRICS_PORT", 9091),
+		LogLevel:      s.getString("LOG_LEVEL", "info"),
+		LogFormat:     s.getString("LOG_FORMAT", "console"),
+		AuthEnabled:   s.getBool("AUTH_ENABLED", false),
+		AuthIssuer:    s.getString("AUTH_ISSUER", ""),
+		AuthAudience:  s.getString("AUTH_AUDIENCE", ""),
+		AuthJWKSURL:   s.getString("AUTH_JWKS_URL", ""),
+
+		AuthMethodScopes: s.getString("AUTH_METHOD_SCOPES", ""),
+
+		LogFile:           s.getString("LOG_FILE", ""),
+		LogFileMaxSizeMB:  s.getInt("LOG_FILE_MAX_SIZE_MB", 100),
+		LogFileMaxBackups: s.getInt("LOG_FILE_MAX_BACKUPS", 5),
+		LogDebugSample:    s.getInt("LOG_DEBUG_SAMPLE", 0),
+
+		SelfTestEnabled:  s.getBool("SELFTEST_ENABLED", true),
+		SelfTestInterval: s.getDuration("SELFTEST_INTERVAL", time.Hour),
+		SelfTestDatasets: s.getList("SELFTEST_DATASETS", []string{"e"}),
+
+		SourcesEnabled:      s.getBool("SOURCES_ENABLED", false),
+		SourcesAllowedPaths: s.getList("SOURCES_ALLOWED_PATHS", []string{"/dev/urandom"}),
+
+		DFTMemoryLimitMB: s.getInt("DFT_MEMORY_LIMIT_MB", 256),
+
+		PrecisePValuesEnabled: s.getBool("PRECISE_PVALUES_ENABLED", false),
+
+		AdmissionMaxInFlightBits: s.getInt("ADMISSION_MAX_INFLIGHT_BITS", 20000000),
+		RateLimitRPS:             s.getFloat("RATE_LIMIT_RPS", 0),
+		RateLimitBurst:           s.getInt("RATE_LIMIT_BURST", 10),
+		RateLimitIdentities:      s.getString("RATE_LIMIT_IDENTITIES", ""),
+
+		AuditLogPath:       s.getString("AUDIT_LOG_PATH", ""),
+		AuditLogMaxSizeMB:  s.getInt("AUDIT_LOG_MAX_SIZE_MB", 100),
+		AuditLogMaxBackups: s.getInt("AUDIT_LOG_MAX_BACKUPS", 0),
+		AuditLogHashChain:  s.getBool("AUDIT_LOG_HASH_CHAIN", false),
+
+		MetricsUniformityWindow: s.getInt("METRICS_UNIFORMITY_WINDOW", 100),
+
+		TracingExporter:     s.getString("TRACING_EXPORTER", "none"),
+		TracingOTLPEndpoint: s.getString("TRACING_OTLP_ENDPOINT", ""),
+		TracingOTLPInsecure: s.getBool("TRACING_OTLP_INSECURE", false),
+		TracingSampleRatio:  s.getFloat("TRACING_SAMPLE_RATIO", 1),
+
+		DriftWindow:     s.getInt("DRIFT_WINDOW", 100),
+		DriftMaxSources: s.getInt("DRIFT_MAX_SOURCES", 100),
+
+		NotifyWebhookURL:        s.getString("NOTIFY_WEBHOOK_URL", ""),
+		NotifyWebhookSecret:     s.getString("NOTIFY_WEBHOOK_SECRET", ""),
+		NotifyWebhookMaxRetries: s.getInt("NOTIFY_WEBHOOK_MAX_RETRIES", 3),
+		NotifyFile:              s.getString("NOTIFY_FILE", ""),
+		NotifyTimeout:           s.getDuration("NOTIFY_TIMEOUT", 30*time.Second),
+		NotifyRules:             s.getList("NOTIFY_RULES", []string{"source_fail", "source_recovered"}),
+		NotifyRunMinFailures:    s.getInt("NOTIFY_RUN_MIN_FAILURES", 1),
 	}
 
+	if err := s.err(); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -80,6 +210,19 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid LOG_LEVEL: %s (must be debug/info/warn/error)", c.LogLevel)
 	}
 
+	if c.LogFormat != "" && c.LogFormat != "console" && c.LogFormat != "json" {
+		return fmt.Errorf("invalid LOG_FORMAT: %s (must be console/json)", c.LogFormat)
+	}
+	if c.LogFileMaxSizeMB < 0 {
+		return fmt.Errorf("invalid LOG_FILE_MAX_SIZE_MB: %d (must not be negative)", c.LogFileMaxSizeMB)
+	}
+	if c.LogFileMaxBackups < 0 {
+		return fmt.Errorf("invalid LOG_FILE_MAX_BACKUPS: %d (must not be negative)", c.LogFileMaxBackups)
+	}
+	if c.LogDebugSample < 0 {
+		return fmt.Errorf("invalid LOG_DEBUG_SAMPLE: %d (must not be negative)", c.LogDebugSample)
+	}
+
 	if c.AuthEnabled {
 		if c.AuthIssuer == "" {
 			return fmt.Errorf("invalid AUTH_ISSUER: required when AUTH_ENABLED=true")
@@ -89,6 +232,72 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	methodScopes, err := c.MethodScopes()
+	if err != nil {
+		return err
+	}
+	if len(methodScopes) > 0 && !c.AuthEnabled {
+		return fmt.Errorf("invalid AUTH_METHOD_SCOPES: requires AUTH_ENABLED=true")
+	}
+
+	if c.SelfTestEnabled {
+		if c.SelfTestInterval < 0 {
+			return fmt.Errorf("invalid SELFTEST_INTERVAL: %s (must not be negative)", c.SelfTestInterval)
+		}
+		if len(c.SelfTestDatasets) == 0 {
+			return fmt.Errorf("invalid SELFTEST_DATASETS: required when SELFTEST_ENABLED=true")
+		}
+	}
+
+	if c.SourcesEnabled {
+		if !c.AuthEnabled {
+			return fmt.Errorf("invalid SOURCES_ENABLED: requires AUTH_ENABLED=true")
+		}
+	}
+
+	if c.DFTMemoryLimitMB < 1 {
+		return fmt.Errorf("invalid DFT_MEMORY_LIMIT_MB: %d (must be at least 1)", c.DFTMemoryLimitMB)
+	}
+
+	if c.AdmissionMaxInFlightBits < 0 {
+		return fmt.Errorf("invalid ADMISSION_MAX_INFLIGHT_BITS: %d (must not be negative)", c.AdmissionMaxInFlightBits)
+	}
+	if err := validateRateLimit("RATE_LIMIT_RPS", RateLimit{RPS: c.RateLimitRPS, Burst: c.RateLimitBurst}); err != nil {
+		return err
+	}
+	if _, err := c.RateLimitOverrides(); err != nil {
+		return err
+	}
+
+	if c.AuditLogMaxSizeMB < 0 {
+		return fmt.Errorf("invalid AUDIT_LOG_MAX_SIZE_MB: %d (must not be negative)", c.AuditLogMaxSizeMB)
+	}
+	if c.AuditLogMaxBackups < 0 {
+		return fmt.Errorf("invalid AUDIT_LOG_MAX_BACKUPS: %d (must not be negative)", c.AuditLogMaxBackups)
+	}
+
+	switch c.TracingExporter {
+	case "", "none", "otlp", "stdout":
+	default:
+		return fmt.Errorf("invalid TRACING_EXPORTER: %s (must be none/otlp/stdout)", c.TracingExporter)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 || math.IsNaN(c.TracingSampleRatio) {
+		return fmt.Errorf("invalid TRACING_SAMPLE_RATIO: %v (must be between 0 and 1)", c.TracingSampleRatio)
+	}
+
+	if c.DriftWindow < 0 {
+		return fmt.Errorf("invalid DRIFT_WINDOW: %d (must not be negative, 0 disables drift detection)", c.DriftWindow)
+	}
+	if c.DriftWindow > 0 && c.DriftMaxSources < 1 {
+		return fmt.Errorf("invalid DRIFT_MAX_SOURCES: %d (must be at least 1)", c.DriftMaxSources)
+	}
+
+	if c.NotificationsEnabled() {
+		if err := c.validateNotifications(); err != nil {
+			return err
+		}
+	}
+
 	if c.TLSEnabled {
 		if c.TLSCertFile == "" {
 			return fmt.Errorf("invalid TLS_CERT_FILE: required when TLS_ENABLED=true")
@@ -104,6 +313,74 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.MetricsUniformityWindow < 10 {
+		return fmt.Errorf("invalid METRICS_UNIFORMITY_WINDOW: %d (must be at least 10)", c.MetricsUniformityWindow)
+	}
+
+	return nil
+}
+
+// reloadable are the settings that a reload applies to the running server.
+var reloadable = map[string]bool{
+	"LogLevel":                 true,
+	"AdmissionMaxInFlightBits": true,
+	"RateLimitRPS":             true,
+	"RateLimitBurst":           true,
+	"RateLimitIdentities":      true,
+	"DFTMemoryLimitMB":         true,
+	"PrecisePValuesEnabled":    true,
+	"SourcesEnabled":           true,
+	"SourcesAllowedPaths":      true,
+	"NotifyRules":              true,
+	"NotifyRunMinFailures":     true,
+}
+
+// RestartRequired returns the names of the settings that differ in next but are not
+// applied by a reload, such as the listeners, TLS and authentication.
+func (c *Config) RestartRequired(next *Config) []string {
+	current, updated := reflect.ValueOf(c).Elem(), reflect.ValueOf(next).Elem()
+	var changed []string
+	for i := 0; i < current.NumField(); i++ {
+		name := current.Type().Field(i).Name
+		if reloadable[name] {
+			continue
+		}
+		if !reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// notifyRules are the rule names accepted in NOTIFY_RULES.
+var notifyRules = []string{"run_failed", "source_warn", "source_fail", "source_recovered"}
+
+// NotificationsEnabled reports whether a webhook or notification file is configured.
+func (c *Config) NotificationsEnabled() bool {
+	return c.NotifyWebhookURL != "" || c.NotifyFile != ""
+}
+
+func (c *Config) validateNotifications() error {
+	if c.NotifyWebhookURL != "" {
+		u, err := url.Parse(c.NotifyWebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid NOTIFY_WEBHOOK_URL: %s (must be an http or https URL)", c.NotifyWebhookURL)
+		}
+	}
+	if c.NotifyWebhookMaxRetries < 0 {
+		return fmt.Errorf("invalid NOTIFY_WEBHOOK_MAX_RETRIES: %d (must not be negative)", c.NotifyWebhookMaxRetries)
+	}
+	if c.NotifyTimeout <= 0 {
+		return fmt.Errorf("invalid NOTIFY_TIMEOUT: %s (must be positive)", c.NotifyTimeout)
+	}
+	for _, rule := range c.NotifyRules {
+		if !slices.Contains(notifyRules, rule) {
+			return fmt.Errorf("invalid NOTIFY_RULES: unknown rule %s (must be %s)", rule, strings.Join(notifyRules, "/"))
+		}
+		if rule == "run_failed" && c.NotifyRunMinFailures < 1 {
+			return fmt.Errorf("invalid NOTIFY_RUN_MIN_FAILURES: %d (must be at least 1)", c.NotifyRunMinFailures)
+		}
+	}
 	return nil
 }
 
@@ -117,6 +394,91 @@ func (c *Config) TLSMinVersionValue() (uint16, error) {
 	return parseTLSMinVersion(c.TLSMinVersion)
 }
 
+// DefaultMethodScopes are required for RPCs without their own AUTH_METHOD_SCOPES entry when
+// authentication is enabled. SelfTest runs the whole battery on up to four 1,000,000-bit
+// data sets and TestSource reads the random sources of the host, so both are reserved
+// for administrators.
+var DefaultMethodScopes = map[string][]string{
+	"SelfTest":   {"nist:admin"},
+	"TestSource": {"nist:admin"},
+}
+
+// MethodScopes returns the scopes per RPC parsed from AUTH_METHOD_SCOPES, a comma-separated
+// list of method=scope entries where a method accepts any of several scopes separated by "|"
+// and the method "*" applies to all RPCs without their own entry. With AUTH_ENABLED=true the
+// DefaultMethodScopes apply to RPCs that are not listed.
+func (c *Config) MethodScopes() (map[string][]string, error) {
+	methodScopes := make(map[string][]string)
+	for _, entry := range strings.Split(c.AuthMethodScopes, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		method, spec, ok := strings.Cut(entry, "=")
+		method = strings.TrimSpace(method)
+		var scopes []string
+		for _, scope := range strings.Split(spec, "|") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+		if !ok || method == "" || len(scopes) == 0 {
+			return nil, fmt.Errorf("invalid AUTH_METHOD_SCOPES: %q (must be method=scope[|scope...])", entry)
+		}
+		if _, dup := methodScopes[method]; dup {
+			return nil, fmt.Errorf("invalid AUTH_METHOD_SCOPES: %q listed twice", method)
+		}
+		methodScopes[method] = scopes
+	}
+	if c.AuthEnabled {
+		for method, scopes := range DefaultMethodScopes {
+			if _, ok := methodScopes[method]; !ok {
+				methodScopes[method] = scopes
+			}
+		}
+	}
+	return methodScopes, nil
+}
+
+// RateLimitOverrides returns the per-identity rate limits parsed from RATE_LIMIT_IDENTITIES,
+// a comma-separated list of identity=rps:burst entries.
+func (c *Config) RateLimitOverrides() (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(c.RateLimitIdentities, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		identity, spec, ok := strings.Cut(entry, "=")
+		rps, burst, ok2 := strings.Cut(spec, ":")
+		identity = strings.TrimSpace(identity)
+		if !ok || !ok2 || identity == "" {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_IDENTITIES: %q (must be identity=rps:burst)", entry)
+		}
+		limit := RateLimit{}
+		var err error
+		if limit.RPS, err = strconv.ParseFloat(strings.TrimSpace(rps), 64); err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_IDENTITIES: %q (must be identity=rps:burst)", entry)
+		}
+		if limit.Burst, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_IDENTITIES: %q (must be identity=rps:burst)", entry)
+		}
+		if err := validateRateLimit("RATE_LIMIT_IDENTITIES", limit); err != nil {
+			return nil, err
+		}
+		limits[identity] = limit
+	}
+	return limits, nil
+}
+
+func validateRateLimit(key string, limit RateLimit) error {
+	if limit.RPS < 0 || math.IsNaN(limit.RPS) || math.IsInf(limit.RPS, 0) {
+		return fmt.Errorf("invalid %s: %v (must be a non-negative number, 0 disables the limit)", key, limit.RPS)
+	}
+	if limit.RPS > 0 && limit.Burst < 1 {
+		return fmt.Errorf("invalid %s: burst %d (must be at least 1)", key, limit.Burst)
+	}
+	return nil
+}
+
 func parseTLSClientAuth(mode string) (tls.ClientAuthType, error) {
 	switch strings.ToLower(strings.TrimSpace(mode)) {
 	case "", "none", "noclientcert":
@@ -144,31 +506,3 @@ func parseTLSMinVersion(version string) (uint16, error) {
 		return 0, fmt.Errorf("invalid TLS_MIN_VERSION: %s (use 1.2 or 1.3)", version)
 	}
 }
-
-// getEnvString reads a string from environment variable or returns default
-func getEnvString(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-// getEnvInt reads an integer from environment variable or returns default
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
-		}
-	}
-	return defaultValue
-}
-
-// getEnvBool reads a boolean from environment variable or returns default
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if boolVal, err := strconv.ParseBool(value); err == nil {
-			return boolVal
-		}
-	}
-	return defaultValue
-}
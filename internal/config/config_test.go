@@ -1,7 +1,9 @@
 package config
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadWithEnvOverrides(t *testing.T) {
@@ -19,9 +21,9 @@ func TestLoadWithEnvOverrides(t *testing.T) {
 	t.Setenv("TLS_CLIENT_AUTH", "requireandverify")
 	t.Setenv("TLS_MIN_VERSION", "1.3")
 
-	cfg, err := Load()
+	cfg, err := Load("")
 	if err != nil {
-		t.Fatalf("Load() returned error: %v", err)
+		t.Fatalf("Load returned error: %v", err)
 	}
 
 	if cfg.GRPCPort != 5000 || cfg.MetricsPort != 6000 {
@@ -70,6 +72,36 @@ func TestValidateFailures(t *testing.T) {
 		{"tls enabled missing key", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", TLSEnabled: true, TLSCertFile: "/tmp/cert.pem"}},
 		{"tls enabled invalid client auth", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", TLSEnabled: true, TLSCertFile: "/tmp/cert.pem", TLSKeyFile: "/tmp/key.pem", TLSClientAuth: "invalid"}},
 		{"tls enabled invalid min version", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", TLSEnabled: true, TLSCertFile: "/tmp/cert.pem", TLSKeyFile: "/tmp/key.pem", TLSMinVersion: "1.1"}},
+		{"self-test negative interval", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", SelfTestEnabled: true, SelfTestInterval: -time.Second, SelfTestDatasets: []string{"e"}}},
+		{"self-test missing datasets", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", SelfTestEnabled: true}},
+		{"sources without auth", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", SourcesEnabled: true}},
+		{"dft memory limit zero", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 0}},
+		{"method scopes without auth", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, AuthMethodScopes: "TestSource=nist:admin"}},
+		{"malformed method scopes", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, AuthEnabled: true, AuthIssuer: "https://issuer", AuthAudience: "aud", AuthMethodScopes: "TestSource="}},
+		{"duplicate method scopes", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, AuthEnabled: true, AuthIssuer: "https://issuer", AuthAudience: "aud", AuthMethodScopes: "SelfTest=a,SelfTest=b"}},
+		{"negative audit log size", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, AuditLogMaxSizeMB: -1}},
+		{"negative audit log backups", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, AuditLogMaxBackups: -1}},
+		{"bad log format", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", LogFormat: "logfmt", DFTMemoryLimitMB: 1}},
+		{"negative log file size", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, LogFileMaxSizeMB: -1}},
+		{"negative log file backups", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, LogFileMaxBackups: -1}},
+		{"negative debug sample", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, LogDebugSample: -1}},
+		{"unknown trace exporter", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, TracingExporter: "jaeger"}},
+		{"trace sample ratio above 1", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, TracingSampleRatio: 1.5}},
+		{"negative trace sample ratio", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, TracingSampleRatio: -0.1}},
+		{"negative drift window", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, DriftWindow: -1}},
+		{"drift without sources", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, DriftWindow: 100}},
+		{"notify webhook not http", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, NotifyWebhookURL: "ftp://alerts", NotifyTimeout: time.Second}},
+		{"notify webhook without host", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, NotifyWebhookURL: "https://", NotifyTimeout: time.Second}},
+		{"negative notify retries", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, NotifyFile: "stdout", NotifyTimeout: time.Second, NotifyWebhookMaxRetries: -1}},
+		{"notify without timeout", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, NotifyFile: "stdout"}},
+		{"unknown notify rule", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, NotifyFile: "stdout", NotifyTimeout: time.Second, NotifyRules: []string{"run_passed"}}},
+		{"run_failed without minimum", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, NotifyFile: "stdout", NotifyTimeout: time.Second, NotifyRules: []string{"run_failed"}}},
+		{"uniformity window too small", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, MetricsUniformityWindow: 5}},
+		{"negative in-flight budget", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, AdmissionMaxInFlightBits: -1}},
+		{"negative rate limit", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, RateLimitRPS: -1}},
+		{"rate limit without burst", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, RateLimitRPS: 5}},
+		{"malformed rate limit identities", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, RateLimitIdentities: "ci=5"}},
+		{"rate limit identity without burst", Config{GRPCPort: 9000, MetricsPort: 9001, LogLevel: "info", DFTMemoryLimitMB: 1, RateLimitIdentities: "ci=5:0"}},
 	}
 
 	for _, tt := range tests {
@@ -81,22 +113,22 @@ func TestValidateFailures(t *testing.T) {
 		})
 	}
 
-	// getEnvInt falls back on parse error
+	// getInt falls back on parse error
 	t.Setenv("SOME_INT", "notanint")
-	if v := getEnvInt("SOME_INT", 42); v != 42 {
+	if v := (&source{}).getInt("SOME_INT", 42); v != 42 {
 		t.Fatalf("expected default on parse error, got %d", v)
 	}
 }
 
 func TestLoadDefaults(t *testing.T) {
 	// Clear any environment variables
-	for _, key := range []string{"GRPC_PORT", "METRICS_PORT", "LOG_LEVEL", "AUTH_ENABLED", "AUTH_ISSUER", "AUTH_AUDIENCE", "AUTH_JWKS_URL", "TLS_ENABLED", "TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_CA_FILE", "TLS_CLIENT_AUTH", "TLS_MIN_VERSION"} {
+	for _, key := range []string{"GRPC_PORT", "METRICS_PORT", "LOG_LEVEL", "LOG_FORMAT", "LOG_FILE", "LOG_FILE_MAX_SIZE_MB", "LOG_FILE_MAX_BACKUPS", "LOG_DEBUG_SAMPLE", "AUTH_ENABLED", "AUTH_ISSUER", "AUTH_AUDIENCE", "AUTH_JWKS_URL", "AUTH_METHOD_SCOPES", "TLS_ENABLED", "TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_CA_FILE", "TLS_CLIENT_AUTH", "TLS_MIN_VERSION", "SELFTEST_ENABLED", "SELFTEST_INTERVAL", "SELFTEST_DATASETS", "DFT_MEMORY_LIMIT_MB", "ADMISSION_MAX_INFLIGHT_BITS", "RATE_LIMIT_RPS", "RATE_LIMIT_BURST", "RATE_LIMIT_IDENTITIES", "AUDIT_LOG_PATH", "AUDIT_LOG_MAX_SIZE_MB", "AUDIT_LOG_MAX_BACKUPS", "AUDIT_LOG_HASH_CHAIN", "TRACING_EXPORTER", "TRACING_OTLP_ENDPOINT", "TRACING_OTLP_INSECURE", "TRACING_SAMPLE_RATIO", "METRICS_UNIFORMITY_WINDOW", "DRIFT_WINDOW", "DRIFT_MAX_SOURCES", "NOTIFY_WEBHOOK_URL", "NOTIFY_WEBHOOK_SECRET", "NOTIFY_WEBHOOK_MAX_RETRIES", "NOTIFY_FILE", "NOTIFY_TIMEOUT", "NOTIFY_RULES", "NOTIFY_RUN_MIN_FAILURES"} {
 		t.Setenv(key, "")
 	}
 
-	cfg, err := Load()
+	cfg, err := Load("")
 	if err != nil {
-		t.Fatalf("Load() returned error: %v", err)
+		t.Fatalf("Load returned error: %v", err)
 	}
 
 	// Check default values
@@ -109,10 +141,13 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.LogLevel != "info" {
 		t.Errorf("expected default LogLevel=info, got %s", cfg.LogLevel)
 	}
+	if cfg.LogFormat != "console" || cfg.LogFile != "" || cfg.LogFileMaxSizeMB != 100 || cfg.LogFileMaxBackups != 5 || cfg.LogDebugSample != 0 {
+		t.Errorf("unexpected logging defaults: %+v", cfg)
+	}
 	if cfg.AuthEnabled {
 		t.Errorf("expected AuthEnabled to be false by default")
 	}
-	if cfg.AuthIssuer != "" || cfg.AuthAudience != "" || cfg.AuthJWKSURL != "" {
+	if cfg.AuthIssuer != "" || cfg.AuthAudience != "" || cfg.AuthJWKSURL != "" || cfg.AuthMethodScopes != "" {
 		t.Errorf("expected auth config defaults to be empty, got %+v", cfg)
 	}
 	if cfg.TLSEnabled {
@@ -127,12 +162,272 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.TLSMinVersion != "1.2" {
 		t.Errorf("expected TLSMinVersion to default to '1.2', got %s", cfg.TLSMinVersion)
 	}
+	if !cfg.SelfTestEnabled {
+		t.Errorf("expected SelfTestEnabled to be true by default")
+	}
+	if cfg.SelfTestInterval != time.Hour {
+		t.Errorf("expected SelfTestInterval to default to 1h, got %s", cfg.SelfTestInterval)
+	}
+	if len(cfg.SelfTestDatasets) != 1 || cfg.SelfTestDatasets[0] != "e" {
+		t.Errorf("expected SelfTestDatasets to default to [e], got %v", cfg.SelfTestDatasets)
+	}
+	if cfg.SourcesEnabled {
+		t.Errorf("expected SourcesEnabled to be false by default")
+	}
+	if cfg.PrecisePValuesEnabled {
+		t.Errorf("expected PrecisePValuesEnabled to be false by default")
+	}
+	if len(cfg.SourcesAllowedPaths) != 1 || cfg.SourcesAllowedPaths[0] != "/dev/urandom" {
+		t.Errorf("expected SourcesAllowedPaths to default to [/dev/urandom], got %v", cfg.SourcesAllowedPaths)
+	}
+	if cfg.DFTMemoryLimitMB != 256 {
+		t.Errorf("expected DFTMemoryLimitMB to default to 256, got %d", cfg.DFTMemoryLimitMB)
+	}
+	if cfg.AdmissionMaxInFlightBits != 20000000 {
+		t.Errorf("expected AdmissionMaxInFlightBits to default to 20000000, got %d", cfg.AdmissionMaxInFlightBits)
+	}
+	if cfg.RateLimitRPS != 0 || cfg.RateLimitBurst != 10 || cfg.RateLimitIdentities != "" {
+		t.Errorf("expected rate limiting to be disabled by default, got %+v", cfg)
+	}
+	if cfg.AuditLogPath != "" || cfg.AuditLogMaxSizeMB != 100 || cfg.AuditLogMaxBackups != 0 || cfg.AuditLogHashChain {
+		t.Errorf("unexpected audit log defaults: %+v", cfg)
+	}
+	if cfg.TracingExporter != "none" || cfg.TracingOTLPEndpoint != "" || cfg.TracingOTLPInsecure || cfg.TracingSampleRatio != 1 {
+		t.Errorf("unexpected tracing defaults: %+v", cfg)
+	}
+	if cfg.MetricsUniformityWindow != 100 {
+		t.Errorf("expected MetricsUniformityWindow to default to 100, got %d", cfg.MetricsUniformityWindow)
+	}
+	if cfg.DriftWindow != 100 || cfg.DriftMaxSources != 100 {
+		t.Errorf("unexpected drift detection defaults: %+v", cfg)
+	}
+	if cfg.NotificationsEnabled() || cfg.NotifyWebhookMaxRetries != 3 || cfg.NotifyTimeout != 30*time.Second || cfg.NotifyRunMinFailures != 1 {
+		t.Errorf("unexpected notification defaults: %+v", cfg)
+	}
+	if strings.Join(cfg.NotifyRules, ",") != "source_fail,source_recovered" {
+		t.Errorf("unexpected default notification rules: %v", cfg.NotifyRules)
+	}
+}
+
+func TestLoadNotifyOverrides(t *testing.T) {
+	t.Setenv("NOTIFY_WEBHOOK_URL", "https://alerts.example.com/hook")
+	t.Setenv("NOTIFY_WEBHOOK_SECRET", "s3cret")
+	t.Setenv("NOTIFY_WEBHOOK_MAX_RETRIES", "5")
+	t.Setenv("NOTIFY_FILE", "stdout")
+	t.Setenv("NOTIFY_TIMEOUT", "1m")
+	t.Setenv("NOTIFY_RULES", "source_warn, source_fail")
+	t.Setenv("NOTIFY_RUN_MIN_FAILURES", "0")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.NotificationsEnabled() || cfg.NotifyWebhookSecret != "s3cret" || cfg.NotifyWebhookMaxRetries != 5 || cfg.NotifyTimeout != time.Minute {
+		t.Errorf("unexpected notification config: %+v", cfg)
+	}
+	if strings.Join(cfg.NotifyRules, ",") != "source_warn,source_fail" {
+		t.Errorf("unexpected notification rules: %v", cfg.NotifyRules)
+	}
+}
+
+func TestLoadTracingOverrides(t *testing.T) {
+	t.Setenv("TRACING_EXPORTER", "otlp")
+	t.Setenv("TRACING_OTLP_ENDPOINT", "otel-collector:4317")
+	t.Setenv("TRACING_OTLP_INSECURE", "true")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.TracingExporter != "otlp" || cfg.TracingOTLPEndpoint != "otel-collector:4317" || !cfg.TracingOTLPInsecure || cfg.TracingSampleRatio != 0.25 {
+		t.Fatalf("unexpected tracing settings: %+v", cfg)
+	}
+}
+
+func TestLoadLoggingOverrides(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "json")
+	t.Setenv("LOG_FILE", "/var/log/nist/service.log")
+	t.Setenv("LOG_FILE_MAX_SIZE_MB", "20")
+	t.Setenv("LOG_FILE_MAX_BACKUPS", "0")
+	t.Setenv("LOG_DEBUG_SAMPLE", "100")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.LogFormat != "json" || cfg.LogFile != "/var/log/nist/service.log" || cfg.LogFileMaxSizeMB != 20 || cfg.LogFileMaxBackups != 0 || cfg.LogDebugSample != 100 {
+		t.Fatalf("unexpected logging settings: %+v", cfg)
+	}
+}
+
+func TestLoadAuditLogOverrides(t *testing.T) {
+	t.Setenv("AUDIT_LOG_PATH", "/var/log/nist/audit.jsonl")
+	t.Setenv("AUDIT_LOG_MAX_SIZE_MB", "10")
+	t.Setenv("AUDIT_LOG_MAX_BACKUPS", "5")
+	t.Setenv("AUDIT_LOG_HASH_CHAIN", "true")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.AuditLogPath != "/var/log/nist/audit.jsonl" || cfg.AuditLogMaxSizeMB != 10 || cfg.AuditLogMaxBackups != 5 || !cfg.AuditLogHashChain {
+		t.Fatalf("unexpected audit log settings: %+v", cfg)
+	}
+}
+
+func TestLoadAdmissionOverrides(t *testing.T) {
+	t.Setenv("ADMISSION_MAX_INFLIGHT_BITS", "0")
+	t.Setenv("RATE_LIMIT_RPS", "2.5")
+	t.Setenv("RATE_LIMIT_BURST", "5")
+	t.Setenv("RATE_LIMIT_IDENTITIES", "ci-pipeline=50:100, lab.example.com = 0.5:1,")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.AdmissionMaxInFlightBits != 0 || cfg.RateLimitRPS != 2.5 || cfg.RateLimitBurst != 5 {
+		t.Fatalf("unexpected admission settings: %+v", cfg)
+	}
+	limits, err := cfg.RateLimitOverrides()
+	if err != nil {
+		t.Fatalf("RateLimitOverrides() returned error: %v", err)
+	}
+	if len(limits) != 2 || limits["ci-pipeline"] != (RateLimit{RPS: 50, Burst: 100}) || limits["lab.example.com"] != (RateLimit{RPS: 0.5, Burst: 1}) {
+		t.Fatalf("unexpected rate limit overrides: %v", limits)
+	}
+
+	// getFloat falls back on parse error
+	t.Setenv("SOME_FLOAT", "fast")
+	if v := (&source{}).getFloat("SOME_FLOAT", 1.5); v != 1.5 {
+		t.Fatalf("expected default on parse error, got %v", v)
+	}
+}
+
+func TestLoadSourcesOverrides(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("AUTH_ISSUER", "https://issuer.example.com")
+	t.Setenv("AUTH_AUDIENCE", "nist-api")
+	t.Setenv("SOURCES_ENABLED", "true")
+	t.Setenv("SOURCES_ALLOWED_PATHS", "/dev/hwrng,/dev/urandom")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !cfg.SourcesEnabled {
+		t.Fatalf("unexpected sources settings: %+v", cfg)
+	}
+	if len(cfg.SourcesAllowedPaths) != 2 || cfg.SourcesAllowedPaths[0] != "/dev/hwrng" {
+		t.Fatalf("unexpected allowed paths: %v", cfg.SourcesAllowedPaths)
+	}
+}
+
+func TestLoadPrecisePValues(t *testing.T) {
+	t.Setenv("PRECISE_PVALUES_ENABLED", "true")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !cfg.PrecisePValuesEnabled {
+		t.Fatalf("expected PrecisePValuesEnabled to be true, got %+v", cfg)
+	}
+}
+
+func TestLoadMethodScopes(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("AUTH_ISSUER", "https://issuer.example.com")
+	t.Setenv("AUTH_AUDIENCE", "nist-api")
+	t.Setenv("AUTH_METHOD_SCOPES", "TestSource=nist:admin, SelfTest = nist:admin | nist:operator ,*=nist:read,")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	scopes, err := cfg.MethodScopes()
+	if err != nil {
+		t.Fatalf("MethodScopes() returned error: %v", err)
+	}
+	want := map[string][]string{
+		"TestSource": {"nist:admin"},
+		"SelfTest":   {"nist:admin", "nist:operator"},
+		"*":          {"nist:read"},
+	}
+	if len(scopes) != len(want) {
+		t.Fatalf("unexpected method scopes: %v", scopes)
+	}
+	for method, w := range want {
+		if got := scopes[method]; strings.Join(got, " ") != strings.Join(w, " ") {
+			t.Errorf("%s: got scopes %v, want %v", method, got, w)
+		}
+	}
+}
+
+func TestDefaultMethodScopes(t *testing.T) {
+	cfg := &Config{AuthEnabled: true, AuthMethodScopes: "*=nist:read"}
+	scopes, err := cfg.MethodScopes()
+	if err != nil {
+		t.Fatalf("MethodScopes() returned error: %v", err)
+	}
+	for _, method := range []string{"SelfTest", "TestSource"} {
+		if got := strings.Join(scopes[method], " "); got != "nist:admin" {
+			t.Errorf("expected %s to default to nist:admin, got %q", method, got)
+		}
+	}
+
+	cfg.AuthEnabled = false
+	if scopes, err := cfg.MethodScopes(); err != nil || len(scopes) != 1 {
+		t.Errorf("expected no default scopes without authentication, got %v, %v", scopes, err)
+	}
+}
+
+func TestLoadSelfTestOverrides(t *testing.T) {
+	t.Setenv("SELFTEST_ENABLED", "true")
+	t.Setenv("SELFTEST_INTERVAL", "15m")
+	t.Setenv("SELFTEST_DATASETS", "pi, sqrt2,,")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.SelfTestInterval != 15*time.Minute {
+		t.Fatalf("unexpected self-test interval: %s", cfg.SelfTestInterval)
+	}
+	if len(cfg.SelfTestDatasets) != 2 || cfg.SelfTestDatasets[0] != "pi" || cfg.SelfTestDatasets[1] != "sqrt2" {
+		t.Fatalf("unexpected self-test datasets: %v", cfg.SelfTestDatasets)
+	}
+
+	// getDuration falls back on parse error
+	t.Setenv("SOME_DURATION", "soon")
+	if v := (&source{}).getDuration("SOME_DURATION", time.Minute); v != time.Minute {
+		t.Fatalf("expected default on parse error, got %s", v)
+	}
 }
 
 func TestLoadInvalidConfig(t *testing.T) {
 	t.Setenv("GRPC_PORT", "0")
-	_, err := Load()
+	_, err := Load("")
 	if err == nil {
 		t.Fatal("expected error for invalid port")
 	}
 }
+
+func TestRestartRequired(t *testing.T) {
+	current := &Config{GRPCPort: 9090, LogLevel: "info", SourcesAllowedPaths: []string{"/dev/urandom"}}
+
+	next := *current
+	next.LogLevel = "debug"
+	next.RateLimitRPS = 5
+	next.SourcesAllowedPaths = []string{"/dev/hwrng"}
+	if changed := current.RestartRequired(&next); len(changed) != 0 {
+		t.Errorf("reloadable changes reported as requiring a restart: %v", changed)
+	}
+
+	next.GRPCPort = 9000
+	next.TLSEnabled = true
+	changed := current.RestartRequired(&next)
+	if strings.Join(changed, ",") != "GRPCPort,TLSEnabled" {
+		t.Errorf("RestartRequired = %v, want [GRPCPort TLSEnabled]", changed)
+	}
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// source resolves settings from the environment and then from the configuration file.
+// Values from the environment that do not parse fall back to the default, values from
+// the file are reported as errors.
+type source struct {
+	// file holds the file settings by environment variable name
+	file map[string]fileValue
+	used map[string]bool
+	errs []error
+}
+
+// fileValue is a setting from the configuration file and the key it was given under.
+type fileValue struct {
+	value string
+	key   string
+}
+
+// lookup returns the value of the setting key and whether it was taken from the file.
+func (s *source) lookup(key string) (string, bool) {
+	if s.used == nil {
+		s.used = make(map[string]bool)
+	}
+	s.used[key] = true
+
+	if value := os.Getenv(key); value != "" {
+		return value, false
+	}
+	v := s.file[key]
+	return v.value, true
+}
+
+// invalid records a file value that does not parse.
+func (s *source) invalid(key, value string, fromFile bool, err error) {
+	if fromFile {
+		s.errs = append(s.errs, fmt.Errorf("%s: invalid value %q: %w", s.file[key].key, value, err))
+	}
+}
+
+// err returns the invalid and unknown settings of the file.
+func (s *source) err() error {
+	var unknown []string
+	for key, v := range s.file {
+		if !s.used[key] {
+			unknown = append(unknown, v.key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		s.errs = append(s.errs, fmt.Errorf("%s: unknown setting", key))
+	}
+	return errors.Join(s.errs...)
+}
+
+// getString reads a string setting or returns default
+func (s *source) getString(key, defaultValue string) string {
+	if value, _ := s.lookup(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getInt reads an integer setting or returns default
+func (s *source) getInt(key string, defaultValue int) int {
+	value, fromFile := s.lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+	intVal, err := strconv.Atoi(value)
+	if err != nil {
+		s.invalid(key, value, fromFile, err)
+		return defaultValue
+	}
+	return intVal
+}
+
+// getFloat reads a float setting or returns default
+func (s *source) getFloat(key string, defaultValue float64) float64 {
+	value, fromFile := s.lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+	floatVal, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		s.invalid(key, value, fromFile, err)
+		return defaultValue
+	}
+	return floatVal
+}
+
+// getBool reads a boolean setting or returns default
+func (s *source) getBool(key string, defaultValue bool) bool {
+	value, fromFile := s.lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolVal, err := strconv.ParseBool(value)
+	if err != nil {
+		s.invalid(key, value, fromFile, err)
+		return defaultValue
+	}
+	return boolVal
+}
+
+// getDuration reads a time.Duration (e.g. "30m") setting or returns default
+func (s *source) getDuration(key string, defaultValue time.Duration) time.Duration {
+	value, fromFile := s.lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+	durVal, err := time.ParseDuration(value)
+	if err != nil {
+		s.invalid(key, value, fromFile, err)
+		return defaultValue
+	}
+	return durVal
+}
+
+// getList reads a comma-separated list setting or returns default
+func (s *source) getList(key string, defaultValue []string) []string {
+	value, _ := s.lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// readFile reads a YAML (.yaml, .yml) or TOML (.toml) configuration file. Its keys are
+// the environment variable names in lower case; nested tables join their keys with an
+// underscore, so that log: {level: debug} sets LOG_LEVEL. Lists are joined with commas.
+func readFile(path string) (map[string]fileValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read configuration file: %w", err)
+	}
+
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported configuration file format %q (use .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse configuration file %s: %w", path, err)
+	}
+
+	values := make(map[string]fileValue)
+	if err := flatten("", doc, values); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flatten adds the settings of table to values, with their keys prefixed by prefix.
+func flatten(prefix string, table map[string]any, values map[string]fileValue) error {
+	for k, v := range table {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		if nested, ok := v.(map[string]any); ok {
+			if err := flatten(key, nested, values); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if v == nil {
+			continue
+		}
+		value, err := settingValue(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		if prev, ok := values[name]; ok {
+			return fmt.Errorf("%s: already set as %s", key, prev.key)
+		}
+		values[name] = fileValue{value: value, key: key}
+	}
+	return nil
+}
+
+// settingValue formats a scalar or a list of scalars in the syntax of the environment
+// variables.
+func settingValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		// Integral values are written out in full, so that 2e7 or 20000000.0 parse
+		// as integer settings.
+		if v == math.Trunc(v) {
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			if _, ok := item.([]any); ok {
+				return "", errors.New("nested lists are not supported")
+			}
+			if _, ok := item.(map[string]any); ok {
+				return "", errors.New("tables in lists are not supported")
+			}
+			s, err := settingValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", v)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes a configuration file named name with content to a temporary directory.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadYAMLFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+grpc_port: 9000
+log:
+  level: debug
+  format: json
+rate_limit_rps: 2.5
+selftest:
+  interval: 15m
+  datasets: [pi, sqrt2]
+notify_file: stdout
+notify:
+  rules:
+    - source_warn
+    - source_fail
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.GRPCPort != 9000 || cfg.LogLevel != "debug" || cfg.LogFormat != "json" || cfg.RateLimitRPS != 2.5 {
+		t.Errorf("unexpected settings: %+v", cfg)
+	}
+	if cfg.SelfTestInterval != 15*time.Minute || strings.Join(cfg.SelfTestDatasets, ",") != "pi,sqrt2" {
+		t.Errorf("unexpected self-test settings: %s %v", cfg.SelfTestInterval, cfg.SelfTestDatasets)
+	}
+	if cfg.NotifyFile != "stdout" || strings.Join(cfg.NotifyRules, ",") != "source_warn,source_fail" {
+		t.Errorf("unexpected notification settings: %s %v", cfg.NotifyFile, cfg.NotifyRules)
+	}
+	// Settings missing from the file keep their defaults
+	if cfg.MetricsPort != 9091 || cfg.DFTMemoryLimitMB != 256 {
+		t.Errorf("unexpected defaults: metrics port %d, DFT memory limit %d", cfg.MetricsPort, cfg.DFTMemoryLimitMB)
+	}
+}
+
+func TestLoadTOMLFile(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+metrics_port = 9191
+sources_allowed_paths = ["/dev/urandom", "/dev/hwrng"]
+
+[auth]
+enabled = true
+issuer = "https://issuer.example"
+audience = "nist"
+
+[drift]
+window = 50
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.MetricsPort != 9191 || !cfg.AuthEnabled || cfg.AuthIssuer != "https://issuer.example" || cfg.DriftWindow != 50 {
+		t.Errorf("unexpected settings: %+v", cfg)
+	}
+	if strings.Join(cfg.SourcesAllowedPaths, ",") != "/dev/urandom,/dev/hwrng" {
+		t.Errorf("unexpected allowed paths: %v", cfg.SourcesAllowedPaths)
+	}
+}
+
+func TestLoadIntegralFloats(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": "admission_max_inflight_bits: 2e7\naudit_log_max_size_mb: 50.0\nrate_limit_rps: 1e1\n",
+		"config.toml": "admission_max_inflight_bits = 2e7\naudit_log_max_size_mb = 50.0\nrate_limit_rps = 1e1\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load(writeFile(t, name, content))
+			if err != nil {
+				t.Fatalf("Load returned error: %v", err)
+			}
+			if cfg.AdmissionMaxInFlightBits != 20000000 || cfg.AuditLogMaxSizeMB != 50 || cfg.RateLimitRPS != 10 {
+				t.Errorf("unexpected settings: %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestSettingValueFloat(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{2e7, "20000000"},
+		{1e15, "1000000000000000"},
+		{-3, "-3"},
+		{2.5, "2.5"},
+		{1e-7, "1e-07"},
+	}
+	for _, tt := range tests {
+		got, err := settingValue(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("settingValue(%g) = %q, %v; want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadFileEnvOverride(t *testing.T) {
+	path := writeFile(t, "config.yml", "log_level: debug\ngrpc_port: 9000\n")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.LogLevel != "warn" {
+		t.Errorf("environment did not take precedence: log level %s", cfg.LogLevel)
+	}
+	if cfg.GRPCPort != 9000 {
+		t.Errorf("file setting not applied: gRPC port %d", cfg.GRPCPort)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"unknown setting", "config.yaml", "grpc_port: 9000\nlog:\n  levle: debug\n", "log.levle: unknown setting"},
+		{"invalid value", "config.yaml", "grpc_port: ninety\n", `grpc_port: invalid value "ninety"`},
+		{"invalid duration", "config.toml", "[selftest]\ninterval = \"hourly\"\n", "selftest.interval: invalid value"},
+		{"duplicate setting", "config.yaml", "log_level: info\nlog:\n  level: debug\n", "already set"},
+		{"nested list", "config.yaml", "selftest_datasets: [[pi]]\n", "nested lists are not supported"},
+		{"validation", "config.yaml", "grpc_port: 70000\n", "invalid GRPC_PORT"},
+		{"syntax", "config.yaml", "grpc_port: [\n", "parse configuration file"},
+		{"unsupported format", "config.json", "{}", "unsupported configuration file format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeFile(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestSourceEnvParseError(t *testing.T) {
+	// Environment values that do not parse fall back to the default, as before
+	// configuration files were supported
+	t.Setenv("GRPC_PORT", "ninety")
+	s := &source{file: map[string]fileValue{"GRPC_PORT": {value: "9000", key: "grpc_port"}}}
+	if v := s.getInt("GRPC_PORT", 9090); v != 9090 {
+		t.Errorf("getInt = %d, want the default", v)
+	}
+	if err := s.err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+// Package drift evaluates successive test results of the same random source over a
+// rolling window with the NIST SP 800-22 proportion and uniformity criteria (section
+// 4.2), so that a source that degrades intermittently is detected even when each
+// single run passes.
+package drift
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"gonum.org/v1/gonum/mathext"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+)
+
+// State is the alert state of a statistic or source.
+type State int
+
+const (
+	// StateUnknown means there are not yet enough samples to evaluate.
+	StateUnknown State = iota
+	// StateOK means every statistic is within the NIST acceptance range.
+	StateOK
+	// StateWarn means a statistic is close to the limit of the acceptance range.
+	StateWarn
+	// StateFail means a statistic is outside the acceptance range.
+	StateFail
+)
+
+// String returns the state name used in logs.
+func (s State) String() string {
+	switch s {
+	case StateOK:
+		return "OK"
+	case StateWarn:
+		return "WARN"
+	case StateFail:
+		return "FAIL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+const (
+	// Alpha is the significance level of the single tests.
+	Alpha = nist.Alpha
+	// MinProportionSamples is the number of p-values below which a proportion is not evaluated.
+	MinProportionSamples = 10
+	// MinUniformitySamples is the number of p-values per sub-test below which the
+	// uniformity is not evaluated (SP 800-22 section 4.2.2 requires at least 55).
+	MinUniformitySamples = 55
+
+	// warnSigma is the width of the early-warning proportion range in standard
+	// deviations; the acceptance range is that of nist.ProportionFailed.
+	warnSigma = 2
+	// failUniformity is the NIST uniformity threshold; warnUniformity is the early warning.
+	failUniformity = 0.0001
+	warnUniformity = 0.001
+)
+
+// Result holds the p-values of one test run on one sequence: one p-value, or one per
+// sub-test for tests such as non_overlapping_template. Tests skipped for the sequence
+// are left out.
+type Result struct {
+	Test    string
+	PValues []float64
+}
+
+// TestStatus is the evaluation of one test over the window.
+type TestStatus struct {
+	Name string
+	// Samples is the number of p-values of all sub-tests in the window.
+	Samples int
+	// Proportion is the fraction of p-values >= Alpha.
+	Proportion float64
+	// ProportionMin is the lower limit of the NIST acceptable proportion range.
+	ProportionMin float64
+	// Uniformity is the smallest uniformity p-value of the sub-tests, Bonferroni
+	// corrected for their number, or -1 with fewer than MinUniformitySamples samples.
+	Uniformity float64
+	State      State
+}
+
+// Status is the evaluation of a source over its window.
+type Status struct {
+	Source string
+	// State is the worst state of the tests.
+	State State
+	// Samples is the number of submissions in the window.
+	Samples    int
+	WindowSize int
+	Updated    time.Time
+	// Tests are ordered by name.
+	Tests []TestStatus
+}
+
+// Monitor keeps the results of the last submissions of each source. It is safe for
+// concurrent use.
+type Monitor struct {
+	windowSize int
+	maxSources int
+
+	mu      sync.Mutex
+	sources map[string]*window
+}
+
+// window is a ring of the last submissions of a source.
+type window struct {
+	submissions [][]Result
+	next        int
+	full        bool
+	updated     time.Time
+}
+
+// NewMonitor returns a monitor that keeps the last windowSize submissions of up to
+// maxSources sources; submissions of further sources are not tracked.
+func NewMonitor(windowSize, maxSources int) *Monitor {
+	return &Monitor{windowSize: windowSize, maxSources: maxSources, sources: make(map[string]*window)}
+}
+
+// Observe adds the results of a submission of source at time at and returns the new
+// status of the source, or false if the source is not tracked because the monitor
+// already tracks maxSources sources.
+func (m *Monitor) Observe(source string, at time.Time, results []Result) (Status, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.sources[source]
+	if !ok {
+		if len(m.sources) >= m.maxSources {
+			return Status{}, false
+		}
+		w = &window{submissions: make([][]Result, m.windowSize)}
+		m.sources[source] = w
+	}
+	w.submissions[w.next] = results
+	w.next = (w.next + 1) % m.windowSize
+	w.full = w.full || w.next == 0
+	w.updated = at
+	return m.evaluate(source, w), true
+}
+
+// Status returns the status of source, or false if it has no submissions.
+func (m *Monitor) Status(source string) (Status, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.sources[source]
+	if !ok {
+		return Status{}, false
+	}
+	return m.evaluate(source, w), true
+}
+
+func (m *Monitor) evaluate(source string, w *window) Status {
+	count := w.next
+	if w.full {
+		count = m.windowSize
+	}
+
+	// p-values per test and sub-test index
+	pValues := make(map[string][][]float64)
+	for _, submission := range w.submissions[:count] {
+		for _, r := range submission {
+			subTests := pValues[r.Test]
+			for len(subTests) < len(r.PValues) {
+				subTests = append(subTests, nil)
+			}
+			for i, p := range r.PValues {
+				subTests[i] = append(subTests[i], p)
+			}
+			pValues[r.Test] = subTests
+		}
+	}
+
+	status := Status{Source: source, Samples: count, WindowSize: m.windowSize, Updated: w.updated}
+	for name, subTests := range pValues {
+		t := evaluateTest(name, subTests)
+		status.Tests = append(status.Tests, t)
+		if t.State > status.State {
+			status.State = t.State
+		}
+	}
+	sort.Slice(status.Tests, func(i, j int) bool { return status.Tests[i].Name < status.Tests[j].Name })
+	return status
+}
+
+// evaluateTest pools the sub-tests of a test for the proportion, which keeps its range
+// meaningful for tests with many sub-tests, and corrects the smallest sub-test uniformity
+// for the number of sub-tests.
+func evaluateTest(name string, subTests [][]float64) TestStatus {
+	t := TestStatus{Name: name, Uniformity: -1}
+	passed := 0
+	for _, values := range subTests {
+		t.Samples += len(values)
+		for _, p := range values {
+			if p >= Alpha {
+				passed++
+			}
+		}
+	}
+	if t.Samples == 0 {
+		return t
+	}
+	t.Proportion = float64(passed) / float64(t.Samples)
+	t.ProportionMin = nist.ProportionLimit(t.Samples, nist.ProportionSigma)
+
+	evaluated := 0
+	for _, values := range subTests {
+		if len(values) < MinUniformitySamples {
+			continue
+		}
+		u := math.Min(1, Uniformity(values)*float64(len(subTests)))
+		if evaluated == 0 || u < t.Uniformity {
+			t.Uniformity = u
+		}
+		evaluated++
+	}
+
+	if t.Samples < MinProportionSamples {
+		return t
+	}
+	switch {
+	case nist.ProportionFailed(passed, t.Samples) || (evaluated > 0 && t.Uniformity < failUniformity):
+		t.State = StateFail
+	case t.Proportion < nist.ProportionLimit(t.Samples, warnSigma) || (evaluated > 0 && t.Uniformity < warnUniformity):
+		t.State = StateWarn
+	default:
+		t.State = StateOK
+	}
+	return t
+}
+
+// Uniformity returns the chi-squared p-value of the p-values distributed over ten equal
+// bins (SP 800-22 section 4.2.2).
+func Uniformity(pValues []float64) float64 {
+	const bins = 10
+	var counts [bins]int
+	for _, p := range pValues {
+		i := int(p * bins)
+		if i >= bins {
+			i = bins - 1
+		}
+		if i < 0 {
+			i = 0
+		}
+		counts[i]++
+	}
+	expected := float64(len(pValues)) / bins
+	chi2 := 0.0
+	for _, c := range counts {
+		d := float64(c) - expected
+		chi2 += d * d / expected
+	}
+	return mathext.GammaIncRegComp((bins-1)/2.0, chi2/2)
+}
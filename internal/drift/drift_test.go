@@ -0,0 +1,164 @@
+package drift
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// uniform returns n p-values spread evenly over (0, 1).
+func uniform(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = (float64(i) + 0.5) / float64(n)
+	}
+	return values
+}
+
+func TestUniformity(t *testing.T) {
+	if got := Uniformity(uniform(100)); math.Abs(got-1) > 1e-12 {
+		t.Errorf("Uniformity of evenly spread p-values = %v, want 1", got)
+	}
+	clustered := make([]float64, 100)
+	for i := range clustered {
+		clustered[i] = 0.5
+	}
+	if got := Uniformity(clustered); got > 1e-6 {
+		t.Errorf("Uniformity of clustered p-values = %v, want near 0", got)
+	}
+	// 0 and 1 fall into the first and last bin
+	bounds := []float64{0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 1}
+	if got := Uniformity(bounds); math.Abs(got-1) > 1e-12 {
+		t.Errorf("Uniformity of p-values including 0 and 1 = %v, want 1", got)
+	}
+}
+
+func TestMonitorWindow(t *testing.T) {
+	m := NewMonitor(3, 10)
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, ok := m.Status("trng"); ok {
+		t.Fatal("status of a source without submissions")
+	}
+
+	for i := 0; i < 5; i++ {
+		status, ok := m.Observe("trng", at.Add(time.Duration(i)*time.Second), []Result{{Test: "runs", PValues: []float64{0.5}}})
+		if !ok {
+			t.Fatalf("submission %d not tracked", i)
+		}
+		if want := min(i+1, 3); status.Samples != want {
+			t.Errorf("submission %d: Samples = %d, want %d", i, status.Samples, want)
+		}
+	}
+
+	status, ok := m.Status("trng")
+	if !ok {
+		t.Fatal("missing status")
+	}
+	if status.WindowSize != 3 || !status.Updated.Equal(at.Add(4*time.Second)) {
+		t.Errorf("status = %+v", status)
+	}
+	if len(status.Tests) != 1 || status.Tests[0].Name != "runs" || status.Tests[0].Samples != 3 {
+		t.Fatalf("tests = %+v, want runs with the 3 p-values of the window", status.Tests)
+	}
+	// Too few p-values for a state.
+	if status.State != StateUnknown || status.Tests[0].Uniformity != -1 {
+		t.Errorf("state = %v, uniformity = %v; want UNKNOWN and -1", status.State, status.Tests[0].Uniformity)
+	}
+}
+
+func TestMonitorMaxSources(t *testing.T) {
+	m := NewMonitor(10, 2)
+	for _, source := range []string{"a", "b"} {
+		if _, ok := m.Observe(source, time.Now(), nil); !ok {
+			t.Fatalf("source %s not tracked", source)
+		}
+	}
+	if _, ok := m.Observe("c", time.Now(), nil); ok {
+		t.Error("source beyond the limit tracked")
+	}
+	if _, ok := m.Observe("a", time.Now(), nil); !ok {
+		t.Error("known source no longer tracked")
+	}
+}
+
+func TestMonitorStates(t *testing.T) {
+	tests := []struct {
+		name    string
+		pValues func(i int) float64
+		want    State
+	}{
+		{"uniform", func(i int) float64 { return (float64(i%100) + 0.5) / 100 }, StateOK},
+		// 3 of 100 below alpha: within the 3 sigma but outside the 2 sigma range.
+		{"proportion warn", func(i int) float64 {
+			if i%100 < 3 {
+				return 0.005
+			}
+			return (float64(i%100) + 0.5) / 100
+		}, StateWarn},
+		{"proportion fail", func(i int) float64 {
+			if i%10 == 0 {
+				return 0.001
+			}
+			return 0.5
+		}, StateFail},
+		// All p-values pass, but cluster in one bin.
+		{"uniformity fail", func(int) float64 { return 0.55 }, StateFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitor(100, 1)
+			var status Status
+			for i := 0; i < 100; i++ {
+				status, _ = m.Observe("trng", time.Now(), []Result{{Test: "runs", PValues: []float64{tt.pValues(i)}}})
+			}
+			if status.State != tt.want {
+				t.Errorf("state = %v, want %v (%+v)", status.State, tt.want, status.Tests)
+			}
+		})
+	}
+}
+
+func TestMonitorRecovers(t *testing.T) {
+	m := NewMonitor(20, 1)
+	var status Status
+	for i := 0; i < 20; i++ {
+		status, _ = m.Observe("trng", time.Now(), []Result{{Test: "runs", PValues: []float64{0.001}}})
+	}
+	if status.State != StateFail {
+		t.Fatalf("state = %v, want FAIL", status.State)
+	}
+	for i := 0; i < 20; i++ {
+		status, _ = m.Observe("trng", time.Now(), []Result{{Test: "runs", PValues: []float64{0.5}}})
+	}
+	if status.State != StateOK {
+		t.Errorf("state = %v, want OK once the failing results left the window", status.State)
+	}
+}
+
+// A healthy generator with many sub-tests must not raise false alarms although some of
+// its single p-values fall below alpha.
+func TestMonitorManySubTests(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewMonitor(100, 1)
+	var status Status
+	for i := 0; i < 100; i++ {
+		results := []Result{{Test: "runs", PValues: []float64{rng.Float64()}}}
+		templates := make([]float64, 148)
+		for j := range templates {
+			templates[j] = rng.Float64()
+		}
+		results = append(results, Result{Test: "non_overlapping_template", PValues: templates})
+		status, _ = m.Observe("trng", time.Now(), results)
+	}
+	if status.State == StateFail {
+		t.Errorf("state = %v for uniformly distributed p-values (%+v)", status.State, status.Tests)
+	}
+	if len(status.Tests) != 2 || status.Tests[0].Name != "non_overlapping_template" || status.Tests[0].Samples != 14800 {
+		t.Fatalf("tests = %+v", status.Tests)
+	}
+	if status.Tests[0].Uniformity < 0 {
+		t.Error("uniformity not evaluated with 100 samples per sub-test")
+	}
+}
@@ -0,0 +1,123 @@
+package generators
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	// lcgModulus and lcgMultiplier define the Park-Miller minimal standard generator (D.1).
+	lcgModulus    = 2147483647 // 2^31 - 1
+	lcgMultiplier = 16807
+
+	// width512 is the state size of the quadratic and cubic congruential generators.
+	width512 = 512
+
+	// xorLag is the length of the XOR generator's recurrence (D.5).
+	xorLag = 127
+
+	// prime512 is the 512-bit prime modulus used by QCG-I (D.2) and Modular Exponentiation (D.6).
+	prime512 = "987b6a6bf2c56a97291c445409920032499f9ee7ad128301b5d0254aa1a9633fdbd378d40149f1e23a13849f3d45992f5c4c6b7104099bc301f6005f9d8115e1"
+
+	// seed512Low and seed512High are the default 512-bit seeds of the congruential generators.
+	seed512Low  = "3844506a9456c564b8b8538e0cc15aff46c95e69600f084f0657c2401b3c244734b62ea9bb95be4923b9b7e84eeaf1a224894ef0328d44bc3eb3e983644da3f5"
+	seed512High = "7844506a9456c564b8b8538e0cc15aff46c95e69600f084f0657c2401b3c244734b62ea9bb95be4923b9b7e84eeaf1a224894ef0328d44bc3eb3e983644da3f5"
+)
+
+// generateLCG implements z_{i+1} = 16807 * z_i mod (2^31 - 1) and emits 1 when
+// z_{i+1} / (2^31 - 1) >= 0.5.
+func generateLCG(seed *big.Int, w *bitWriter) error {
+	z := new(big.Int).Mod(seed, big.NewInt(lcgModulus)).Uint64()
+	if z == 0 {
+		return fmt.Errorf("seed must not be a multiple of %d", lcgModulus)
+	}
+
+	for !w.full() {
+		z = z * lcgMultiplier % lcgModulus
+		if float64(z)/lcgModulus >= 0.5 {
+			w.writeBit(1)
+		} else {
+			w.writeBit(0)
+		}
+	}
+	return nil
+}
+
+// generateQCG1 implements x_{i+1} = x_i^2 mod p and emits every x_i as 512 bits.
+func generateQCG1(seed *big.Int, w *bitWriter) error {
+	p := hexInt(prime512)
+	x := new(big.Int).Mod(seed, p)
+	if x.Sign() == 0 {
+		return fmt.Errorf("seed must not be a multiple of the modulus")
+	}
+
+	for !w.full() {
+		x.Mul(x, x).Mod(x, p)
+		w.writeInt(x, width512)
+	}
+	return nil
+}
+
+// generateQCG2 implements x_{i+1} = 2x_i^2 + 3x_i + 1 mod 2^512 and emits every x_i as 512 bits.
+func generateQCG2(seed *big.Int, w *bitWriter) error {
+	mask := maskBits(width512)
+	x := new(big.Int).And(seed, mask)
+	t := new(big.Int)
+
+	for !w.full() {
+		// x = (2x + 3) * x + 1
+		t.Lsh(x, 1).Add(t, big.NewInt(3))
+		x.Mul(x, t).Add(x, big.NewInt(1)).And(x, mask)
+		w.writeInt(x, width512)
+	}
+	return nil
+}
+
+// generateCCG implements x_{i+1} = x_i^3 mod 2^512 and emits every x_i as 512 bits.
+func generateCCG(seed *big.Int, w *bitWriter) error {
+	mask := maskBits(width512)
+	x := new(big.Int).And(seed, mask)
+	if x.Bit(0) == 0 {
+		return fmt.Errorf("seed must be odd")
+	}
+	t := new(big.Int)
+
+	for !w.full() {
+		t.Mul(x, x)
+		x.Mul(x, t).And(x, mask)
+		w.writeInt(x, width512)
+	}
+	return nil
+}
+
+// generateXOR emits the 127-bit seed followed by x_i = x_{i-1} XOR x_{i-127}.
+func generateXOR(seed *big.Int, w *bitWriter) error {
+	if seed.BitLen() > xorLag {
+		return fmt.Errorf("seed must fit in %d bits", xorLag)
+	}
+	if seed.Sign() == 0 {
+		return fmt.Errorf("seed must not be zero")
+	}
+
+	// Circular buffer of the last 127 bits.
+	var bits [xorLag]uint
+	for i := range bits {
+		bits[i] = seed.Bit(xorLag - 1 - i)
+		w.writeBit(bits[i])
+	}
+
+	prev := bits[xorLag-1]
+	for i := 0; !w.full(); i = (i + 1) % xorLag {
+		// bits[i] holds x_{j-127}; it is replaced by x_j.
+		prev ^= bits[i]
+		bits[i] = prev
+		w.writeBit(prev)
+	}
+	return nil
+}
+
+// maskBits returns 2^width - 1.
+func maskBits(width int) *big.Int {
+	mask := new(big.Int).Lsh(big.NewInt(1), uint(width)) //nolint:gosec // width is a small constant
+	return mask.Sub(mask, big.NewInt(1))
+}
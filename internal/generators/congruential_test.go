@@ -0,0 +1,87 @@
+package generators
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestCongruentialPrefixes(t *testing.T) {
+	// Reference prefixes computed independently from the recurrences in Appendix D.
+	tests := []struct {
+		name string
+		want string
+	}{
+		{LCG, "a0731420ba7be2bd"},
+		{QCG1, "7c45bc2ad181c92ebbcda09d6753a09e74a2bbdc0501d112b0c515748e9b1fb9" +
+			"4e7cfcedf7a655d311e9527e2972bf4d04027078af0de4126fcab27c5cf66f46"},
+		{XOR, "16d917929bb440afea42b6004c2e7fcfc923ca39da4f019567064800ef975515"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(tt.name, nil, len(tt.want)*4)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Fatalf("unexpected prefix: got %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQCG2AndCCGRecurrences(t *testing.T) {
+	x := hexInt(seed512High)
+	mask := maskBits(width512)
+
+	out, err := Generate(QCG2, nil, 1024)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	x1 := hexInt(hex.EncodeToString(out[:64]))
+	x2 := hexInt(hex.EncodeToString(out[64:]))
+	if x1.Cmp(qcg2Step(x, mask)) != 0 || x2.Cmp(qcg2Step(x1, mask)) != 0 {
+		t.Fatal("QCG-II output does not follow 2x^2 + 3x + 1 mod 2^512")
+	}
+
+	out, err = Generate(CCG, nil, 512)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := hexInt(seed512High)
+	want.Exp(want, hexInt("3"), nil).And(want, mask)
+	if hexInt(hex.EncodeToString(out)).Cmp(want) != 0 {
+		t.Fatal("CCG output does not follow x^3 mod 2^512")
+	}
+}
+
+func qcg2Step(x, mask *big.Int) *big.Int {
+	y := new(big.Int).Mul(x, x)
+	y.Lsh(y, 1)
+	y.Add(y, new(big.Int).Mul(x, big.NewInt(3)))
+	y.Add(y, big.NewInt(1))
+	return y.And(y, mask)
+}
+
+func TestCongruentialSeedErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		seed string
+	}{
+		{LCG, "7fffffff"},
+		{QCG1, prime512},
+		{CCG, "02"},
+		{XOR, "80000000000000000000000000000000"},
+		{XOR, "00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seed, _ := hex.DecodeString(tt.seed)
+			if _, err := Generate(tt.name, seed, 64); err == nil {
+				t.Fatalf("expected error for seed %s", tt.seed)
+			}
+		})
+	}
+}
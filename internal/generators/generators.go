@@ -0,0 +1,123 @@
+// Package generators provides the reference pseudorandom bit generators described in
+// SP 800-22 Rev 1a Appendix D. They are deterministic for a given seed and are used to
+// exercise the test battery without shipping data files.
+package generators
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+// Names of the available generators.
+const (
+	LCG           = "lcg"
+	QCG1          = "qcg1"
+	QCG2          = "qcg2"
+	CCG           = "ccg"
+	XOR           = "xor"
+	ModExp        = "modexp"
+	BBS           = "bbs"
+	MicaliSchnorr = "micali_schnorr"
+	GSHA1         = "g_sha1"
+)
+
+// Names lists the available generators in the order of Appendix D.
+var Names = []string{LCG, QCG1, QCG2, CCG, XOR, ModExp, BBS, MicaliSchnorr, GSHA1}
+
+// generator produces nBits bits into w starting from seed.
+type generator struct {
+	defaultSeed string // hex
+	generate    func(seed *big.Int, w *bitWriter) error
+}
+
+var registry = map[string]generator{
+	LCG:           {"01664fed", generateLCG},
+	QCG1:          {seed512Low, generateQCG1},
+	QCG2:          {seed512High, generateQCG2},
+	CCG:           {seed512High, generateCCG},
+	XOR:           {"0b6c8bc94dda2057f5215b0026173fe7", generateXOR},
+	ModExp:        {"7ab36982ce1adf832019cdfeb2393cabdf0214ec", generateModExp},
+	BBS:           {"10d6333cfac8e30e808d2192f7c0439480da79db9bbca1667d73be9a677ed31311f3b830937763837cb7b1b1dc75f14eea417f84d9625628750de99e7ef1e976", generateBBS},
+	MicaliSchnorr: {"0237c5f791c2cfe47bfb16d2d54a0d60665b20904ec822a6", generateMicaliSchnorr},
+	GSHA1:         {"ec822a619d6ed5d9492218a7a4c5b15d57c61601", generateGSHA1},
+}
+
+// Generate returns nBits bits of the named generator, packed MSB-first. The seed is a
+// big-endian unsigned integer; the generator's default seed is used if it is empty.
+// nBits must be a positive multiple of 8.
+func Generate(name string, seed []byte, nBits int) ([]byte, error) {
+	g, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown generator: %q", name)
+	}
+	if nBits <= 0 || nBits%8 != 0 {
+		return nil, fmt.Errorf("invalid output length: %d bits (must be a positive multiple of 8)", nBits)
+	}
+
+	if len(seed) == 0 {
+		seed = DefaultSeed(name)
+	}
+
+	w := newBitWriter(nBits)
+	if err := g.generate(new(big.Int).SetBytes(seed), w); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return w.out, nil
+}
+
+// DefaultSeed returns the seed used by Generate when none is given, or nil if the
+// generator does not exist.
+func DefaultSeed(name string) []byte {
+	g, ok := registry[name]
+	if !ok {
+		return nil
+	}
+	seed, err := hex.DecodeString(g.defaultSeed)
+	if err != nil {
+		panic(fmt.Sprintf("generators: invalid default seed for %s: %v", name, err))
+	}
+	return seed
+}
+
+// bitWriter collects generator output MSB-first into a fixed-size buffer.
+type bitWriter struct {
+	out []byte
+	n   int
+}
+
+func newBitWriter(nBits int) *bitWriter {
+	return &bitWriter{out: make([]byte, nBits/8)}
+}
+
+// full reports whether the requested number of bits has been written.
+func (w *bitWriter) full() bool {
+	return w.n == len(w.out)*8
+}
+
+// writeBit appends a single bit; it is ignored once the writer is full.
+func (w *bitWriter) writeBit(b uint) {
+	if w.full() {
+		return
+	}
+	if b&1 == 1 {
+		w.out[w.n>>3] |= 0x80 >> (w.n & 7)
+	}
+	w.n++
+}
+
+// writeInt appends the width least significant bits of x, most significant first.
+func (w *bitWriter) writeInt(x *big.Int, width int) {
+	for i := width - 1; i >= 0 && !w.full(); i-- {
+		w.writeBit(x.Bit(i))
+	}
+}
+
+// hexInt parses a hex constant; it panics on malformed input.
+func hexInt(s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("generators: invalid hex constant " + s)
+	}
+	return x
+}
@@ -0,0 +1,74 @@
+package generators
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateAllGenerators(t *testing.T) {
+	for _, name := range Names {
+		t.Run(name, func(t *testing.T) {
+			a, err := Generate(name, nil, 4096)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if len(a) != 512 {
+				t.Fatalf("expected 512 bytes, got %d", len(a))
+			}
+
+			b, err := Generate(name, DefaultSeed(name), 4096)
+			if err != nil {
+				t.Fatalf("Generate with default seed failed: %v", err)
+			}
+			if !bytes.Equal(a, b) {
+				t.Fatal("empty seed must select the default seed")
+			}
+
+			long, err := Generate(name, nil, 8192)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if !bytes.Equal(long[:len(a)], a) {
+				t.Fatal("longer output does not extend shorter one")
+			}
+
+			other, err := Generate(name, []byte{0x01, 0x23, 0x45, 0x67}, 4096)
+			if err != nil {
+				t.Fatalf("Generate with custom seed failed: %v", err)
+			}
+			if bytes.Equal(a, other) {
+				t.Fatal("different seeds produced identical output")
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := Generate("mersenne_twister", nil, 64); err == nil {
+		t.Error("expected error for unknown generator")
+	}
+	if _, err := Generate(LCG, nil, 0); err == nil {
+		t.Error("expected error for zero length")
+	}
+	if _, err := Generate(LCG, nil, 12); err == nil {
+		t.Error("expected error for length not a multiple of 8")
+	}
+	if DefaultSeed("mersenne_twister") != nil {
+		t.Error("expected nil default seed for unknown generator")
+	}
+}
+
+func TestBitWriter(t *testing.T) {
+	w := newBitWriter(16)
+	for _, b := range []uint{1, 0, 1, 1} {
+		w.writeBit(b)
+	}
+	w.writeInt(hexInt("abc"), 12)
+	if !w.full() {
+		t.Fatal("writer should be full")
+	}
+	w.writeBit(1) // ignored
+	if !bytes.Equal(w.out, []byte{0xba, 0xbc}) {
+		t.Fatalf("unexpected output %x", w.out)
+	}
+}
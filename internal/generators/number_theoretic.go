@@ -0,0 +1,85 @@
+package generators
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	// modExpBase is the generator g of the Modular Exponentiation generator (D.6).
+	modExpBase = seed512Low
+	// modExpSeedBits is the size of the exponent fed back from each output block.
+	modExpSeedBits = 160
+
+	// bbsP and bbsQ are the 512-bit primes (both 3 mod 4) whose product is the modulus of
+	// the Blum-Blum-Shub (D.7) and Micali-Schnorr (D.8) generators.
+	bbsP = "e65097baec92e70478caf4ed0ed94e1c94b154466bfb9ec9be37b2b0ff8526c222b76e0e915017535ae8b9207250257d0a0c87c0dacef78e17d1ef9dc44fd91f"
+	bbsQ = "e029aefcf8ea2c29d99cb53dd5fa9bc1d0176f5df8d9110fd16ee21f32e37ba86ff42f00531ad5b8a43073182cc2e15f5c86e8da059e346777c9a985f7d8a867"
+
+	// Micali-Schnorr parameters for a 1024-bit modulus: k = floor(N(1 - 2/e)) output bits
+	// and r = N - k feedback bits per step.
+	msExponent = 11
+	msOutBits  = 837
+	msSeedBits = 187
+)
+
+// generateModExp implements x_i = g^{y_i} mod p, emitting every x_i as 512 bits and
+// feeding its 160 least significant bits back as the next exponent.
+func generateModExp(seed *big.Int, w *bitWriter) error {
+	p := hexInt(prime512)
+	g := hexInt(modExpBase)
+	mask := maskBits(modExpSeedBits)
+
+	y := new(big.Int).And(seed, mask)
+	x := new(big.Int)
+	for !w.full() {
+		x.Exp(g, y, p)
+		w.writeInt(x, width512)
+		y.And(x, mask)
+	}
+	return nil
+}
+
+// generateBBS implements x_{i+1} = x_i^2 mod n with x_0 = seed^2 mod n and emits the
+// least significant bit of every x_i (i >= 1).
+func generateBBS(seed *big.Int, w *bitWriter) error {
+	n := bbsModulus()
+	if new(big.Int).GCD(nil, nil, seed, n).Cmp(big.NewInt(1)) != 0 {
+		return fmt.Errorf("seed must be coprime to the modulus")
+	}
+
+	x := new(big.Int).Exp(seed, big.NewInt(2), n)
+	if x.Cmp(big.NewInt(1)) == 0 {
+		return fmt.Errorf("seed must not be a square root of 1")
+	}
+
+	for !w.full() {
+		x.Mul(x, x).Mod(x, n)
+		w.writeBit(x.Bit(0))
+	}
+	return nil
+}
+
+// generateMicaliSchnorr implements y_i = x_{i-1}^e mod n, where x_i are the r most
+// significant bits of y_i and the k least significant bits of y_i are emitted.
+func generateMicaliSchnorr(seed *big.Int, w *bitWriter) error {
+	n := bbsModulus()
+	e := big.NewInt(msExponent)
+	x := new(big.Int).And(seed, maskBits(msSeedBits))
+	if x.Sign() == 0 {
+		return fmt.Errorf("seed must not be zero modulo 2^%d", msSeedBits)
+	}
+
+	y := new(big.Int)
+	for !w.full() {
+		y.Exp(x, e, n)
+		w.writeInt(y, msOutBits)
+		x.Rsh(y, msOutBits)
+	}
+	return nil
+}
+
+// bbsModulus returns n = p * q.
+func bbsModulus() *big.Int {
+	return new(big.Int).Mul(hexInt(bbsP), hexInt(bbsQ))
+}
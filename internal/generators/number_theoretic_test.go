@@ -0,0 +1,71 @@
+package generators
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestBBSPrefix(t *testing.T) {
+	// Reference prefix computed independently from x_{i+1} = x_i^2 mod pq.
+	got, err := Generate(BBS, nil, 64)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if hex.EncodeToString(got) != "1b260c795fae8bf4" {
+		t.Fatalf("unexpected prefix %x", got)
+	}
+}
+
+func TestModExpFeedback(t *testing.T) {
+	out, err := Generate(ModExp, nil, 1024)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	p, g := hexInt(prime512), hexInt(modExpBase)
+	y := new(big.Int).SetBytes(DefaultSeed(ModExp))
+	for i := 0; i < 2; i++ {
+		x := new(big.Int).Exp(g, y, p)
+		if new(big.Int).SetBytes(out[64*i:64*(i+1)]).Cmp(x) != 0 {
+			t.Fatalf("block %d does not equal g^y mod p", i)
+		}
+		y.And(x, maskBits(modExpSeedBits))
+	}
+}
+
+func TestMicaliSchnorrBlocks(t *testing.T) {
+	out, err := Generate(MicaliSchnorr, nil, 8*((msOutBits+7)/8)) // first block plus a few bits
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	x := new(big.Int).And(new(big.Int).SetBytes(DefaultSeed(MicaliSchnorr)), maskBits(msSeedBits))
+	y := new(big.Int).Exp(x, big.NewInt(msExponent), bbsModulus())
+	z := new(big.Int).And(y, maskBits(msOutBits))
+
+	got := new(big.Int).SetBytes(out)
+	got.Rsh(got, uint(len(out)*8-msOutBits))
+	if got.Cmp(z) != 0 {
+		t.Fatal("first block does not equal the k least significant bits of x^e mod n")
+	}
+}
+
+func TestNumberTheoreticSeedErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		seed []byte
+	}{
+		{BBS, hexInt(bbsP).Bytes()},
+		{BBS, []byte{0x01}},
+		{MicaliSchnorr, new(big.Int).Lsh(big.NewInt(1), msSeedBits).Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Generate(tt.name, tt.seed, 64); err == nil {
+				t.Fatalf("expected error for seed %x", tt.seed)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package generators
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// gSHA1SeedBits is the size b of the XKEY state of the FIPS 186-2 generator (D.9).
+const gSHA1SeedBits = 160
+
+// sha1IV is the initial SHA-1 chaining value, used as t in G(t, c).
+var sha1IV = [5]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0}
+
+// generateGSHA1 implements the FIPS 186-2 Appendix 3 generator with the SHA-1 based
+// one-way function G: x_j = G(t, XKEY) and XKEY = (1 + XKEY + x_j) mod 2^b. Every x_j
+// is emitted as 160 bits.
+func generateGSHA1(seed *big.Int, w *bitWriter) error {
+	mask := maskBits(gSHA1SeedBits)
+	xkey := new(big.Int).And(seed, mask)
+	if xkey.Sign() == 0 {
+		return fmt.Errorf("seed must not be zero modulo 2^%d", gSHA1SeedBits)
+	}
+
+	var block [64]byte
+	x := new(big.Int)
+	one := big.NewInt(1)
+	for !w.full() {
+		// c = XKEY padded with zeros to a full 512-bit block (no SHA-1 length padding).
+		clear(block[:])
+		xkey.FillBytes(block[:gSHA1SeedBits/8])
+
+		h := sha1Block(sha1IV, &block)
+		var digest [20]byte
+		for i, v := range h {
+			binary.BigEndian.PutUint32(digest[4*i:], v)
+		}
+
+		x.SetBytes(digest[:])
+		w.writeInt(x, gSHA1SeedBits)
+
+		xkey.Add(xkey, one).Add(xkey, x).And(xkey, mask)
+	}
+	return nil
+}
+
+// sha1Block applies the SHA-1 compression function to a single 512-bit block.
+func sha1Block(h [5]uint32, block *[64]byte) [5]uint32 {
+	var m [80]uint32
+	for i := 0; i < 16; i++ {
+		m[i] = binary.BigEndian.Uint32(block[4*i:])
+	}
+	for i := 16; i < 80; i++ {
+		m[i] = bits.RotateLeft32(m[i-3]^m[i-8]^m[i-14]^m[i-16], 1)
+	}
+
+	a, b, c, d, e := h[0], h[1], h[2], h[3], h[4]
+	for i := 0; i < 80; i++ {
+		var f, k uint32
+		switch {
+		case i < 20:
+			f, k = (b&c)|(^b&d), 0x5a827999
+		case i < 40:
+			f, k = b^c^d, 0x6ed9eba1
+		case i < 60:
+			f, k = (b&c)|(b&d)|(c&d), 0x8f1bbcdc
+		default:
+			f, k = b^c^d, 0xca62c1d6
+		}
+		t := bits.RotateLeft32(a, 5) + f + e + k + m[i]
+		a, b, c, d, e = t, a, bits.RotateLeft32(b, 30), c, d
+	}
+
+	return [5]uint32{h[0] + a, h[1] + b, h[2] + c, h[3] + d, h[4] + e}
+}
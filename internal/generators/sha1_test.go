@@ -0,0 +1,52 @@
+package generators
+
+import (
+	"crypto/sha1" //nolint:gosec // used only to verify the compression function
+	"encoding/binary"
+	"math/big"
+	"testing"
+)
+
+func TestSHA1BlockMatchesStandardLibrary(t *testing.T) {
+	// "abc" with SHA-1 padding fits into a single block.
+	var block [64]byte
+	copy(block[:], "abc")
+	block[3] = 0x80
+	binary.BigEndian.PutUint64(block[56:], 24)
+
+	h := sha1Block(sha1IV, &block)
+	var got [20]byte
+	for i, v := range h {
+		binary.BigEndian.PutUint32(got[4*i:], v)
+	}
+
+	if want := sha1.Sum([]byte("abc")); got != want {
+		t.Fatalf("sha1Block mismatch: got %x, want %x", got, want)
+	}
+}
+
+func TestGSHA1KeyUpdate(t *testing.T) {
+	out, err := Generate(GSHA1, nil, 320)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	xkey := new(big.Int).SetBytes(DefaultSeed(GSHA1))
+	for i := 0; i < 2; i++ {
+		var block [64]byte
+		xkey.FillBytes(block[:20])
+		h := sha1Block(sha1IV, &block)
+		var x [20]byte
+		for j, v := range h {
+			binary.BigEndian.PutUint32(x[4*j:], v)
+		}
+		if string(out[20*i:20*(i+1)]) != string(x[:]) {
+			t.Fatalf("block %d does not equal G(t, XKEY)", i)
+		}
+		xkey.Add(xkey, big.NewInt(1)).Add(xkey, new(big.Int).SetBytes(x[:])).And(xkey, maskBits(gSHA1SeedBits))
+	}
+
+	if _, err := Generate(GSHA1, new(big.Int).Lsh(big.NewInt(1), gSHA1SeedBits).Bytes(), 64); err == nil {
+		t.Fatal("expected error for seed that is zero modulo 2^160")
+	}
+}
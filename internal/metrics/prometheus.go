@@ -1,10 +1,30 @@
 package metrics
 
 import (
+	"strconv"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// PValueBuckets are the upper bounds of the ten uniform p-value bins.
+var PValueBuckets = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1}
+
+// MaxSources bounds the source label values; further sources are reported as OtherSource.
+const MaxSources = 100
+
+// Source label values of requests without a source and of sources beyond MaxSources.
+const (
+	NoSource    = "none"
+	OtherSource = "other"
+)
+
+var (
+	sourcesMu sync.Mutex
+	sources   = make(map[string]bool)
+)
+
 var (
 	// TestsTotal counts the total number of individual tests run
 	TestsTotal = promauto.NewCounterVec(
@@ -51,6 +71,98 @@ var (
 		[]string{"test"},
 	)
 
+	// PValueDistribution collects the sub-test p-values of each test and source in the ten
+	// uniform bins of the NIST uniformity check
+	PValueDistribution = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "nist_p_value_distribution",
+			Help:    "Distribution of the sub-test p-values of individual NIST tests in ten uniform bins",
+			Buckets: PValueBuckets,
+		},
+		[]string{"test", "source"},
+	)
+
+	// PValueUniformity reports the uniformity p-value of the last p-values of each test and source
+	PValueUniformity = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nist_p_value_uniformity",
+			Help: "Chi-squared uniformity p-value of the sub-test p-values in the sliding window of each test and source",
+		},
+		[]string{"test", "source"},
+	)
+
+	// SubTestFailuresTotal counts failed sub-tests of the tests that report several p-values
+	SubTestFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nist_subtest_failures_total",
+			Help: "Total number of failed sub-tests (e.g. templates or excursion states) by test and sub-test index",
+		},
+		[]string{"test", "subtest"},
+	)
+
+	// InputBits tracks the size of the analyzed inputs
+	InputBits = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "nist_input_bits",
+			Help:    "Size in bits of the sequences analyzed",
+			Buckets: []float64{387840, 1e6, 2e6, 5e6, 1e7},
+		},
+		[]string{"method"},
+	)
+
+	// SourceState reports the drift state of the rolling window of each source
+	SourceState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nist_source_state",
+			Help: "Drift state of the rolling window of a source (0 = unknown, 1 = OK, 2 = WARN, 3 = FAIL)",
+		},
+		[]string{"source"},
+	)
+
+	// SourceWindowSamples reports the number of submissions in the rolling window of each source
+	SourceWindowSamples = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nist_source_window_samples",
+			Help: "Number of submissions in the rolling drift window of a source",
+		},
+		[]string{"source"},
+	)
+
+	// NotificationsTotal counts outbound notifications by event kind and result
+	NotificationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nist_notifications_total",
+			Help: "Total number of outbound notifications by event kind and result (success, error or dropped)",
+		},
+		[]string{"kind", "result"},
+	)
+
+	// SelfTestStatus reports the outcome of the last known-answer self-test
+	SelfTestStatus = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nist_self_test_status",
+			Help: "Outcome of the last known-answer self-test (1 = passed, 0 = failed, -1 = pending)",
+		},
+	)
+
+	// SelfTestRunsTotal counts completed self-test runs by result
+	SelfTestRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nist_self_test_runs_total",
+			Help: "Total number of known-answer self-test runs",
+		},
+		[]string{"result"},
+	)
+
+	// SelfTestDuration tracks the duration of self-test runs
+	SelfTestDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "nist_self_test_duration_seconds",
+			Help:    "Duration of known-answer self-test runs in seconds",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 8),
+		},
+	)
+
 	// RequestsTotal counts total gRPC requests
 	RequestsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -59,6 +171,31 @@ var (
 		},
 		[]string{"method", "status"},
 	)
+
+	// AdmissionRejectedTotal counts requests rejected by admission control by reason
+	AdmissionRejectedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nist_admission_rejected_total",
+			Help: "Total number of requests rejected by admission control (rate_limit or capacity)",
+		},
+		[]string{"reason"},
+	)
+
+	// InFlightBits reports the bits of the requests admitted and not yet completed
+	InFlightBits = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nist_inflight_bits",
+			Help: "Total size in bits of the requests currently being processed",
+		},
+	)
+
+	// InFlightRequests reports the number of admitted requests not yet completed
+	InFlightRequests = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nist_inflight_requests",
+			Help: "Number of requests currently being processed",
+		},
+	)
 )
 
 // RecordTestDuration records the duration of a test
@@ -80,3 +217,64 @@ func RecordPValue(testName string, pValue float64) {
 func IncrementRequestsTotal(method, status string) {
 	RequestsTotal.WithLabelValues(method, status).Inc()
 }
+
+// RecordAdmissionRejected increments the admission rejection counter
+func RecordAdmissionRejected(reason string) {
+	AdmissionRejectedTotal.WithLabelValues(reason).Inc()
+}
+
+// SourceLabel returns the label value of source. Sources are client supplied, so only the
+// first MaxSources get their own label.
+func SourceLabel(source string) string {
+	if source == "" {
+		return NoSource
+	}
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	if !sources[source] {
+		if len(sources) >= MaxSources {
+			return OtherSource
+		}
+		sources[source] = true
+	}
+	return source
+}
+
+// RecordPValueDistribution adds a p-value to the distribution of its test and source label
+func RecordPValueDistribution(testName, source string, pValue float64) {
+	PValueDistribution.WithLabelValues(testName, source).Observe(pValue)
+}
+
+// RecordSubTestFailure increments the failure counter of a sub-test
+func RecordSubTestFailure(testName string, subTest int) {
+	SubTestFailuresTotal.WithLabelValues(testName, strconv.Itoa(subTest)).Inc()
+}
+
+// RecordInputBits records the size of an analyzed input
+func RecordInputBits(method string, bits int) {
+	InputBits.WithLabelValues(method).Observe(float64(bits))
+}
+
+// RecordSourceStatus records the drift state and window fill of a source
+func RecordSourceStatus(source string, state, samples int) {
+	SourceState.WithLabelValues(source).Set(float64(state))
+	SourceWindowSamples.WithLabelValues(source).Set(float64(samples))
+}
+
+// RecordNotification increments the notification counter
+func RecordNotification(kind, result string) {
+	NotificationsTotal.WithLabelValues(kind, result).Inc()
+}
+
+// RecordSelfTest records the outcome and duration of a self-test run
+func RecordSelfTest(passed bool, durationSeconds float64) {
+	result := "fail"
+	status := 0.0
+	if passed {
+		result = "pass"
+		status = 1.0
+	}
+	SelfTestStatus.Set(status)
+	SelfTestRunsTotal.WithLabelValues(result).Inc()
+	SelfTestDuration.Observe(durationSeconds)
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -17,6 +18,24 @@ func TestMetricsRegistration(t *testing.T) {
 	if _, err := PValue.GetMetricWithLabelValues("frequency"); err != nil {
 		t.Fatalf("PValue missing labels: %v", err)
 	}
+	if _, err := PValueDistribution.GetMetricWithLabelValues("frequency", NoSource); err != nil {
+		t.Fatalf("PValueDistribution missing labels: %v", err)
+	}
+	if _, err := SubTestFailuresTotal.GetMetricWithLabelValues("frequency", "0"); err != nil {
+		t.Fatalf("SubTestFailuresTotal missing labels: %v", err)
+	}
+	if _, err := InputBits.GetMetricWithLabelValues("RunTests"); err != nil {
+		t.Fatalf("InputBits missing labels: %v", err)
+	}
+	if _, err := SourceState.GetMetricWithLabelValues("trng"); err != nil {
+		t.Fatalf("SourceState missing labels: %v", err)
+	}
+	if _, err := SourceWindowSamples.GetMetricWithLabelValues("trng"); err != nil {
+		t.Fatalf("SourceWindowSamples missing labels: %v", err)
+	}
+	if _, err := NotificationsTotal.GetMetricWithLabelValues("run_failed", "success"); err != nil {
+		t.Fatalf("NotificationsTotal missing labels: %v", err)
+	}
 	if _, err := RequestsTotal.GetMetricWithLabelValues("RunTests", "success"); err != nil {
 		t.Fatalf("RequestsTotal missing labels: %v", err)
 	}
@@ -33,6 +52,15 @@ func TestMetricsRegistration(t *testing.T) {
 		"nist_last_overall_pass_rate":   false,
 		"nist_p_value":                  false,
 		"nist_requests_total":           false,
+		"nist_self_test_status":         false,
+		"nist_inflight_bits":            false,
+		"nist_inflight_requests":        false,
+		"nist_p_value_distribution":     false,
+		"nist_subtest_failures_total":   false,
+		"nist_input_bits":               false,
+		"nist_source_state":             false,
+		"nist_source_window_samples":    false,
+		"nist_notifications_total":      false,
 	}
 	for _, mf := range mfs {
 		if _, ok := required[mf.GetName()]; ok {
@@ -52,7 +80,33 @@ func TestMetricWrappers(t *testing.T) {
 	IncrementTestsTotal("test_test", "pass")
 	RecordPValue("test_test", 0.5)
 	IncrementRequestsTotal("TestRPC", "ok")
+	RecordSelfTest(true, 1.5)
+	RecordSelfTest(false, 2.0)
+	RecordAdmissionRejected("capacity")
+	RecordPValueDistribution("test_test", NoSource, 0.42)
+	RecordSubTestFailure("test_test", 3)
+	RecordInputBits("TestRPC", 1000000)
+	RecordSourceStatus("test_source", 1, 10)
+	RecordNotification("run_failed", "success")
 
 	// We can't easily check the exact values without more complex setup,
 	// but running them ensures coverage and no panics.
 }
+
+func TestSourceLabel(t *testing.T) {
+	if got := SourceLabel(""); got != NoSource {
+		t.Errorf("expected %q for an empty source, got %q", NoSource, got)
+	}
+	for i := 0; i < MaxSources; i++ {
+		source := fmt.Sprintf("source-%d", i)
+		if got := SourceLabel(source); got != source {
+			t.Fatalf("expected own label for %s, got %q", source, got)
+		}
+	}
+	if got := SourceLabel("one-too-many"); got != OtherSource {
+		t.Errorf("expected %q beyond MaxSources, got %q", OtherSource, got)
+	}
+	if got := SourceLabel("source-0"); got != "source-0" {
+		t.Errorf("known source lost its label: %q", got)
+	}
+}
@@ -0,0 +1,296 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AmmannChristian/go-authx/grpcserver"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/metrics"
+)
+
+// RateLimit is a token bucket refilled at Rate requests per second up to Burst tokens.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// AdmissionConfig configures UnaryAdmissionInterceptor.
+type AdmissionConfig struct {
+	// MaxInFlightBits bounds the total size of the requests processed concurrently; 0
+	// disables the budget. A request larger than the budget is admitted only when no
+	// other request is in flight.
+	MaxInFlightBits int64
+	// DefaultBits is the weight of requests that carry neither a bitstream nor a bit
+	// count, and of requests whose bit count is 0 (the server default).
+	DefaultBits int64
+	// DatasetBits is the weight of each data set of a request that lists data sets, such
+	// as a self-test; DefaultDatasets is their number when the request lists none.
+	DatasetBits     int64
+	DefaultDatasets int
+	// ExtraBits, if set, returns the weight a request adds to its size for options that
+	// cost more than the size suggests, such as precise p-values.
+	ExtraBits func(req interface{}) int64
+	// RateLimit applies to every identity without an entry in Limits; a zero Rate
+	// disables rate limiting for them.
+	RateLimit RateLimit
+	// Limits overrides RateLimit per identity.
+	Limits map[string]RateLimit
+	// ExemptMethods are full method names that bypass admission control.
+	ExemptMethods []string
+}
+
+const (
+	// capacityRetryDelay is the retry hint when the in-flight budget is exhausted.
+	capacityRetryDelay = time.Second
+	// maxIdleBuckets is the number of identities above which full buckets, which are
+	// equivalent to new ones, are dropped.
+	maxIdleBuckets = 10000
+)
+
+// Admission holds the state shared by all calls of the admission interceptor. Its
+// limits can be changed while it is in use.
+type Admission struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	cfg      AdmissionConfig
+	inFlight int64
+	buckets  map[string]*tokenBucket
+}
+
+// NewAdmission returns an admission controller for cfg.
+func NewAdmission(cfg AdmissionConfig) *Admission {
+	return &Admission{cfg: cfg, now: time.Now, buckets: map[string]*tokenBucket{}}
+}
+
+// UnaryAdmissionInterceptor rejects requests with ResourceExhausted when the caller's
+// token bucket is empty or the in-flight budget, weighted by the number of bits per
+// request, is used up. Rejections carry a RetryInfo detail and a retry-after header in
+// seconds. It identifies callers by Identity and must run after authentication.
+func UnaryAdmissionInterceptor(cfg AdmissionConfig) grpc.UnaryServerInterceptor {
+	return NewAdmission(cfg).Intercept
+}
+
+// Reconfigure replaces the limits. Requests in flight keep their reservation, and
+// buckets keep their tokens up to the new burst.
+func (a *Admission) Reconfigure(cfg AdmissionConfig) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cfg = cfg
+}
+
+// Intercept is the unary interceptor, see UnaryAdmissionInterceptor.
+func (a *Admission) Intercept(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	cfg := a.config()
+	if slices.Contains(cfg.ExemptMethods, info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	identity := Identity(ctx)
+	if wait, ok := a.allow(identity); !ok {
+		metrics.RecordAdmissionRejected("rate_limit")
+		return nil, exhausted(ctx, wait, "rate limit exceeded for %s", identity)
+	}
+
+	reserved, ok := a.acquire(weight(req, cfg))
+	if !ok {
+		metrics.RecordAdmissionRejected("capacity")
+		return nil, exhausted(ctx, capacityRetryDelay, "server busy: %d bits in flight, limit is %d", a.inFlightBits(), cfg.MaxInFlightBits)
+	}
+	defer a.release(reserved)
+
+	return handler(ctx, req)
+}
+
+func (a *Admission) config() AdmissionConfig {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cfg
+}
+
+// weight returns the number of bits a request asks the server to process, plus the
+// ExtraBits of its options.
+func weight(req interface{}, cfg AdmissionConfig) int64 {
+	w := requestBits(req, cfg)
+	if cfg.ExtraBits != nil {
+		w += cfg.ExtraBits(req)
+	}
+	return w
+}
+
+// requestBits returns the size of the input of a request in bits.
+func requestBits(req interface{}, cfg AdmissionConfig) int64 {
+	switch r := req.(type) {
+	case interface{ GetBitstream() []byte }:
+		return int64(len(r.GetBitstream())) * 8
+	case interface{ GetBits() int32 }:
+		if bits := r.GetBits(); bits > 0 {
+			return int64(bits)
+		}
+	case interface{ GetDatasets() []string }:
+		n := len(r.GetDatasets())
+		if n == 0 {
+			n = cfg.DefaultDatasets
+		}
+		return int64(n) * cfg.DatasetBits
+	}
+	return cfg.DefaultBits
+}
+
+// acquire reserves weight bits of the in-flight budget, capped at the budget so that a
+// single oversized request can still run, and returns the reserved bits.
+func (a *Admission) acquire(weight int64) (int64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if limit := a.cfg.MaxInFlightBits; limit > 0 {
+		weight = min(weight, limit)
+		if a.inFlight+weight > limit {
+			return 0, false
+		}
+	}
+	a.inFlight += weight
+	metrics.InFlightBits.Set(float64(a.inFlight))
+	metrics.InFlightRequests.Inc()
+	return weight, true
+}
+
+// release returns the bits reserved by acquire.
+func (a *Admission) release(reserved int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.inFlight -= reserved
+	metrics.InFlightBits.Set(float64(a.inFlight))
+	metrics.InFlightRequests.Dec()
+}
+
+func (a *Admission) inFlightBits() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.inFlight
+}
+
+// allow takes a token from the identity's bucket or returns the time until one is
+// available.
+func (a *Admission) allow(identity string) (time.Duration, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limit, ok := a.cfg.Limits[identity]
+	if !ok {
+		limit = a.cfg.RateLimit
+	}
+	if limit.Rate <= 0 {
+		return 0, true
+	}
+	now := a.now()
+
+	b, ok := a.buckets[identity]
+	if !ok {
+		if len(a.buckets) >= maxIdleBuckets {
+			a.dropFullBuckets(now)
+		}
+		b = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		a.buckets[identity] = b
+	}
+	return b.take(limit, now)
+}
+
+// dropFullBuckets removes the buckets that have refilled completely.
+func (a *Admission) dropFullBuckets(now time.Time) {
+	for identity, b := range a.buckets {
+		limit, ok := a.cfg.Limits[identity]
+		if !ok {
+			limit = a.cfg.RateLimit
+		}
+		b.refill(limit, now)
+		if b.tokens >= float64(limit.Burst) {
+			delete(a.buckets, identity)
+		}
+	}
+}
+
+// tokenBucket holds the tokens of one identity as of last.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(limit RateLimit, now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+}
+
+func (b *tokenBucket) take(limit RateLimit, now time.Time) (time.Duration, bool) {
+	b.refill(limit, now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return wait, false
+}
+
+// exhausted returns a ResourceExhausted error with a retry hint of wait, rounded to
+// milliseconds in the RetryInfo detail and up to seconds in the retry-after header.
+func exhausted(ctx context.Context, wait time.Duration, format string, args ...interface{}) error {
+	wait = max(wait.Round(time.Millisecond), time.Millisecond)
+	seconds := int64(math.Ceil(wait.Seconds()))
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10)))
+
+	msg := fmt.Sprintf(format, args...)
+	st, err := status.New(codes.ResourceExhausted, fmt.Sprintf("%s; retry after %s", msg, wait)).
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return status.Errorf(codes.ResourceExhausted, "%s; retry after %s", msg, wait)
+	}
+	return st.Err()
+}
+
+// Identity returns the caller's identity for rate limiting: the subject of a validated
+// JWT, the common name of a verified client certificate, or the peer IP address.
+func Identity(ctx context.Context) string {
+	if claims, ok := grpcserver.TokenClaimsFromContext(ctx); ok && claims.Subject != "" {
+		return claims.Subject
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		if chains := tlsInfo.State.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+			if cn := chains[0][0].Subject.CommonName; cn != "" {
+				return cn
+			}
+		}
+	}
+	if p.Addr == nil {
+		return "unknown"
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
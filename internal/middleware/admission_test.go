@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/AmmannChristian/go-authx/grpcserver"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb"
+)
+
+var testInfo = &grpc.UnaryServerInfo{FullMethod: pb.Sp80022TestService_RunTestSuite_FullMethodName}
+
+func okHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	return "ok", nil
+}
+
+// newTestAdmission returns an admission controller whose clock is advanced by the
+// returned function.
+func newTestAdmission(cfg AdmissionConfig) (*Admission, func(time.Duration)) {
+	now := time.Unix(0, 0)
+	a := &Admission{cfg: cfg, now: func() time.Time { return now }, buckets: map[string]*tokenBucket{}}
+	return a, func(d time.Duration) { now = now.Add(d) }
+}
+
+// retryDelay asserts that err is ResourceExhausted and returns its RetryInfo delay.
+func retryDelay(t *testing.T, err error) time.Duration {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+	t.Fatalf("no RetryInfo detail in %v", err)
+	return 0
+}
+
+func TestAdmissionRateLimit(t *testing.T) {
+	a, advance := newTestAdmission(AdmissionConfig{
+		RateLimit: RateLimit{Rate: 2, Burst: 2},
+		Limits:    map[string]RateLimit{"ci": {Rate: 0}},
+	})
+	ctx := grpcserver.WithTokenClaims(context.Background(), &grpcserver.TokenClaims{Subject: "alice"})
+
+	for i := 0; i < 2; i++ {
+		if _, err := a.Intercept(ctx, nil, testInfo, okHandler); err != nil {
+			t.Fatalf("request %d within burst rejected: %v", i, err)
+		}
+	}
+	_, err := a.Intercept(ctx, nil, testInfo, okHandler)
+	if d := retryDelay(t, err); d != 500*time.Millisecond {
+		t.Errorf("retry delay %s, want 500ms", d)
+	}
+
+	advance(250 * time.Millisecond)
+	_, err = a.Intercept(ctx, nil, testInfo, okHandler)
+	if d := retryDelay(t, err); d != 250*time.Millisecond {
+		t.Errorf("retry delay %s, want 250ms", d)
+	}
+
+	advance(250 * time.Millisecond)
+	if _, err := a.Intercept(ctx, nil, testInfo, okHandler); err != nil {
+		t.Fatalf("request after refill rejected: %v", err)
+	}
+
+	t.Run("buckets are per identity", func(t *testing.T) {
+		bob := grpcserver.WithTokenClaims(context.Background(), &grpcserver.TokenClaims{Subject: "bob"})
+		if _, err := a.Intercept(bob, nil, testInfo, okHandler); err != nil {
+			t.Fatalf("other identity rejected: %v", err)
+		}
+	})
+
+	t.Run("override disables the limit", func(t *testing.T) {
+		ci := grpcserver.WithTokenClaims(context.Background(), &grpcserver.TokenClaims{Subject: "ci"})
+		for i := 0; i < 10; i++ {
+			if _, err := a.Intercept(ci, nil, testInfo, okHandler); err != nil {
+				t.Fatalf("request %d of unlimited identity rejected: %v", i, err)
+			}
+		}
+	})
+
+	t.Run("exempt methods", func(t *testing.T) {
+		a.cfg.ExemptMethods = []string{"/grpc.health.v1.Health/Check"}
+		info := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+		for i := 0; i < 5; i++ {
+			if _, err := a.Intercept(ctx, nil, info, okHandler); err != nil {
+				t.Fatalf("exempt request rejected: %v", err)
+			}
+		}
+	})
+}
+
+func TestAdmissionInFlightBudget(t *testing.T) {
+	a, _ := newTestAdmission(AdmissionConfig{MaxInFlightBits: 10000, DefaultBits: 4000})
+	ctx := context.Background()
+
+	// The first request holds 8000 bits while the nested calls run in its handler.
+	holding := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if a.inFlightBits() != 8000 {
+			t.Errorf("in flight %d bits, want 8000", a.inFlightBits())
+		}
+
+		_, err := a.Intercept(ctx, &pb.Sp80022TestRequest{Bitstream: make([]byte, 500)}, testInfo, okHandler)
+		if d := retryDelay(t, err); d != capacityRetryDelay {
+			t.Errorf("retry delay %s, want %s", d, capacityRetryDelay)
+		}
+		// A request without a bit count weighs DefaultBits.
+		_, err = a.Intercept(ctx, &pb.GenerateAndTestRequest{}, testInfo, okHandler)
+		retryDelay(t, err)
+
+		if _, err := a.Intercept(ctx, &pb.GenerateAndTestRequest{Bits: 2000}, testInfo, okHandler); err != nil {
+			t.Errorf("request within the remaining budget rejected: %v", err)
+		}
+		return "ok", nil
+	}
+	if _, err := a.Intercept(ctx, &pb.Sp80022TestRequest{Bitstream: make([]byte, 1000)}, testInfo, holding); err != nil {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	if a.inFlightBits() != 0 {
+		t.Fatalf("in flight %d bits after completion, want 0", a.inFlightBits())
+	}
+
+	t.Run("oversized request runs alone", func(t *testing.T) {
+		nested := func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, err := a.Intercept(ctx, &pb.GenerateAndTestRequest{Bits: 8}, testInfo, okHandler)
+			retryDelay(t, err)
+			return "ok", nil
+		}
+		if _, err := a.Intercept(ctx, &pb.GenerateAndTestRequest{Bits: 1 << 30}, testInfo, nested); err != nil {
+			t.Fatalf("oversized request rejected on an idle server: %v", err)
+		}
+		if a.inFlightBits() != 0 {
+			t.Fatalf("in flight %d bits after completion, want 0", a.inFlightBits())
+		}
+	})
+}
+
+func TestAdmissionWeight(t *testing.T) {
+	cfg := AdmissionConfig{DefaultBits: 1000, DatasetBits: 1000000, DefaultDatasets: 4}
+	tests := []struct {
+		name string
+		req  interface{}
+		want int64
+	}{
+		{"bitstream", &pb.Sp80022TestRequest{Bitstream: make([]byte, 100)}, 800},
+		{"bit count", &pb.GenerateAndTestRequest{Bits: 5000}, 5000},
+		{"default bit count", &pb.GenerateAndTestRequest{}, 1000},
+		{"self-test datasets", &pb.SelfTestRequest{Datasets: []string{"pi", "e"}}, 2000000},
+		{"self-test all datasets", &pb.SelfTestRequest{}, 4000000},
+		{"other request", &pb.GetSourceStatusRequest{}, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weight(tt.req, cfg); got != tt.want {
+				t.Errorf("weight = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdmissionWeightExtraBits(t *testing.T) {
+	cfg := AdmissionConfig{
+		DefaultBits: 1000,
+		ExtraBits: func(req interface{}) int64 {
+			if r, ok := req.(*pb.Sp80022TestRequest); ok && r.GetConfig().GetPrecisePValues() {
+				return 5000
+			}
+			return 0
+		},
+	}
+	precise := &pb.Sp80022TestRequest{Bitstream: make([]byte, 100), Config: &pb.Sp80022TestConfig{PrecisePValues: true}}
+	if got := weight(precise, cfg); got != 5800 {
+		t.Errorf("weight = %d, want 5800", got)
+	}
+	if got := weight(&pb.Sp80022TestRequest{Bitstream: make([]byte, 100)}, cfg); got != 800 {
+		t.Errorf("weight = %d, want 800", got)
+	}
+}
+
+func TestAdmissionReconfigure(t *testing.T) {
+	a, _ := newTestAdmission(AdmissionConfig{MaxInFlightBits: 10000})
+	ctx := grpcserver.WithTokenClaims(context.Background(), &grpcserver.TokenClaims{Subject: "alice"})
+
+	// A request admitted under the old budget keeps its reservation when the budget shrinks.
+	holding := func(ctx context.Context, req interface{}) (interface{}, error) {
+		a.Reconfigure(AdmissionConfig{MaxInFlightBits: 4000})
+		_, err := a.Intercept(ctx, &pb.GenerateAndTestRequest{Bits: 2000}, testInfo, okHandler)
+		retryDelay(t, err)
+		return "ok", nil
+	}
+	if _, err := a.Intercept(ctx, &pb.Sp80022TestRequest{Bitstream: make([]byte, 1000)}, testInfo, holding); err != nil {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	if a.inFlightBits() != 0 {
+		t.Fatalf("in flight %d bits after completion, want 0", a.inFlightBits())
+	}
+
+	a.Reconfigure(AdmissionConfig{RateLimit: RateLimit{Rate: 1, Burst: 1}})
+	if _, err := a.Intercept(ctx, nil, testInfo, okHandler); err != nil {
+		t.Fatalf("request within the new burst rejected: %v", err)
+	}
+	_, err := a.Intercept(ctx, nil, testInfo, okHandler)
+	retryDelay(t, err)
+}
+
+func TestAdmissionDropsFullBuckets(t *testing.T) {
+	a, advance := newTestAdmission(AdmissionConfig{RateLimit: RateLimit{Rate: 1, Burst: 1}})
+	for i := 0; i < maxIdleBuckets; i++ {
+		a.allow(fmt.Sprintf("client-%d", i))
+	}
+	if len(a.buckets) != maxIdleBuckets {
+		t.Fatalf("got %d buckets, want %d", len(a.buckets), maxIdleBuckets)
+	}
+
+	advance(time.Second)
+	if _, ok := a.allow("new"); !ok {
+		t.Fatal("new identity rejected")
+	}
+	if len(a.buckets) != 1 {
+		t.Fatalf("got %d buckets after refill, want only the new one", len(a.buckets))
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.7"), Port: 4711}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "lab-client"}}
+	tlsInfo := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"no peer", context.Background(), "unknown"},
+		{"peer address", peer.NewContext(context.Background(), &peer.Peer{Addr: addr}), "192.0.2.7"},
+		{"client certificate", peer.NewContext(context.Background(), &peer.Peer{Addr: addr, AuthInfo: tlsInfo}), "lab-client"},
+		{"token subject", grpcserver.WithTokenClaims(
+			peer.NewContext(context.Background(), &peer.Peer{Addr: addr, AuthInfo: tlsInfo}),
+			&grpcserver.TokenClaims{Subject: "svc-account"},
+		), "svc-account"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Identity(tt.ctx); got != tt.want {
+				t.Errorf("Identity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+
+	"github.com/AmmannChristian/go-authx/grpcserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AnyMethod is the key of the scopes required by methods without their own entry.
+const AnyMethod = "*"
+
+// DenialRecorder records a call rejected for missing scopes, e.g. in the audit log. err
+// is the PermissionDenied status returned to the caller.
+type DenialRecorder func(ctx context.Context, method string, err error)
+
+// UnaryAuthorizationInterceptor rejects calls with PermissionDenied unless the validated
+// token holds one of the scopes listed for the method in scopes, which maps full method
+// names, or AnyMethod, to the accepted scopes. Methods without an entry are open to every
+// authenticated caller. Every decision on a restricted method is written to the log as an
+// audit entry, and denials are passed to recordDenial if it is not nil. It must run after
+// the authentication interceptor.
+func UnaryAuthorizationInterceptor(scopes map[string][]string, recordDenial DenialRecorder, exemptMethods ...string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if slices.Contains(exemptMethods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		required, ok := scopes[info.FullMethod]
+		if !ok {
+			required = scopes[AnyMethod]
+		}
+		if len(required) == 0 {
+			return handler(ctx, req)
+		}
+
+		var subject string
+		var granted []string
+		if claims, ok := grpcserver.TokenClaimsFromContext(ctx); ok {
+			subject = claims.Subject
+			granted = claims.Scopes
+		}
+		allowed := slices.ContainsFunc(required, func(scope string) bool {
+			return slices.Contains(granted, scope)
+		})
+
+		logger := Logger(ctx)
+		event := logger.Info()
+		decision := "allow"
+		if !allowed {
+			event = logger.Warn()
+			decision = "deny"
+		}
+		event.
+			Str("audit", "authorization").
+			Str("decision", decision).
+			Str("subject", subject).
+			Str("method", info.FullMethod).
+			Strs("required_scopes", required).
+			Strs("scopes", granted).
+			Msg("Authorization decision")
+
+		if !allowed {
+			err := status.Errorf(codes.PermissionDenied, "%s requires one of the scopes %q", info.FullMethod, required)
+			if recordDenial != nil {
+				recordDenial(ctx, info.FullMethod, err)
+			}
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AmmannChristian/go-authx/grpcserver"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryAuthorizationInterceptor(t *testing.T) {
+	const (
+		sourceMethod = "/nist.sp800_22.v1.Sp80022TestService/TestSource"
+		suiteMethod  = "/nist.sp800_22.v1.Sp80022TestService/RunTestSuite"
+		healthMethod = "/grpc.health.v1.Health/Check"
+	)
+
+	var logs bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&logs)
+	defer func() { log.Logger = previous }()
+
+	withScopes := func(scopes ...string) context.Context {
+		ctx := log.Logger.With().Str("request_id", "req-1").Logger().WithContext(context.Background())
+		return grpcserver.WithTokenClaims(ctx, &grpcserver.TokenClaims{Subject: "alice", Scopes: scopes})
+	}
+
+	tests := []struct {
+		name   string
+		scopes map[string][]string
+		ctx    context.Context
+		method string
+		want   codes.Code
+		audit  string
+	}{
+		{"no rules", nil, withScopes(), suiteMethod, codes.OK, ""},
+		{"method scope held", map[string][]string{sourceMethod: {"nist:admin"}}, withScopes("nist:read", "nist:admin"), sourceMethod, codes.OK, `"decision":"allow"`},
+		{"any of the scopes", map[string][]string{sourceMethod: {"nist:admin", "nist:operator"}}, withScopes("nist:operator"), sourceMethod, codes.OK, `"decision":"allow"`},
+		{"method scope missing", map[string][]string{sourceMethod: {"nist:admin"}}, withScopes("nist:read"), sourceMethod, codes.PermissionDenied, `"decision":"deny"`},
+		{"no token", map[string][]string{sourceMethod: {"nist:admin"}}, context.Background(), sourceMethod, codes.PermissionDenied, `"decision":"deny"`},
+		{"unrestricted method", map[string][]string{sourceMethod: {"nist:admin"}}, withScopes(), suiteMethod, codes.OK, ""},
+		{"wildcard", map[string][]string{AnyMethod: {"nist:read"}}, withScopes(), suiteMethod, codes.PermissionDenied, `"decision":"deny"`},
+		{"method entry overrides wildcard", map[string][]string{AnyMethod: {"nist:read"}, sourceMethod: {"nist:admin"}}, withScopes("nist:admin"), sourceMethod, codes.OK, `"decision":"allow"`},
+		{"exempt method", map[string][]string{AnyMethod: {"nist:read"}}, context.Background(), healthMethod, codes.OK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			var denied []string
+			record := func(_ context.Context, method string, err error) {
+				if status.Code(err) != codes.PermissionDenied {
+					t.Errorf("recorded denial with %v", err)
+				}
+				denied = append(denied, method)
+			}
+			interceptor := UnaryAuthorizationInterceptor(tt.scopes, record, healthMethod)
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return "ok", nil
+			}
+
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("got code %s, want %s (err: %v)", got, tt.want, err)
+			}
+			if called != (tt.want == codes.OK) {
+				t.Fatalf("handler called = %v", called)
+			}
+			if (len(denied) == 1) != (tt.want == codes.PermissionDenied) || len(denied) > 1 {
+				t.Fatalf("recorded denials %v", denied)
+			}
+			if len(denied) == 1 && denied[0] != tt.method {
+				t.Errorf("denial recorded for %q, want method %s", denied[0], tt.method)
+			}
+
+			if tt.audit == "" {
+				if logs.Len() != 0 {
+					t.Errorf("unexpected audit entry: %s", logs.String())
+				}
+				return
+			}
+			entry := logs.String()
+			for _, s := range []string{`"audit":"authorization"`, tt.audit, `"method":"` + tt.method + `"`} {
+				if !strings.Contains(entry, s) {
+					t.Errorf("audit entry misses %s: %s", s, entry)
+				}
+			}
+			if _, ok := grpcserver.TokenClaimsFromContext(tt.ctx); ok {
+				if !strings.Contains(entry, `"request_id":"req-1"`) || !strings.Contains(entry, `"subject":"alice"`) {
+					t.Errorf("audit entry misses request ID or subject: %s", entry)
+				}
+			}
+		})
+	}
+}
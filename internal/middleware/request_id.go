@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
@@ -12,7 +14,15 @@ type contextKey string
 
 const RequestIDKey contextKey = "request_id"
 
-// UnaryRequestIDInterceptor adds a unique request ID to each gRPC request
+// RequestIDHeader is the metadata key carrying the request ID in both directions.
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLength bounds client-supplied request IDs, which end up in every log line.
+const maxRequestIDLength = 128
+
+// UnaryRequestIDInterceptor adds a request ID to each gRPC request: the one the client sent
+// in x-request-id if it is valid, otherwise a new UUID. The ID is returned to the client in
+// the x-request-id response header and trailer.
 func UnaryRequestIDInterceptor() grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -20,23 +30,66 @@ func UnaryRequestIDInterceptor() grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		// Generate unique request ID
-		requestID := uuid.New().String()
+		requestID := incomingRequestID(ctx)
+		if requestID == "" {
+			// Generate unique request ID
+			requestID = uuid.New().String()
+		}
 
-		// Add to context for internal use
-		ctx = context.WithValue(ctx, RequestIDKey, requestID)
+		// Add to context for internal use, with a logger that tags every line with it
+		ctx = WithRequestID(ctx, requestID)
 
-		// Add to outgoing metadata for clients to receive
-		md := metadata.Pairs("x-request-id", requestID)
+		// Add to outgoing metadata for clients to receive, also in the trailer so that
+		// clients which only inspect the final status can correlate failed calls
+		md := metadata.Pairs(RequestIDHeader, requestID)
 		if err := grpc.SetHeader(ctx, md); err != nil {
 			// Continue even if header setting fails
 			// This is not critical for request processing
 		}
+		_ = grpc.SetTrailer(ctx, md)
 
 		return handler(ctx, req)
 	}
 }
 
+// incomingRequestID returns the request ID sent by the client, or "" if none was sent or
+// it is not a short string of printable ASCII characters.
+func incomingRequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(RequestIDHeader)
+	if len(values) == 0 {
+		return ""
+	}
+	id := values[0]
+	if len(id) > maxRequestIDLength {
+		return ""
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return ""
+		}
+	}
+	return id
+}
+
+// WithRequestID returns a context carrying requestID and a logger that adds it to every line
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, RequestIDKey, requestID)
+	return log.Logger.With().Str("request_id", requestID).Logger().WithContext(ctx)
+}
+
+// Logger returns the request logger of the context, which adds the request ID to every
+// line, or the global logger outside of a request
+func Logger(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return &log.Logger
+}
+
 // GetRequestID retrieves the request ID from the context
 func GetRequestID(ctx context.Context) string {
 	if id, ok := ctx.Value(RequestIDKey).(string); ok {
@@ -1,10 +1,15 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
 
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestUnaryRequestIDInterceptor(t *testing.T) {
@@ -57,3 +62,89 @@ func TestGetRequestID_WithID(t *testing.T) {
 		t.Errorf("expected %s, got %s", expectedID, requestID)
 	}
 }
+
+func TestLogger(t *testing.T) {
+	origLogger := log.Logger
+	defer func() { log.Logger = origLogger }()
+	var buf bytes.Buffer
+	log.Logger = zerolog.New(&buf)
+
+	if Logger(context.Background()) != &log.Logger {
+		t.Fatal("expected the global logger outside of a request")
+	}
+
+	var requestID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		requestID = GetRequestID(ctx)
+		Logger(ctx).Info().Msg("inside")
+		return nil, nil
+	}
+	if _, err := UnaryRequestIDInterceptor()(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler); err != nil {
+		t.Fatalf("interceptor returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"request_id":"`+requestID+`"`) {
+		t.Errorf("log line %q does not carry request ID %s", buf.String(), requestID)
+	}
+}
+
+// transportStream records the metadata set by a handler.
+type transportStream struct {
+	header, trailer metadata.MD
+}
+
+func (s *transportStream) Method() string { return "/test.Service/Method" }
+
+func (s *transportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *transportStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *transportStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestIncomingRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"valid", "client-req-7", true},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"control character", "req\n1", false},
+		{"space", "req 1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &transportStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(RequestIDHeader, tt.incoming))
+
+			var requestID string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				requestID = GetRequestID(ctx)
+				return nil, nil
+			}
+			if _, err := UnaryRequestIDInterceptor()(ctx, "request", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler); err != nil {
+				t.Fatalf("interceptor returned error: %v", err)
+			}
+
+			if tt.keep && requestID != tt.incoming {
+				t.Errorf("expected incoming request ID %q, got %q", tt.incoming, requestID)
+			}
+			if !tt.keep && len(requestID) != 36 {
+				t.Errorf("expected a generated UUID instead of %q, got %q", tt.incoming, requestID)
+			}
+			if got := stream.header.Get(RequestIDHeader); len(got) != 1 || got[0] != requestID {
+				t.Errorf("header %v does not echo %s", got, requestID)
+			}
+			if got := stream.trailer.Get(RequestIDHeader); len(got) != 1 || got[0] != requestID {
+				t.Errorf("trailer %v does not echo %s", got, requestID)
+			}
+		})
+	}
+}
@@ -1,52 +1,79 @@
 package nist
 
 import (
+	"fmt"
 	"math"
-
-	"gonum.org/v1/gonum/mathext"
+	"math/bits"
 )
 
+// ApproximateEntropyMaxBlockLength is the largest block length m of the Approximate
+// Entropy test; the bound on n keeps the pattern tables below n/16 entries.
+const ApproximateEntropyMaxBlockLength = 32
+
+// ApproximateEntropyResult holds the outcome of the Approximate Entropy test.
+type ApproximateEntropyResult struct {
+	PValue    float64
+	Passed    bool
+	Tail      Tail
+	ApEn      float64
+	ChiSquare float64
+}
+
 // ApproximateEntropyTest implements the NIST Approximate Entropy test.
-// It returns the p-value and whether it passes at Alpha.
+// It returns the p-value and whether it passes at Alpha; invalid parameters or too
+// short sequences yield (0, false).
 func ApproximateEntropyTest(bitstream []byte, m int) (float64, bool) {
-	bits := expandBits(bitstream)
-	n := len(bits)
-	if n == 0 || m < 1 {
+	res, err := ApproximateEntropy(bitstream, m)
+	if err != nil {
 		return 0, false
 	}
+	return res.PValue, res.Passed
+}
 
-	var apEn [2]float64
-	for r := 0; r < 2; r++ {
-		blockSize := m + r
-		numBlocks := n
-		powLen := (1 << (blockSize + 1)) - 1
-		P := make([]int, powLen)
-
-		for i := 0; i < numBlocks; i++ {
-			k := 1
-			for j := 0; j < blockSize; j++ {
-				k <<= 1
-				if bits[(i+j)%n] == 1 {
-					k++
-				}
-			}
-			P[k-1]++
+// ApproximateEntropy runs the Approximate Entropy test with block length m. It returns
+// a *ParameterError if m is outside 1-32 and an *InsufficientBitsError unless
+// m < ⌊log2 n⌋ - 5, the bound recommended by SP 800-22 Section 2.12.7, i.e. n >= 2^(m+6).
+func ApproximateEntropy(bitstream []byte, m int) (*ApproximateEntropyResult, error) {
+	if m < 1 || m > ApproximateEntropyMaxBlockLength {
+		return nil, &ParameterError{
+			Test:  "approximate_entropy",
+			Param: "block_length",
+			Value: m,
+			Min:   1,
+			Max:   ApproximateEntropyMaxBlockLength,
 		}
-
-		sum := 0.0
-		index := (1 << blockSize) - 1
-		for i := 0; i < (1 << blockSize); i++ {
-			if P[index] > 0 {
-				sum += float64(P[index]) * math.Log(float64(P[index])/float64(numBlocks))
-			}
-			index++
+	}
+	n := len(bitstream) * 8
+	if n == 0 || m >= bits.Len(uint(n))-1-5 {
+		return nil, &InsufficientBitsError{
+			Test:   "approximate_entropy",
+			Bits:   n,
+			Need:   1 << (m + 6),
+			Reason: fmt.Sprintf("m = %d must be below floor(log2 n) - 5", m),
 		}
-		apEn[r] = sum / float64(numBlocks)
 	}
 
-	apen := apEn[0] - apEn[1]
-	chiSquared := 2.0 * float64(n) * (math.Log(2) - apen)
-	pValue := mathext.GammaIncRegComp(math.Pow(2, float64(m-1)), chiSquared/2.0)
+	apEn := approximateEntropyPhi(bitstream, m) - approximateEntropyPhi(bitstream, m+1)
+	chiSquared := 2.0 * float64(n) * (math.Log(2) - apEn)
+	tail := Tail{Kind: GammaTail, A: math.Pow(2, float64(m-1)), X: chiSquared / 2.0}
+	pValue := tail.PValue()
+
+	return &ApproximateEntropyResult{
+		PValue:    pValue,
+		Passed:    pValue >= Alpha,
+		Tail:      tail,
+		ApEn:      apEn,
+		ChiSquare: chiSquared,
+	}, nil
+}
 
-	return pValue, pValue >= Alpha
+// approximateEntropyPhi returns φ^(m), the mean log frequency of the overlapping m-bit
+// patterns of the cyclic sequence.
+func approximateEntropyPhi(bitstream []byte, m int) float64 {
+	n := len(bitstream) * 8
+	sum := 0.0
+	patternCounts(bitstream, m, func(count int) {
+		sum += float64(count) * math.Log(float64(count)/float64(n))
+	})
+	return sum / float64(n)
 }
@@ -1,7 +1,11 @@
 package nist
 
 import (
+	"errors"
+	"math"
 	"testing"
+
+	"gonum.org/v1/gonum/mathext"
 )
 
 func TestApproximateEntropy(t *testing.T) {
@@ -30,3 +34,74 @@ func TestApproximateEntropy(t *testing.T) {
 		}
 	})
 }
+
+// approximateEntropyTestBitwise is the original implementation with modular indexing
+// and a table of 2^(m+1) counts, kept as the reference for the rolling window.
+func approximateEntropyTestBitwise(bitstream []byte, m int) (float64, bool) {
+	bits := expandBits(bitstream)
+	n := len(bits)
+
+	var apEn [2]float64
+	for r := 0; r < 2; r++ {
+		blockSize := m + r
+		P := make([]int, (1<<(blockSize+1))-1)
+		for i := 0; i < n; i++ {
+			k := 1
+			for j := 0; j < blockSize; j++ {
+				k <<= 1
+				if bits[(i+j)%n] == 1 {
+					k++
+				}
+			}
+			P[k-1]++
+		}
+
+		sum := 0.0
+		index := (1 << blockSize) - 1
+		for i := 0; i < (1 << blockSize); i++ {
+			if P[index] > 0 {
+				sum += float64(P[index]) * math.Log(float64(P[index])/float64(n))
+			}
+			index++
+		}
+		apEn[r] = sum / float64(n)
+	}
+
+	apen := apEn[0] - apEn[1]
+	chiSquared := 2.0 * float64(n) * (math.Log(2) - apen)
+	pValue := mathext.GammaIncRegComp(math.Pow(2, float64(m-1)), chiSquared/2.0)
+	return pValue, pValue >= Alpha
+}
+
+func TestApproximateEntropyMatchesBitwise(t *testing.T) {
+	data := pseudoRandomBytes(125000, 31)
+	for _, m := range []int{1, 2, 5, 10, 13} {
+		got, gotPass := ApproximateEntropyTest(data, m)
+		want, wantPass := approximateEntropyTestBitwise(data, m)
+		if got != want || gotPass != wantPass {
+			t.Fatalf("m=%d: got p=%v, bitwise p=%v", m, got, want)
+		}
+	}
+}
+
+func TestApproximateEntropyBlockLengthBound(t *testing.T) {
+	data := pseudoRandomBytes(125000, 32) // 1,000,000 bits: floor(log2 n) = 19
+
+	if _, err := ApproximateEntropy(data, 13); err != nil {
+		t.Fatalf("m=13 rejected: %v", err)
+	}
+	var short *InsufficientBitsError
+	if _, err := ApproximateEntropy(data, 14); !errors.As(err, &short) {
+		t.Fatalf("expected *InsufficientBitsError for m=14, got %v", err)
+	}
+	if short.Need != 1<<20 {
+		t.Errorf("need %d bits, want %d", short.Need, 1<<20)
+	}
+
+	var perr *ParameterError
+	for _, m := range []int{0, 33} {
+		if _, err := ApproximateEntropy(data, m); !errors.As(err, &perr) {
+			t.Errorf("m=%d: expected *ParameterError, got %v", m, err)
+		}
+	}
+}
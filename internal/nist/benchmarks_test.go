@@ -2,6 +2,8 @@ package nist
 
 import (
 	"crypto/rand"
+	"fmt"
+	"runtime"
 	"testing"
 )
 
@@ -65,6 +67,42 @@ func BenchmarkBinaryMatrixRankTest(b *testing.B) {
 	}
 }
 
+// BenchmarkBinaryMatrixRankElimination compares per-bit and packed uint64 elimination
+// of the 32x32 matrices of 1M bits
+func BenchmarkBinaryMatrixRankElimination(b *testing.B) {
+	bits := make([]byte, 125000) // 1M bits
+	rand.Read(bits)
+
+	b.Run("bitwise", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			binaryMatrixRankTestBitwise(bits)
+		}
+	})
+	b.Run("packed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BinaryMatrixRankTest(bits)
+		}
+	})
+}
+
+// BenchmarkSerialPatternCounting compares modular per-bit indexing with the rolling
+// window for m = 16 on 1M bits
+func BenchmarkSerialPatternCounting(b *testing.B) {
+	bits := make([]byte, 125000) // 1M bits
+	rand.Read(bits)
+
+	b.Run("bitwise", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			serialPValuesBitwise(bits, 16)
+		}
+	})
+	b.Run("rolling", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			SerialPValues(bits, 16)
+		}
+	})
+}
+
 // BenchmarkDiscreteFourierTransformTest benchmarks the DFT test
 func BenchmarkDiscreteFourierTransformTest(b *testing.B) {
 	bits := make([]byte, 125000) // 1M bits
@@ -75,6 +113,21 @@ func BenchmarkDiscreteFourierTransformTest(b *testing.B) {
 	}
 }
 
+// BenchmarkDiscreteFourierTransformPrimeLength benchmarks the DFT test on byte counts
+// that are prime, so that the transform has a large prime factor
+func BenchmarkDiscreteFourierTransformPrimeLength(b *testing.B) {
+	for _, nBytes := range []int{48017, 125003} {
+		b.Run(fmt.Sprintf("%d_bytes", nBytes), func(b *testing.B) {
+			bits := make([]byte, nBytes)
+			rand.Read(bits)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				DiscreteFourierTransformTest(bits)
+			}
+		})
+	}
+}
+
 // BenchmarkNonOverlappingTemplateTest benchmarks the Non-overlapping Template test
 func BenchmarkNonOverlappingTemplateTest(b *testing.B) {
 	bits := make([]byte, 125000) // 1M bits
@@ -155,6 +208,50 @@ func BenchmarkLinearComplexityTest(b *testing.B) {
 	}
 }
 
+// BenchmarkLinearComplexityBlocks compares the bitwise and packed Berlekamp-Massey
+// implementations on a single block
+func BenchmarkLinearComplexityBlocks(b *testing.B) {
+	for _, M := range []int{500, 1000, 5000} {
+		data := make([]byte, M/8+1)
+		rand.Read(data)
+		blockBits := expandBits(data)[:M]
+
+		b.Run(fmt.Sprintf("bitwise_M%d", M), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				linearComplexityBitwise(blockBits)
+			}
+		})
+		b.Run(fmt.Sprintf("packed_M%d", M), func(b *testing.B) {
+			s := newBMScratch(M)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.linearComplexity(data, 0)
+			}
+		})
+	}
+}
+
+// BenchmarkLinearComplexityWorkers compares serial and parallel block processing
+func BenchmarkLinearComplexityWorkers(b *testing.B) {
+	bits := make([]byte, 125000) // 1M bits
+	rand.Read(bits)
+
+	procs := []int{1}
+	if n := runtime.NumCPU(); n > 1 {
+		procs = append(procs, n)
+	}
+	for _, procs := range procs {
+		b.Run(fmt.Sprintf("procs_%d", procs), func(b *testing.B) {
+			prev := runtime.GOMAXPROCS(procs)
+			defer runtime.GOMAXPROCS(prev)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				LinearComplexityTest(bits, 500)
+			}
+		})
+	}
+}
+
 // BenchmarkRunAllTests benchmarks the complete NIST test suite
 func BenchmarkRunAllTests(b *testing.B) {
 	bits := make([]byte, 125000) // 1M bits
@@ -1,146 +1,123 @@
 package nist
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	// BinaryMatrixRankMinDim and BinaryMatrixRankMaxDim bound the matrix dimensions M and Q.
+	// Rows are held in a single uint64 word.
+	BinaryMatrixRankMinDim = 2
+	BinaryMatrixRankMaxDim = 64
+	// BinaryMatrixRankMinMatrices is the minimum number of matrices N, i.e. n >= 38MQ.
+	BinaryMatrixRankMinMatrices = 38
+)
 
 // BinaryMatrixRankTest implements the NIST Binary Matrix Rank test (32x32).
 // It returns the p-value and whether it passes at Alpha.
 func BinaryMatrixRankTest(bitstream []byte) (float64, bool) {
-	bits := expandBits(bitstream)
-	n := len(bits)
+	return BinaryMatrixRankTestMQ(bitstream, 32, 32)
+}
 
-	const (
-		m = 32
-		q = 32
-	)
+// BinaryMatrixRankTestMQ implements the NIST Binary Matrix Rank test for M x Q
+// matrices, with the rank probabilities computed for those dimensions. It returns
+// (0, false) for dimensions outside 2-64 or fewer bits than one matrix.
+func BinaryMatrixRankTestMQ(bitstream []byte, M, Q int) (float64, bool) {
+	return minPValue(binaryMatrixRankTails(bitstream, M, Q))
+}
 
-	N := n / (m * q)
+// checkBinaryMatrixRank validates the number of M x Q matrices in n bits.
+func checkBinaryMatrixRank(n, M, Q int) error {
+	if n/(M*Q) < BinaryMatrixRankMinMatrices {
+		return &InsufficientBitsError{
+			Test:   "binary_matrix_rank",
+			Bits:   n,
+			Need:   BinaryMatrixRankMinMatrices * M * Q,
+			Reason: fmt.Sprintf("%d matrices of %dx%d bits", BinaryMatrixRankMinMatrices, M, Q),
+		}
+	}
+	return nil
+}
+
+func binaryMatrixRankTails(bitstream []byte, M, Q int) []Tail {
+	if M < BinaryMatrixRankMinDim || M > BinaryMatrixRankMaxDim || Q < BinaryMatrixRankMinDim || Q > BinaryMatrixRankMaxDim {
+		return nil
+	}
+
+	n := len(bitstream) * 8
+	N := n / (M * Q)
 	if N == 0 {
-		return 0, false
+		return nil
 	}
 
-	p32 := binaryRankProbability(32)
-	p31 := binaryRankProbability(31)
-	p30 := 1 - (p32 + p31)
+	full := min(M, Q)
+	pFull := binaryRankProbability(full, M, Q)
+	pFull1 := binaryRankProbability(full-1, M, Q)
+	pRest := 1 - (pFull + pFull1)
 
-	var f32, f31 float64
+	rows := make([]uint64, M)
+	var fFull, fFull1 float64
 	for k := 0; k < N; k++ {
-		matrix := make([][]uint8, m)
-		for i := range matrix {
-			matrix[i] = make([]uint8, q)
-			for j := 0; j < q; j++ {
-				matrix[i][j] = bits[k*(m*q)+i*q+j]
-			}
+		for i := range rows {
+			rows[i] = readBits(bitstream, k*M*Q+i*Q, Q)
 		}
 
-		rank := computeRank(matrix)
-		if rank == 32 {
-			f32++
-		} else if rank == 31 {
-			f31++
+		switch rankGF2(rows) {
+		case full:
+			fFull++
+		case full - 1:
+			fFull1++
 		}
 	}
-	f30 := float64(N) - (f32 + f31)
+	fRest := float64(N) - (fFull + fFull1)
 
-	chiSquared := math.Pow(f32-float64(N)*p32, 2)/(float64(N)*p32) +
-		math.Pow(f31-float64(N)*p31, 2)/(float64(N)*p31) +
-		math.Pow(f30-float64(N)*p30, 2)/(float64(N)*p30)
+	chiSquared := math.Pow(fFull-float64(N)*pFull, 2)/(float64(N)*pFull) +
+		math.Pow(fFull1-float64(N)*pFull1, 2)/(float64(N)*pFull1) +
+		math.Pow(fRest-float64(N)*pRest, 2)/(float64(N)*pRest)
 
-	pValue := math.Exp(-chiSquared / 2.0)
-
-	return pValue, pValue >= Alpha
+	return []Tail{{Kind: ExpTail, X: chiSquared / 2.0}}
 }
 
-func binaryRankProbability(r int) float64 {
+// binaryRankProbability returns the probability that a random M x Q matrix over GF(2)
+// has rank r:
+//
+//	2^(r(Q+M-r) - MQ) * prod_{i=0}^{r-1} (1 - 2^(i-Q)) (1 - 2^(i-M)) / (1 - 2^(i-r))
+func binaryRankProbability(r, M, Q int) float64 {
 	product := 1.0
 	for i := 0; i <= r-1; i++ {
-		num := (1.0 - math.Pow(2, float64(i-32))) * (1.0 - math.Pow(2, float64(i-32)))
+		num := (1.0 - math.Pow(2, float64(i-Q))) * (1.0 - math.Pow(2, float64(i-M)))
 		den := 1.0 - math.Pow(2, float64(i-r))
 		product *= num / den
 	}
-	return math.Pow(2, float64(r*(32+32-r)-32*32)) * product
-}
-
-func computeRank(matrix [][]uint8) int {
-	M := len(matrix)
-	Q := len(matrix[0])
-	m := M
-	if Q < m {
-		m = Q
-	}
-
-	for i := 0; i < m-1; i++ {
-		if matrix[i][i] == 1 {
-			performRowOps(matrix, i, true)
-		} else if findUnitAndSwap(matrix, i, true) {
-			performRowOps(matrix, i, true)
-		}
-	}
-
-	for i := m - 1; i > 0; i-- {
-		if matrix[i][i] == 1 {
-			performRowOps(matrix, i, false)
-		} else if findUnitAndSwap(matrix, i, false) {
-			performRowOps(matrix, i, false)
-		}
-	}
-
-	rank := m
-	for i := 0; i < M; i++ {
-		allZero := true
-		for j := 0; j < Q; j++ {
-			if matrix[i][j] == 1 {
-				allZero = false
-				break
-			}
-		}
-		if allZero {
-			rank--
-		}
-	}
-
-	return rank
+	return math.Pow(2, float64(r*(Q+M-r)-M*Q)) * product
 }
 
-func performRowOps(matrix [][]uint8, i int, forward bool) {
-	M := len(matrix)
-	Q := len(matrix[0])
-	if forward {
-		for j := i + 1; j < M; j++ {
-			if matrix[j][i] == 1 {
-				for k := i; k < Q; k++ {
-					matrix[j][k] ^= matrix[i][k]
-				}
+// rankGF2 returns the rank over GF(2) of the matrix whose rows are the given bit
+// words. The rows are modified.
+func rankGF2(rows []uint64) int {
+	rank := 0
+	for rank < len(rows) {
+		// Pick the remaining row with the highest leading bit as pivot.
+		pivot := rank
+		for i := rank + 1; i < len(rows); i++ {
+			if rows[i] > rows[pivot] {
+				pivot = i
 			}
 		}
-	} else {
-		for j := i - 1; j >= 0; j-- {
-			if matrix[j][i] == 1 {
-				for k := 0; k < Q; k++ {
-					matrix[j][k] ^= matrix[i][k]
-				}
-			}
+		if rows[pivot] == 0 {
+			break
 		}
-	}
-}
-
-func findUnitAndSwap(matrix [][]uint8, i int, forward bool) bool {
-	M := len(matrix)
+		rows[rank], rows[pivot] = rows[pivot], rows[rank]
 
-	if forward {
-		for idx := i + 1; idx < M; idx++ {
-			if matrix[idx][i] == 1 {
-				matrix[i], matrix[idx] = matrix[idx], matrix[i]
-				return true
-			}
-		}
-	} else {
-		for idx := i - 1; idx >= 0; idx-- {
-			if matrix[idx][i] == 1 {
-				matrix[i], matrix[idx] = matrix[idx], matrix[i]
-				return true
+		lead := uint64(1) << (63 - bits.LeadingZeros64(rows[rank]))
+		for i := rank + 1; i < len(rows); i++ {
+			if rows[i]&lead != 0 {
+				rows[i] ^= rows[rank]
 			}
 		}
+		rank++
 	}
-
-	return false
+	return rank
 }
@@ -1,6 +1,7 @@
 package nist
 
 import (
+	"math"
 	"testing"
 )
 
@@ -24,3 +25,224 @@ func TestBinaryMatrixRank(t *testing.T) {
 		}
 	})
 }
+
+// binaryMatrixRankTestBitwise is the original 32x32 implementation on per-bit arrays,
+// kept as the reference for the packed elimination.
+func binaryMatrixRankTestBitwise(bitstream []byte) (float64, bool) {
+	bits := expandBits(bitstream)
+	N := len(bits) / 1024
+
+	p32 := binaryRankProbability(32, 32, 32)
+	p31 := binaryRankProbability(31, 32, 32)
+	p30 := 1 - (p32 + p31)
+
+	var f32, f31 float64
+	for k := 0; k < N; k++ {
+		matrix := make([][]uint8, 32)
+		for i := range matrix {
+			matrix[i] = append([]uint8(nil), bits[k*1024+i*32:k*1024+(i+1)*32]...)
+		}
+
+		rank := computeRankBitwise(matrix)
+		if rank == 32 {
+			f32++
+		} else if rank == 31 {
+			f31++
+		}
+	}
+	f30 := float64(N) - (f32 + f31)
+
+	chiSquared := math.Pow(f32-float64(N)*p32, 2)/(float64(N)*p32) +
+		math.Pow(f31-float64(N)*p31, 2)/(float64(N)*p31) +
+		math.Pow(f30-float64(N)*p30, 2)/(float64(N)*p30)
+
+	pValue := math.Exp(-chiSquared / 2.0)
+	return pValue, pValue >= Alpha
+}
+
+func computeRankBitwise(matrix [][]uint8) int {
+	M := len(matrix)
+	Q := len(matrix[0])
+	m := M
+	if Q < m {
+		m = Q
+	}
+
+	for i := 0; i < m-1; i++ {
+		if matrix[i][i] == 1 {
+			performRowOps(matrix, i, true)
+		} else if findUnitAndSwap(matrix, i, true) {
+			performRowOps(matrix, i, true)
+		}
+	}
+
+	for i := m - 1; i > 0; i-- {
+		if matrix[i][i] == 1 {
+			performRowOps(matrix, i, false)
+		} else if findUnitAndSwap(matrix, i, false) {
+			performRowOps(matrix, i, false)
+		}
+	}
+
+	rank := m
+	for i := 0; i < M; i++ {
+		allZero := true
+		for j := 0; j < Q; j++ {
+			if matrix[i][j] == 1 {
+				allZero = false
+				break
+			}
+		}
+		if allZero {
+			rank--
+		}
+	}
+
+	return rank
+}
+
+func performRowOps(matrix [][]uint8, i int, forward bool) {
+	M := len(matrix)
+	Q := len(matrix[0])
+	if forward {
+		for j := i + 1; j < M; j++ {
+			if matrix[j][i] == 1 {
+				for k := i; k < Q; k++ {
+					matrix[j][k] ^= matrix[i][k]
+				}
+			}
+		}
+	} else {
+		for j := i - 1; j >= 0; j-- {
+			if matrix[j][i] == 1 {
+				for k := 0; k < Q; k++ {
+					matrix[j][k] ^= matrix[i][k]
+				}
+			}
+		}
+	}
+}
+
+func findUnitAndSwap(matrix [][]uint8, i int, forward bool) bool {
+	M := len(matrix)
+
+	if forward {
+		for idx := i + 1; idx < M; idx++ {
+			if matrix[idx][i] == 1 {
+				matrix[i], matrix[idx] = matrix[idx], matrix[i]
+				return true
+			}
+		}
+	} else {
+		for idx := i - 1; idx >= 0; idx-- {
+			if matrix[idx][i] == 1 {
+				matrix[i], matrix[idx] = matrix[idx], matrix[i]
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func TestRankGF2MatchesBitwise(t *testing.T) {
+	// The reference only pivots on the diagonal and is exact for square matrices only;
+	// TestBinaryRankProbability covers rectangular ones by enumeration.
+	dims := [][2]int{{2, 2}, {3, 3}, {6, 6}, {17, 17}, {32, 32}, {64, 64}}
+	for _, d := range dims {
+		M, Q := d[0], d[1]
+		data := pseudoRandomBytes(M*Q*20/8+8, uint64(M*100+Q))
+		for k := 0; k < 20; k++ {
+			rows := make([]uint64, M)
+			matrix := make([][]uint8, M)
+			for i := range rows {
+				rows[i] = readBits(data, k*M*Q+i*Q, Q)
+				matrix[i] = make([]uint8, Q)
+				for j := range matrix[i] {
+					matrix[i][j] = bitAt(data, k*M*Q+i*Q+j)
+				}
+			}
+			// Make some matrices rank deficient.
+			if k%3 == 0 {
+				rows[M-1] = rows[0] ^ rows[1]
+				for j := range matrix[M-1] {
+					matrix[M-1][j] = matrix[0][j] ^ matrix[1][j]
+				}
+			}
+
+			if got, want := rankGF2(rows), computeRankBitwise(matrix); got != want {
+				t.Fatalf("%dx%d matrix %d: rank %d, want %d", M, Q, k, got, want)
+			}
+		}
+	}
+}
+
+func TestBinaryRankProbability(t *testing.T) {
+	// Enumerate all matrices of small dimensions.
+	for _, d := range [][2]int{{2, 2}, {3, 3}, {2, 5}, {4, 3}} {
+		M, Q := d[0], d[1]
+		counts := make([]float64, min(M, Q)+1)
+		rows := make([]uint64, M)
+		for v := uint64(0); v < 1<<(M*Q); v++ {
+			for i := range rows {
+				rows[i] = v >> (i * Q) & (1<<Q - 1)
+			}
+			counts[rankGF2(rows)]++
+		}
+		for r, c := range counts {
+			want := c / float64(uint64(1)<<(M*Q))
+			if got := binaryRankProbability(r, M, Q); math.Abs(got-want) > 1e-15 {
+				t.Errorf("%dx%d rank %d: probability %v, want %v", M, Q, r, got, want)
+			}
+		}
+	}
+
+	// The 32x32 values of SP 800-22 Section 3.5.
+	if p := binaryRankProbability(32, 32, 32); math.Abs(p-0.2888) > 1e-4 {
+		t.Errorf("p32 = %v, want 0.2888", p)
+	}
+	if p := binaryRankProbability(31, 32, 32); math.Abs(p-0.5776) > 1e-4 {
+		t.Errorf("p31 = %v, want 0.5776", p)
+	}
+}
+
+func TestBinaryMatrixRankMatchesBitwise(t *testing.T) {
+	data := pseudoRandomBytes(125000, 11)
+	got, gotPass := BinaryMatrixRankTest(data)
+	want, wantPass := binaryMatrixRankTestBitwise(data)
+	if got != want || gotPass != wantPass {
+		t.Fatalf("packed p=%v, bitwise p=%v", got, want)
+	}
+}
+
+func TestBinaryMatrixRankMQ(t *testing.T) {
+	data := pseudoRandomBytes(125000, 12)
+
+	for _, d := range [][2]int{{1, 32}, {32, 65}, {0, 0}} {
+		if p, pass := BinaryMatrixRankTestMQ(data, d[0], d[1]); p != 0 || pass {
+			t.Errorf("%dx%d: expected rejection, got p=%v", d[0], d[1], p)
+		}
+	}
+
+	for _, d := range [][2]int{{8, 8}, {16, 24}, {64, 64}} {
+		p, _ := BinaryMatrixRankTestMQ(data, d[0], d[1])
+		if p <= 0 || p > 1 {
+			t.Errorf("%dx%d: p-value out of range: %v", d[0], d[1], p)
+		}
+	}
+}
+
+func TestReadBits(t *testing.T) {
+	data := []byte{0b10110010, 0b01111000, 0xff, 0x00, 0x81, 0x42, 0x24, 0x18, 0xa5, 0x5a}
+	for start := 0; start < 16; start++ {
+		for count := 0; count <= 64 && start+count <= len(data)*8; count++ {
+			var want uint64
+			for i := 0; i < count; i++ {
+				want = want<<1 | uint64(bitAt(data, start+i))
+			}
+			if got := readBits(data, start, count); got != want {
+				t.Fatalf("readBits(%d, %d) = %x, want %x", start, count, got, want)
+			}
+		}
+	}
+}
@@ -1,19 +1,21 @@
 package nist
 
-import "gonum.org/v1/gonum/mathext"
-
 // BlockFrequencyTest implements the NIST Block Frequency test.
 // blockSize is the length of each block in bits (M in the NIST documentation).
 // It returns the p-value and whether it passes at Alpha.
 func BlockFrequencyTest(bitstream []byte, blockSize int) (float64, bool) {
+	return minPValue(blockFrequencyTails(bitstream, blockSize))
+}
+
+func blockFrequencyTails(bitstream []byte, blockSize int) []Tail {
 	n := len(bitstream) * 8
 	if blockSize <= 0 || n < blockSize {
-		return 0, false
+		return nil
 	}
 
 	N := n / blockSize // number of complete blocks
 	if N == 0 {
-		return 0, false
+		return nil
 	}
 
 	var sum float64
@@ -31,7 +33,5 @@ func BlockFrequencyTest(bitstream []byte, blockSize int) (float64, bool) {
 	}
 
 	chiSquared := 4 * float64(blockSize) * sum
-	pValue := mathext.GammaIncRegComp(float64(N)/2.0, chiSquared/2.0)
-
-	return pValue, pValue >= Alpha
+	return []Tail{{Kind: GammaTail, A: float64(N) / 2.0, X: chiSquared / 2.0}}
 }
@@ -0,0 +1,367 @@
+package nist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// funcTest adapts one of the single-statistic test functions to the Test interface.
+type funcTest struct {
+	name    string
+	minBits int
+	params  []ParamSpec
+	run     func(bitstream []byte, params Params) []Tail
+	// zeroWarning is reported when the test yields no tails or p = 0 without passing,
+	// which the test functions use to signal that the input could not be evaluated.
+	zeroWarning string
+	// skipWarning, if set, reports a test that yields no tails as skipped instead,
+	// for tests that are not applicable to some inputs.
+	skipWarning string
+}
+
+func (t *funcTest) Name() string        { return t.name }
+func (t *funcTest) MinBits() int        { return t.minBits }
+func (t *funcTest) Params() []ParamSpec { return t.params }
+
+func (t *funcTest) Run(_ context.Context, bitstream []byte, params Params) (TestResult, error) {
+	tails := t.run(bitstream, params)
+	if tails == nil && t.skipWarning != "" {
+		return TestResult{Name: t.name, PValue: -1, Warning: t.skipWarning}, nil
+	}
+	p, passed := minPValue(tails)
+
+	r := TestResult{Name: t.name, PValue: p, Passed: passed, Tails: tails}
+	if p, clamped := clampPValue(p); clamped {
+		r.PValue, r.Passed = p, p >= Alpha
+		r.Warning = clampWarning
+		return r, nil
+	}
+	if p == 0 && !passed {
+		r.Warning = t.zeroWarning
+	}
+	return r, nil
+}
+
+// clampWarning is reported when a p-value left [0, 1] through rounding.
+const clampWarning = "p-value clamped to [0, 1]"
+
+// single wraps a tails function without parameters.
+func single(tails func([]byte) []Tail) func([]byte, Params) []Tail {
+	return func(b []byte, _ Params) []Tail { return tails(b) }
+}
+
+// withParam wraps a tails function taking one integer parameter.
+func withParam(tails func([]byte, int) []Tail, name string) func([]byte, Params) []Tail {
+	return func(b []byte, p Params) []Tail { return tails(b, p[name]) }
+}
+
+// cumulativeSumsTest adapts the Cumulative Sums test to the Test interface, reporting
+// the smaller of the forward and reverse p-values.
+type cumulativeSumsTest struct{}
+
+func (cumulativeSumsTest) Name() string        { return "cumulative_sums" }
+func (cumulativeSumsTest) MinBits() int        { return 100 }
+func (cumulativeSumsTest) Params() []ParamSpec { return nil }
+
+func (t cumulativeSumsTest) Run(_ context.Context, bitstream []byte, _ Params) (TestResult, error) {
+	tails := cumulativeSumsTails(bitstream)
+	p, clamped := 0.0, false
+	if tails != nil {
+		forward, cf := clampPValue(tails[0].PValue())
+		reverse, cr := clampPValue(tails[1].PValue())
+		p, clamped = min(forward, reverse), cf || cr
+	}
+
+	r := TestResult{Name: t.Name(), PValue: p, Passed: p >= Alpha, Tails: tails}
+	if clamped {
+		r.Warning = clampWarning
+	}
+	return r, nil
+}
+
+// binaryMatrixRankTest adds the minimum of 38 matrices of the chosen dimensions to the
+// Binary Matrix Rank test; shorter sequences are reported as skipped. MinBits is the
+// requirement of the smallest matrices.
+type binaryMatrixRankTest struct{ *funcTest }
+
+func (t binaryMatrixRankTest) Run(ctx context.Context, bitstream []byte, params Params) (TestResult, error) {
+	err := checkBinaryMatrixRank(len(bitstream)*8, params["rows"], params["columns"])
+	if r, ok := skippedResult(t.Name(), err); ok {
+		return r, nil
+	}
+	return t.funcTest.Run(ctx, bitstream, params)
+}
+
+// linearComplexityTest adapts LinearComplexity to the Test interface. Sequences with
+// fewer than 200 blocks of the chosen length are reported as skipped.
+type linearComplexityTest struct{}
+
+func (linearComplexityTest) Name() string { return "linear_complexity" }
+
+func (linearComplexityTest) MinBits() int {
+	return LinearComplexityMinBlocks * LinearComplexityMinBlockLength
+}
+
+func (linearComplexityTest) Params() []ParamSpec {
+	return []ParamSpec{
+		{Name: "block_length", Description: "Block length M", Default: LinearComplexityMinBlockLength, Min: LinearComplexityMinBlockLength, Max: LinearComplexityMaxBlockLength},
+		{Name: "report_histogram", Description: "Report the class counts ν_0..ν_6 (0 or 1)", Default: 0, Min: 0, Max: 1},
+	}
+}
+
+func (t linearComplexityTest) Run(_ context.Context, bitstream []byte, params Params) (TestResult, error) {
+	res, err := LinearComplexity(bitstream, params["block_length"])
+	if r, ok := skippedResult(t.Name(), err); ok {
+		return r, nil
+	}
+	if err != nil {
+		return TestResult{}, err
+	}
+
+	r := TestResult{Name: t.Name(), PValue: res.PValue, Passed: res.Passed, Tails: []Tail{res.Tail}}
+	if params["report_histogram"] == 1 {
+		r.Histogram = res.Nu[:]
+	}
+	return r, nil
+}
+
+// overlappingTemplateTest adapts OverlappingTemplate to the Test interface. The template
+// is given as an m-bit integer, most significant bit first; -1 selects the all-ones
+// template of the original test.
+type overlappingTemplateTest struct{}
+
+func (overlappingTemplateTest) Name() string { return "overlapping_template" }
+func (overlappingTemplateTest) MinBits() int { return OverlappingTemplateBlockLength }
+
+func (overlappingTemplateTest) Params() []ParamSpec {
+	return []ParamSpec{
+		{Name: "template_length", Description: "Template length m", Default: 9, Min: OverlappingTemplateMinLength, Max: OverlappingTemplateMaxLength},
+		{Name: "template", Description: "Template bits as an m-bit integer, most significant bit first (-1 selects all ones)", Default: -1, Min: -1, Max: 1<<OverlappingTemplateMaxLength - 1},
+		{Name: "block_length", Description: "Block length M", Default: OverlappingTemplateBlockLength, Min: OverlappingTemplateMinLength},
+		{Name: "degrees_of_freedom", Description: "Degrees of freedom K", Default: OverlappingTemplateDegreesOfFreedom, Min: 1, Max: 32},
+	}
+}
+
+// ValidateParams checks that the template fits in m bits and the block holds it.
+func (overlappingTemplateTest) ValidateParams(params Params) error {
+	m := params["template_length"]
+	if tmpl := params["template"]; tmpl >= 1<<m {
+		return fmt.Errorf("parameter template out of range: %d (must fit in %d bits)", tmpl, m)
+	}
+	if M := params["block_length"]; M < m {
+		return fmt.Errorf("parameter block_length out of range: %d (must be at least the template length %d)", M, m)
+	}
+	return nil
+}
+
+func (t overlappingTemplateTest) Run(_ context.Context, bitstream []byte, params Params) (TestResult, error) {
+	m := params["template_length"]
+	template := OnesTemplate(m)
+	if v := params["template"]; v >= 0 {
+		for i := range template {
+			template[i] = uint8(v >> (m - 1 - i) & 1)
+		}
+	}
+
+	res, err := OverlappingTemplate(bitstream, template, params["block_length"], params["degrees_of_freedom"])
+	if r, ok := skippedResult(t.Name(), err); ok {
+		return r, nil
+	}
+	if err != nil {
+		return TestResult{}, err
+	}
+
+	r := TestResult{Name: t.Name(), PValue: res.PValue, Passed: res.Passed, Tails: []Tail{res.Tail}}
+	if p, clamped := clampPValue(res.PValue); clamped {
+		r.PValue, r.Passed = p, p >= Alpha
+		r.Warning = clampWarning
+		return r, nil
+	}
+	return r, nil
+}
+
+// approximateEntropyTest adapts ApproximateEntropy to the Test interface. Sequences too
+// short for the block length (m >= floor(log2 n) - 5) are reported as skipped.
+type approximateEntropyTest struct{}
+
+func (approximateEntropyTest) Name() string { return "approximate_entropy" }
+
+// MinBits is the requirement of the default block length m = 10.
+func (approximateEntropyTest) MinBits() int { return 1 << 16 }
+
+func (approximateEntropyTest) Params() []ParamSpec {
+	return []ParamSpec{
+		{Name: "block_length", Description: "Block length m (below floor(log2 n) - 5)", Default: 10, Min: 1, Max: ApproximateEntropyMaxBlockLength},
+	}
+}
+
+func (t approximateEntropyTest) Run(_ context.Context, bitstream []byte, params Params) (TestResult, error) {
+	res, err := ApproximateEntropy(bitstream, params["block_length"])
+	if r, ok := skippedResult(t.Name(), err); ok {
+		return r, nil
+	}
+	if err != nil {
+		return TestResult{}, err
+	}
+
+	r := TestResult{Name: t.Name(), PValue: res.PValue, Passed: res.Passed, Tails: []Tail{res.Tail}}
+	return r, nil
+}
+
+// universalStatisticalTest adapts UniversalStatistical to the Test interface. A block
+// length of 0 selects L from the sequence length and 0 initialization blocks select
+// Q = 10 * 2^L; sequences too short for the requested L are reported as skipped.
+type universalStatisticalTest struct{}
+
+func (universalStatisticalTest) Name() string { return "universal_statistical" }
+func (universalStatisticalTest) MinBits() int { return MinBits }
+
+func (universalStatisticalTest) Params() []ParamSpec {
+	return []ParamSpec{
+		{Name: "block_length", Description: "Block length L (6-16, 0 selects L from the sequence length)", Default: 0, Min: 0, Max: UniversalMaxBlockLength},
+		{Name: "init_blocks", Description: "Initialization blocks Q (at least 10 * 2^L, 0 selects 10 * 2^L)", Default: 0, Min: 0},
+	}
+}
+
+// ValidateParams enforces the SP 800-22 constraints on L and Q. Q depends on L and can
+// only be set together with it.
+func (universalStatisticalTest) ValidateParams(params Params) error {
+	L, Q := params["block_length"], params["init_blocks"]
+	if L != 0 && L < UniversalMinBlockLength {
+		return fmt.Errorf("parameter block_length out of range: %d (must be 0 or %d-%d)", L, UniversalMinBlockLength, UniversalMaxBlockLength)
+	}
+	if Q == 0 {
+		return nil
+	}
+	if L == 0 {
+		return fmt.Errorf("parameter init_blocks requires block_length")
+	}
+	if minQ := universalMinInitBlocks(L); Q < minQ {
+		return fmt.Errorf("parameter init_blocks out of range: %d (must be at least %d for L = %d)", Q, minQ, L)
+	}
+	return nil
+}
+
+func (t universalStatisticalTest) Run(_ context.Context, bitstream []byte, params Params) (TestResult, error) {
+	res, err := UniversalStatistical(bitstream, params["block_length"], params["init_blocks"])
+	if r, ok := skippedResult(t.Name(), err); ok {
+		return r, nil
+	}
+	if err != nil {
+		return TestResult{}, err
+	}
+
+	r := TestResult{Name: t.Name(), PValue: res.PValue, Passed: res.Passed, Tails: []Tail{res.Tail}}
+	return r, nil
+}
+
+// skippedResult converts an *InsufficientBitsError into a skipped result.
+func skippedResult(name string, err error) (TestResult, bool) {
+	var short *InsufficientBitsError
+	if !errors.As(err, &short) {
+		return TestResult{}, false
+	}
+	return TestResult{
+		Name:    name,
+		PValue:  -1,
+		Warning: fmt.Sprintf("skipped: needs at least %d bits (%s)", short.Need, short.Reason),
+	}, true
+}
+
+// excursionsSkipWarning reports a sequence with too few cycles for the Random Excursions
+// tests, for which SP 800-22 discontinues them.
+const excursionsSkipWarning = "skipped: fewer than 500 cycles (J < 500)"
+
+// builtinTests returns the 15 SP 800-22 tests in the order of the specification.
+func builtinTests() []Test {
+	return []Test{
+		&funcTest{
+			name:    "frequency_monobit",
+			minBits: 100,
+			run:     single(frequencyTails),
+		},
+		&funcTest{
+			name:    "block_frequency",
+			minBits: 100,
+			params: []ParamSpec{
+				{Name: "block_length", Description: "Block length M", Default: 128, Min: 1},
+			},
+			run:         withParam(blockFrequencyTails, "block_length"),
+			zeroWarning: "insufficient bits for block size",
+		},
+		cumulativeSumsTest{},
+		&funcTest{
+			name:        "runs",
+			minBits:     100,
+			run:         single(runsTails),
+			zeroWarning: "Pi estimator criteria not met",
+		},
+		&funcTest{
+			name:        "longest_run",
+			minBits:     128,
+			run:         single(longestRunOfOnesTails),
+			zeroWarning: "insufficient bits for test",
+		},
+		binaryMatrixRankTest{&funcTest{
+			name:    "binary_matrix_rank",
+			minBits: BinaryMatrixRankMinMatrices * BinaryMatrixRankMinDim * BinaryMatrixRankMinDim,
+			params: []ParamSpec{
+				{Name: "rows", Description: "Matrix rows M", Default: 32, Min: BinaryMatrixRankMinDim, Max: BinaryMatrixRankMaxDim},
+				{Name: "columns", Description: "Matrix columns Q", Default: 32, Min: BinaryMatrixRankMinDim, Max: BinaryMatrixRankMaxDim},
+			},
+			run: func(b []byte, p Params) []Tail {
+				return binaryMatrixRankTails(b, p["rows"], p["columns"])
+			},
+		}},
+		&funcTest{
+			name:    "discrete_fourier_transform",
+			minBits: 1000,
+			run:     single(discreteFourierTransformTails),
+		},
+		&funcTest{
+			name:    "non_overlapping_template",
+			minBits: 100,
+			params: []ParamSpec{
+				{Name: "template_length", Description: "Template length m (only 9 is supported)", Default: 9, Min: 9, Max: 9},
+			},
+			run:         withParam(nonOverlappingTemplateTails, "template_length"),
+			zeroWarning: "only m=9 supported or insufficient bits",
+		},
+		overlappingTemplateTest{},
+		universalStatisticalTest{},
+		approximateEntropyTest{},
+		&funcTest{
+			name:        "random_excursions",
+			minBits:     100,
+			run:         single(randomExcursionsTails),
+			skipWarning: excursionsSkipWarning,
+		},
+		&funcTest{
+			name:        "random_excursions_variant",
+			minBits:     100,
+			run:         single(randomExcursionsVariantTails),
+			skipWarning: excursionsSkipWarning,
+		},
+		&funcTest{
+			name:    "serial",
+			minBits: 100,
+			params: []ParamSpec{
+				{Name: "block_length", Description: "Block length m", Default: 16, Min: 2, Max: SerialMaxBlockLength},
+			},
+			run: withParam(serialTails, "block_length"),
+		},
+		linearComplexityTest{},
+	}
+}
+
+// newDefaultRegistry returns a registry containing the built-in tests.
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, t := range builtinTests() {
+		if err := r.Register(t); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
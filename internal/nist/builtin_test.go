@@ -0,0 +1,287 @@
+package nist
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestBuiltinRegistry(t *testing.T) {
+	want := []string{
+		"frequency_monobit", "block_frequency", "cumulative_sums", "runs", "longest_run",
+		"binary_matrix_rank", "discrete_fourier_transform", "non_overlapping_template",
+		"overlapping_template", "universal_statistical", "approximate_entropy",
+		"random_excursions", "random_excursions_variant", "serial", "linear_complexity",
+	}
+
+	names := DefaultRegistry.Names()
+	if len(names) != len(want) {
+		t.Fatalf("expected %d built-in tests, got %d", len(want), len(names))
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("test %d: got %s, want %s", i, names[i], want[i])
+		}
+	}
+
+	for _, test := range DefaultRegistry.Tests() {
+		if test.MinBits() <= 0 || test.MinBits() > MinBits {
+			t.Errorf("%s: MinBits %d outside (0, %d]", test.Name(), test.MinBits(), MinBits)
+		}
+	}
+}
+
+func TestBuiltinMatchesTestFunctions(t *testing.T) {
+	data := make([]byte, 50000)
+	state := uint32(12345)
+	for i := range data {
+		state = state*1664525 + 1013904223
+		data[i] = byte(state >> 24)
+	}
+
+	results, err := RunAllTests(data)
+	if err != nil {
+		t.Fatalf("RunAllTests failed: %v", err)
+	}
+
+	direct := map[string]func() (float64, bool){
+		"frequency_monobit": func() (float64, bool) { return FrequencyTest(data) },
+		"block_frequency":   func() (float64, bool) { return BlockFrequencyTest(data, 128) },
+		"runs":              func() (float64, bool) { return RunsTest(data) },
+		"serial":            func() (float64, bool) { return SerialTest(data, 16) },
+		"linear_complexity": func() (float64, bool) { return LinearComplexityTest(data, 500) },
+	}
+	for _, r := range results {
+		f, ok := direct[r.Name]
+		if !ok {
+			continue
+		}
+		p, passed := f()
+		if r.PValue != p || r.Passed != passed {
+			t.Errorf("%s: registry gave (%f, %v), direct call (%f, %v)", r.Name, r.PValue, r.Passed, p, passed)
+		}
+		if passed && r.Proportion != 1.0 {
+			t.Errorf("%s: expected proportion 1.0 for a passing test", r.Name)
+		}
+	}
+
+	// Parameter overrides reach the test function.
+	opts := RunOptions{
+		Tests:  []string{"block_frequency", "approximate_entropy"},
+		Params: map[string]Params{"block_frequency": {"block_length": 64}, "approximate_entropy": {"block_length": 8}},
+	}
+	got, err := DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if p, _ := BlockFrequencyTest(data, 64); got[0].PValue != p {
+		t.Errorf("block_frequency override not applied: got %f, want %f", got[0].PValue, p)
+	}
+	if p, _ := ApproximateEntropyTest(data, 8); got[1].PValue != p {
+		t.Errorf("approximate_entropy override not applied: got %f, want %f", got[1].PValue, p)
+	}
+}
+
+func TestBuiltinZeroWarning(t *testing.T) {
+	test, ok := DefaultRegistry.Lookup("block_frequency")
+	if !ok {
+		t.Fatal("block_frequency not registered")
+	}
+
+	r, err := test.Run(context.Background(), make([]byte, 8), Params{"block_length": 128})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if r.Passed || r.Warning != "insufficient bits for block size" {
+		t.Fatalf("expected warning for block longer than input, got %+v", r)
+	}
+}
+
+func TestBuiltinLinearComplexity(t *testing.T) {
+	data := pseudoRandomBytes(100000, 3) // 800000 bits
+
+	opts := RunOptions{
+		Tests:  []string{"linear_complexity"},
+		Params: map[string]Params{"linear_complexity": {"block_length": 1000, "report_histogram": 1}},
+	}
+	got, err := DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	res, err := LinearComplexity(data, 1000)
+	if err != nil {
+		t.Fatalf("LinearComplexity failed: %v", err)
+	}
+	if got[0].PValue != res.PValue || len(got[0].Histogram) != LinearComplexityClasses {
+		t.Fatalf("unexpected result %+v", got[0])
+	}
+	for i, nu := range res.Nu {
+		if got[0].Histogram[i] != nu {
+			t.Errorf("histogram[%d]=%d, want %d", i, got[0].Histogram[i], nu)
+		}
+	}
+
+	// Without the option no histogram is attached; too few blocks are skipped.
+	opts.Params["linear_complexity"] = Params{"block_length": 5000}
+	got, err = DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got[0].PValue != -1 || got[0].Histogram != nil || got[0].Warning != "skipped: needs at least 1000000 bits (200 blocks of 5000 bits)" {
+		t.Errorf("expected skipped result, got %+v", got[0])
+	}
+}
+
+func TestBuiltinBinaryMatrixRankMinimum(t *testing.T) {
+	data := pseudoRandomBytes(10000, 5) // 80000 bits
+
+	tests := []struct {
+		rows, columns int
+		warning       string
+	}{
+		{32, 32, ""},
+		{8, 8, ""},
+		{64, 64, "skipped: needs at least 155648 bits (38 matrices of 64x64 bits)"},
+		{64, 48, "skipped: needs at least 116736 bits (38 matrices of 64x48 bits)"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%dx%d", tt.rows, tt.columns), func(t *testing.T) {
+			opts := RunOptions{
+				Tests:  []string{"binary_matrix_rank"},
+				Params: map[string]Params{"binary_matrix_rank": {"rows": tt.rows, "columns": tt.columns}},
+			}
+			got, err := DefaultRegistry.Run(context.Background(), data, opts)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if tt.warning == "" {
+				if want, _ := BinaryMatrixRankTestMQ(data, tt.rows, tt.columns); got[0].PValue != want {
+					t.Errorf("p-value %g, want %g", got[0].PValue, want)
+				}
+				return
+			}
+			if got[0].PValue != -1 || got[0].Warning != tt.warning {
+				t.Errorf("expected skipped result, got %+v", got[0])
+			}
+		})
+	}
+
+	// Too short for even the smallest matrices
+	got, err := DefaultRegistry.Run(context.Background(), make([]byte, 16), RunOptions{Tests: []string{"binary_matrix_rank"}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got[0].PValue != -1 {
+		t.Errorf("expected skipped result, got %+v", got[0])
+	}
+}
+
+func TestBuiltinUniversalParams(t *testing.T) {
+	data := pseudoRandomBytes(125000, 9) // 1,000,000 bits
+
+	opts := RunOptions{
+		Tests:  []string{"universal_statistical"},
+		Params: map[string]Params{"universal_statistical": {"block_length": 6, "init_blocks": 1000}},
+	}
+	got, err := DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	res, err := UniversalStatistical(data, 6, 1000)
+	if err != nil {
+		t.Fatalf("UniversalStatistical failed: %v", err)
+	}
+	if got[0].PValue != res.PValue {
+		t.Fatalf("override not applied: got %v, want %v", got[0].PValue, res.PValue)
+	}
+
+	opts.Params["universal_statistical"] = Params{"block_length": 9}
+	got, err = DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := "skipped: needs at least 4654080 bits (L = 9: 5120 initialization and 512000 test blocks)"
+	if got[0].PValue != -1 || got[0].Warning != want {
+		t.Errorf("expected skipped result, got %+v", got[0])
+	}
+
+	for _, p := range []Params{{"block_length": 5}, {"block_length": 17}, {"init_blocks": 640}, {"block_length": 6, "init_blocks": 639}} {
+		opts.Params["universal_statistical"] = p
+		if err := DefaultRegistry.Validate(opts); err == nil {
+			t.Errorf("expected validation error for %v", p)
+		}
+	}
+}
+
+func TestBuiltinOverlappingTemplateParams(t *testing.T) {
+	data := pseudoRandomBytes(125000, 13)
+
+	opts := RunOptions{
+		Tests: []string{"overlapping_template"},
+		Params: map[string]Params{"overlapping_template": {
+			"template_length": 4, "template": 0b0110, "block_length": 100, "degrees_of_freedom": 3,
+		}},
+	}
+	got, err := DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	res, err := OverlappingTemplate(data, []uint8{0, 1, 1, 0}, 100, 3)
+	if err != nil {
+		t.Fatalf("OverlappingTemplate failed: %v", err)
+	}
+	if got[0].PValue != res.PValue {
+		t.Fatalf("overrides not applied: got %v, want %v", got[0].PValue, res.PValue)
+	}
+
+	// The default is the all-ones template.
+	opts.Params["overlapping_template"] = Params{"template_length": 10}
+	got, err = DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if p, _ := OverlappingTemplateTest(data, 10); got[0].PValue != p {
+		t.Fatalf("default template: got %v, want %v", got[0].PValue, p)
+	}
+
+	for _, p := range []Params{{"template_length": 4, "template": 16}, {"template_length": 9, "block_length": 8}, {"degrees_of_freedom": 0}} {
+		opts.Params["overlapping_template"] = p
+		if err := DefaultRegistry.Validate(opts); err == nil {
+			t.Errorf("expected validation error for %v", p)
+		}
+	}
+}
+
+func TestBuiltinApproximateEntropyBound(t *testing.T) {
+	data := pseudoRandomBytes(125000, 14) // 1,000,000 bits: m must be below 14
+
+	opts := RunOptions{
+		Tests:  []string{"approximate_entropy"},
+		Params: map[string]Params{"approximate_entropy": {"block_length": 14}},
+	}
+	got, err := DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := "skipped: needs at least 1048576 bits (m = 14 must be below floor(log2 n) - 5)"
+	if got[0].PValue != -1 || got[0].Warning != want {
+		t.Errorf("expected skipped result, got %+v", got[0])
+	}
+}
+
+func TestBuiltinRandomExcursionsSkipped(t *testing.T) {
+	// All ones never return to zero, so there are fewer than 500 cycles
+	data := bytes.Repeat([]byte{0xFF}, 125000)
+
+	opts := RunOptions{Tests: []string{"random_excursions", "random_excursions_variant"}}
+	got, err := DefaultRegistry.Run(context.Background(), data, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, r := range got {
+		if r.PValue != -1 || r.Warning != excursionsSkipWarning || r.Failed() {
+			t.Errorf("expected skipped result, got %+v", r)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package nist
 
-import "math"
+import (
+	"math"
+	"slices"
+)
 
 // Alpha is the default significance level used by the NIST SP800-22 tests.
 const Alpha = 0.01
@@ -12,6 +15,20 @@ func bitAt(data []byte, idx int) uint8 {
 	return (data[byteIdx] >> bitIdx) & 1
 }
 
+// readBits returns count <= 64 bits starting at bit position start in big-endian bit
+// order, with the first bit as the most significant of the result.
+func readBits(data []byte, start, count int) uint64 {
+	var v uint64
+	for idx, end := start, start+count; idx < end; {
+		off := idx & 7
+		take := min(8-off, end-idx)
+		chunk := data[idx>>3] >> (8 - off - take) & (1<<take - 1)
+		v = v<<take | uint64(chunk)
+		idx += take
+	}
+	return v
+}
+
 // expandBits converts a byte slice to a slice of individual bits (0 or 1).
 func expandBits(bitstream []byte) []uint8 {
 	n := len(bitstream) * 8
@@ -27,34 +44,76 @@ func normal(x float64) float64 {
 	return 0.5 * math.Erfc(-x/math.Sqrt2)
 }
 
-// psi2 computes the psi_m statistic used by the Serial and Approximate Entropy tests.
-func psi2(bits []uint8, m int) float64 {
+// psi2 computes the psi_m statistic used by the Serial test over the cyclic sequence of
+// the n bits of bitstream.
+func psi2(bitstream []byte, m int) float64 {
 	if m <= 0 {
 		return 0
 	}
 
-	n := len(bits)
-	powLen := (1 << (m + 1)) - 1
-	P := make([]int, powLen)
+	n := len(bitstream) * 8
+	sum := 0.0
+	patternCounts(bitstream, m, func(count int) {
+		sum += math.Pow(float64(count), 2)
+	})
 
-	for i := 0; i < n; i++ {
-		k := 1
-		for j := 0; j < m; j++ {
-			if bits[(i+j)%n] == 0 {
-				k *= 2
-			} else {
-				k = 2*k + 1
-			}
+	return sum*math.Pow(2, float64(m))/float64(n) - float64(n)
+}
+
+// maxDensePatterns bounds the table of patternCounts; longer patterns are counted by
+// sorting once 2^m exceeds the sequence length.
+const maxDensePatterns = 1 << 20
+
+// patternCounts calls fn with the number of occurrences of every m-bit pattern (m <= 63)
+// that occurs in the cyclic sequence of the n bits of bitstream, where position i covers
+// bits i..i+m-1 mod n. Patterns are visited in ascending order, most significant bit
+// first. The window advances by one shift per position. While 2^m is at most
+// max(n, maxDensePatterns) the counts are kept in a table of 2^m entries; beyond that
+// the n windows are sorted, so memory stays O(n) for any m.
+func patternCounts(bitstream []byte, m int, fn func(count int)) {
+	n := len(bitstream) * 8
+	if n == 0 || m <= 0 {
+		return
+	}
+
+	mask := uint64(1)<<m - 1
+	var window uint64
+	for j := 0; j < m-1; j++ {
+		window = window<<1 | uint64(bitAt(bitstream, j%n))
+	}
+	next := (m - 1) % n
+	advance := func() uint64 {
+		window = (window<<1 | uint64(bitAt(bitstream, next))) & mask
+		if next++; next == n {
+			next = 0
 		}
-		P[k-1]++
+		return window
 	}
 
-	sum := 0.0
-	start := (1 << m) - 1
-	end := (1 << (m + 1)) - 1
-	for i := start; i < end; i++ {
-		sum += math.Pow(float64(P[i]), 2)
+	if m < 32 && 1<<m <= max(n, maxDensePatterns) {
+		counts := make([]int32, 1<<m)
+		for i := 0; i < n; i++ {
+			counts[advance()]++
+		}
+		for _, c := range counts {
+			if c > 0 {
+				fn(int(c))
+			}
+		}
+		return
 	}
 
-	return sum*math.Pow(2, float64(m))/float64(n) - float64(n)
+	windows := make([]uint64, n)
+	for i := range windows {
+		windows[i] = advance()
+	}
+	slices.Sort(windows)
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && windows[j] == windows[i] {
+			j++
+		}
+		fn(j - i)
+		i = j
+	}
 }
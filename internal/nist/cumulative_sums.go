@@ -5,19 +5,50 @@ import "math"
 // CumulativeSumsTest implements the NIST Cumulative Sums (Cusum) test.
 // It returns the minimum p-value across forward and reverse runs and whether it passes at Alpha.
 func CumulativeSumsTest(bitstream []byte) (float64, bool) {
-	bits := expandBits(bitstream)
-	n := len(bits)
-	if n == 0 {
+	if len(bitstream) == 0 {
 		return 0, false
 	}
 
-	pForward := cumulativeSums(bits, false)
-	pReverse := cumulativeSums(bits, true)
+	pForward, pReverse := CumulativeSumsPValues(bitstream)
 	pValue := math.Min(pForward, pReverse)
 
 	return pValue, pValue >= Alpha
 }
 
+// CumulativeSumsPValues returns the forward (mode 0) and reverse (mode 1) p-values
+// of the Cumulative Sums test.
+func CumulativeSumsPValues(bitstream []byte) (forward, reverse float64) {
+	forward, reverse, _ = cumulativeSumsPValues(bitstream)
+	return forward, reverse
+}
+
+// cumulativeSumsPValues also reports whether a p-value had to be clamped to [0, 1].
+func cumulativeSumsPValues(bitstream []byte) (forward, reverse float64, clamped bool) {
+	tails := cumulativeSumsTails(bitstream)
+	if tails == nil {
+		return 0, 0, false
+	}
+
+	forward, cf := clampPValue(tails[0].PValue())
+	reverse, cr := clampPValue(tails[1].PValue())
+	return forward, reverse, cf || cr
+}
+
+// cumulativeSumsTails returns the forward and reverse tails of the Cumulative Sums test.
+func cumulativeSumsTails(bitstream []byte) []Tail {
+	bits := expandBits(bitstream)
+	n := len(bits)
+	if n == 0 {
+		return nil
+	}
+
+	return []Tail{
+		{Kind: CusumTail, A: float64(n), X: cumulativeSums(bits, false)},
+		{Kind: CusumTail, A: float64(n), X: cumulativeSums(bits, true)},
+	}
+}
+
+// cumulativeSums returns the maximal excursion z of the partial sums of the ±1 sequence.
 func cumulativeSums(bits []uint8, reverse bool) float64 {
 	n := len(bits)
 	var sup, inf, sum float64
@@ -57,19 +88,33 @@ func cumulativeSums(bits []uint8, reverse bool) float64 {
 		z = math.Abs(inf)
 	}
 
-	start := int((-float64(n)/z + 1) / 4)
-	finish := int((float64(n)/z - 1) / 4)
+	return z
+}
+
+// cumulativeSumsPValue evaluates
+//
+//	p = 1 - sum_k [Φ((4k+1)z/√n) - Φ((4k-1)z/√n)] + sum_k [Φ((4k+3)z/√n) - Φ((4k+1)z/√n)]
+//
+// for the maximal excursion z of n steps. The k = 0 term of the first sum equals
+// 1 - erfc(z/√(2n)), which absorbs the leading 1; the remaining differences are
+// computed per tail and summed with compensation, so small p-values keep their
+// relative accuracy.
+func cumulativeSumsPValue(n int, z float64) float64 {
+	sqrtN := math.Sqrt(float64(n))
+	arg := func(j int) float64 { return float64(j) * z / sqrtN }
+
+	var p compensatedSum
+	p.add(math.Erfc(z / (sqrtN * math.Sqrt2)))
 
-	var sum1 float64
-	for k := start; k <= finish; k++ {
-		term1 := normal((4*float64(k)+1)*z/math.Sqrt(float64(n))) - normal((4*float64(k)-1)*z/math.Sqrt(float64(n)))
-		sum1 += term1
+	finish := int((float64(n)/z - 1) / 4)
+	for k := int((-float64(n)/z + 1) / 4); k <= finish; k++ {
+		if k != 0 {
+			p.add(-normalDiff(arg(4*k-1), arg(4*k+1)))
+		}
 	}
-	sum1 = 1.0 - sum1
-	for k := start; k <= finish; k++ {
-		term2 := normal((4*float64(k)+3)*z/math.Sqrt(float64(n))) - normal((4*float64(k)+1)*z/math.Sqrt(float64(n)))
-		sum1 += term2
+	for k := int((-float64(n)/z - 3) / 4); k <= finish; k++ {
+		p.add(normalDiff(arg(4*k+1), arg(4*k+3)))
 	}
 
-	return sum1
+	return p.value()
 }
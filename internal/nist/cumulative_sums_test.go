@@ -1,6 +1,7 @@
 package nist
 
 import (
+	"math"
 	"testing"
 )
 
@@ -22,4 +23,16 @@ func TestCumulativeSums(t *testing.T) {
 			t.Errorf("expected non-zero p-value, got p=%.6f", p)
 		}
 	})
+
+	t.Run("p_values_match_minimum", func(t *testing.T) {
+		data := make([]byte, 125)
+		for i := range data {
+			data[i] = byte(i * 37)
+		}
+		forward, reverse := CumulativeSumsPValues(data)
+		p, _ := CumulativeSumsTest(data)
+		if p != math.Min(forward, reverse) {
+			t.Fatalf("expected min(%.6f, %.6f), got %.6f", forward, reverse, p)
+		}
+	})
 }
@@ -3,45 +3,432 @@ package nist
 import (
 	"math"
 	"math/cmplx"
-
-	"gonum.org/v1/gonum/dsp/fourier"
+	"sync"
 )
 
+// DFTMemoryBytes returns the working memory of the Spectral test for a sequence of
+// numBits bits: a complex buffer and a twiddle table of numBits/2 entries each, i.e.
+// 16 bytes per bit, plus the buffers of the Bluestein transforms of prime factors of
+// at least bluesteinMinRadix, up to about 20 bytes per bit more. Plans are pooled per
+// length; every concurrent run holds one.
+func DFTMemoryBytes(numBits int) int64 {
+	return dftPlanBytes(numBits / 2)
+}
+
 // DiscreteFourierTransformTest implements the NIST Spectral (FFT) test.
 // It returns the p-value and whether it passes at Alpha.
 func DiscreteFourierTransformTest(bitstream []byte) (float64, bool) {
+	return minPValue(discreteFourierTransformTails(bitstream))
+}
+
+func discreteFourierTransformTails(bitstream []byte) []Tail {
 	n := len(bitstream) * 8
 	if n == 0 {
-		return 0, false
+		return nil
 	}
 
-	series := make([]float64, n)
-	for i := 0; i < n; i++ {
-		if bitAt(bitstream, i) == 1 {
-			series[i] = 1
-		} else {
-			series[i] = -1
+	upperBound := math.Sqrt(2.995732274 * float64(n))
+	count := 0
+	realSpectrum(bitstream, func(k int, mag float64) {
+		if mag < upperBound {
+			count++
 		}
+	})
+
+	d := (float64(count) - 0.95*float64(n)/2.0) / math.Sqrt(float64(n)/4.0*0.95*0.05)
+	return []Tail{{Kind: ErfcTail, X: math.Abs(d) / math.Sqrt2}}
+}
+
+// realSpectrum calls fn with |X_k| for k = 0..n/2-1, where X is the DFT of the ±1
+// sequence of the n bits of bitstream. The real input is packed into n/2 complex
+// samples z_j = x_2j + i x_2j+1 so that a single half-length transform is needed.
+func realSpectrum(bitstream []byte, fn func(k int, mag float64)) {
+	n := len(bitstream) * 8
+	half := n / 2
+
+	p := getDFTPlan(half)
+	defer putDFTPlan(p)
+
+	p.load(func(j int) complex128 {
+		return complex(pm1(bitstream, 2*j), pm1(bitstream, 2*j+1))
+	})
+	p.transform()
+
+	// X_k = E_k + w^k O_k with E_k = (Z_k + conj Z_{N-k}) / 2, O_k = (Z_k - conj Z_{N-k}) / 2i
+	// and w = exp(-2 pi i / n); p.twiddle[k] holds w^k.
+	z := p.data
+	for k := 0; k < half; k++ {
+		a := z[k]
+		b := z[(half-k)%half]
+		b = complex(real(b), -imag(b))
+		e := (a + b) / 2
+		o := (a - b) / 2
+		o = complex(imag(o), -real(o))
+		x := e + p.twiddle[k]*o
+		fn(k, math.Hypot(real(x), imag(x)))
 	}
+}
+
+// pm1 returns the bit at idx mapped to -1 or +1.
+func pm1(bitstream []byte, idx int) float64 {
+	return float64(2*int(bitAt(bitstream, idx)) - 1)
+}
+
+// dftPlans holds a *sync.Pool of plans for every transform length in use.
+var dftPlans sync.Map
 
-	fft := fourier.NewFFT(n)
-	coeffs := fft.Coefficients(nil, series)
+func getDFTPlan(size int) *dftPlan {
+	pool, _ := dftPlans.LoadOrStore(size, &sync.Pool{
+		New: func() any { return newDFTPlan(size) },
+	})
+	return pool.(*sync.Pool).Get().(*dftPlan)
+}
 
-	magnitudes := make([]float64, n/2)
-	for i := 0; i < n/2; i++ {
-		magnitudes[i] = cmplx.Abs(coeffs[i])
+func putDFTPlan(p *dftPlan) {
+	if pool, ok := dftPlans.Load(p.size); ok {
+		pool.(*sync.Pool).Put(p)
 	}
+}
 
-	upperBound := math.Sqrt(2.995732274 * float64(n))
-	count := 0
-	for _, m := range magnitudes {
-		if m < upperBound {
-			count++
+// bluesteinMinRadix is the smallest radix transformed with Bluestein's algorithm in
+// O(radix log radix) instead of the direct O(radix²) butterfly, so that a sequence
+// length with a large prime factor cannot slow the test down by that factor.
+const bluesteinMinRadix = 64
+
+// dftPlan is an in-place mixed-radix decimation-in-time FFT of a fixed length. The
+// input is stored in digit-reversed order by load and the output is in natural order.
+type dftPlan struct {
+	size    int
+	factors []int
+	data    []complex128
+	// twiddle[k] = exp(-pi i k / size), so exp(-2 pi i j / size) is twiddle[2j] for
+	// 2j < size and -twiddle[2j - size] otherwise.
+	twiddle []complex128
+	scratch []complex128
+	// bluestein holds the transform of every distinct radix of at least bluesteinMinRadix.
+	bluestein map[int]*bluestein
+}
+
+func newDFTPlan(size int) *dftPlan {
+	p := &dftPlan{
+		size:    size,
+		factors: factorize(size),
+		data:    make([]complex128, size),
+		twiddle: make([]complex128, size),
+	}
+	for k := range p.twiddle {
+		s, c := math.Sincos(-math.Pi * float64(k) / float64(size))
+		p.twiddle[k] = complex(c, s)
+	}
+	maxFactor := 0
+	for _, f := range p.factors {
+		if f < bluesteinMinRadix {
+			maxFactor = max(maxFactor, f)
+			continue
+		}
+		if p.bluestein == nil {
+			p.bluestein = make(map[int]*bluestein)
+		}
+		if p.bluestein[f] == nil {
+			p.bluestein[f] = newBluestein(f)
 		}
 	}
+	p.scratch = make([]complex128, 3*maxFactor)
+	return p
+}
 
-	d := (float64(count) - 0.95*float64(n)/2.0) / math.Sqrt(float64(n)/4.0*0.95*0.05)
-	pValue := math.Erfc(math.Abs(d) / math.Sqrt2)
+// dftPlanBytes returns the memory held by a plan of the given size, see newDFTPlan.
+func dftPlanBytes(size int) int64 {
+	bytes := int64(0)
+	maxFactor, last := 0, 0
+	for _, f := range factorize(size) {
+		switch {
+		case f < bluesteinMinRadix:
+			maxFactor = max(maxFactor, f)
+		case f != last:
+			bytes += bluesteinBytes(f)
+		}
+		last = f
+	}
+	return bytes + 16*int64(2*size+3*maxFactor)
+}
+
+// smoothLength returns the smallest integer of the form 2^a 3^b 5^c that is at least n.
+func smoothLength(n int) int {
+	best := math.MaxInt
+	for p5 := 1; ; p5 *= 5 {
+		for p35 := p5; ; p35 *= 3 {
+			v := p35
+			for v < n {
+				v *= 2
+			}
+			best = min(best, v)
+			if p35 >= n {
+				break
+			}
+		}
+		if p5 >= n {
+			break
+		}
+	}
+	return best
+}
 
-	return pValue, pValue >= Alpha
+// factorize splits n into the radices of the transform, preferring 4 and 2.
+func factorize(n int) []int {
+	var factors []int
+	for n%4 == 0 {
+		factors = append(factors, 4)
+		n /= 4
+	}
+	if n%2 == 0 {
+		factors = append(factors, 2)
+		n /= 2
+	}
+	for f := 3; f*f <= n; f += 2 {
+		for n%f == 0 {
+			factors = append(factors, f)
+			n /= f
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return factors
+}
+
+// root returns exp(-2 pi i j / size) for 0 <= j < size.
+func (p *dftPlan) root(j int) complex128 {
+	if 2*j < p.size {
+		return p.twiddle[2*j]
+	}
+	return -p.twiddle[2*j-p.size]
+}
+
+// load fills the plan with sample(j) for j < size in digit-reversed order. Positions
+// are visited sequentially; a mixed-radix counter tracks the matching sample index,
+// whose least significant digit (base factors[0]) is the most significant of the
+// position.
+func (p *dftPlan) load(sample func(j int) complex128) {
+	// A length below 2^63 has at most 63 factors; the arrays avoid allocations in the
+	// Bluestein transforms, which load their plan for every butterfly.
+	var counterBuf, weightBuf [64]int
+	k := len(p.factors)
+	counter := counterBuf[:k] // digit i counts in base factors[i]
+	weight := weightBuf[:k]   // weight[i] = factors[0] * ... * factors[i-1]
+	w := 1
+	for i := 0; i < k; i++ {
+		weight[i] = w
+		w *= p.factors[i]
+	}
+
+	j := 0
+	for pos := range p.data {
+		p.data[pos] = sample(j)
+
+		// Increment the position, whose least significant digit is counter[k-1].
+		for i := k - 1; i >= 0; i-- {
+			counter[i]++
+			j += weight[i]
+			if counter[i] < p.factors[i] {
+				break
+			}
+			counter[i] = 0
+			j -= p.factors[i] * weight[i]
+		}
+	}
+}
+
+// transform computes the unnormalized forward DFT of the loaded data in place.
+func (p *dftPlan) transform() {
+	x := p.data
+	m := 1
+	for i := len(p.factors) - 1; i >= 0; i-- {
+		radix := p.factors[i]
+		length := m * radix
+		stride := p.size / length
+		b := p.bluestein[radix]
+		if radix > 5 && b == nil {
+			p.setOmega(radix)
+		}
+		for base := 0; base < p.size; base += length {
+			for j := 0; j < m; j++ {
+				switch radix {
+				case 2:
+					a0, a1 := x[base+j], x[base+j+m]*p.root(j*stride)
+					x[base+j] = a0 + a1
+					x[base+j+m] = a0 - a1
+				case 4:
+					a0 := x[base+j]
+					a1 := x[base+j+m] * p.root(j*stride)
+					a2 := x[base+j+2*m] * p.root(2*j*stride)
+					a3 := x[base+j+3*m] * p.root(3*j*stride)
+					t0, t1 := a0+a2, a0-a2
+					t2, t3 := a1+a3, a1-a3
+					t3 = complex(imag(t3), -real(t3)) // -i (a1 - a3)
+					x[base+j] = t0 + t2
+					x[base+j+m] = t1 + t3
+					x[base+j+2*m] = t0 - t2
+					x[base+j+3*m] = t1 - t3
+				case 3:
+					p.butterfly3(x, base+j, m, j*stride)
+				case 5:
+					p.butterfly5(x, base+j, m, j*stride)
+				default:
+					if b != nil {
+						b.butterfly(p, x, base+j, m, j*stride)
+					} else {
+						p.butterfly(x, base+j, m, radix, j*stride)
+					}
+				}
+			}
+		}
+		m = length
+	}
+}
+
+// Sines and cosines of the radix-3 and radix-5 butterflies.
+var (
+	sin3  = math.Sin(2 * math.Pi / 3)
+	cos5a = math.Cos(2 * math.Pi / 5)
+	cos5b = math.Cos(4 * math.Pi / 5)
+	sin5a = math.Sin(2 * math.Pi / 5)
+	sin5b = math.Sin(4 * math.Pi / 5)
+)
+
+// butterfly3 is butterfly specialized for radix 3.
+func (p *dftPlan) butterfly3(x []complex128, start, m, tw int) {
+	a0 := x[start]
+	a1 := x[start+m] * p.root(tw)
+	a2 := x[start+2*m] * p.root(2*tw)
+
+	t := a1 + a2
+	b := a0 - t/2
+	d := (a1 - a2) * complex(0, -sin3)
+	x[start] = a0 + t
+	x[start+m] = b + d
+	x[start+2*m] = b - d
+}
+
+// butterfly5 is butterfly specialized for radix 5.
+func (p *dftPlan) butterfly5(x []complex128, start, m, tw int) {
+	a0 := x[start]
+	a1 := x[start+m] * p.root(tw)
+	a2 := x[start+2*m] * p.root(2*tw)
+	a3 := x[start+3*m] * p.root(3*tw)
+	a4 := x[start+4*m] * p.root(4*tw)
+
+	t1, t2 := a1+a4, a2+a3
+	t3, t4 := a1-a4, a2-a3
+	b1 := a0 + complex(cos5a, 0)*t1 + complex(cos5b, 0)*t2
+	b2 := a0 + complex(cos5b, 0)*t1 + complex(cos5a, 0)*t2
+	d1 := complex(0, -1) * (complex(sin5a, 0)*t3 + complex(sin5b, 0)*t4)
+	d2 := complex(0, -1) * (complex(sin5b, 0)*t3 - complex(sin5a, 0)*t4)
+	x[start] = a0 + t1 + t2
+	x[start+m] = b1 + d1
+	x[start+2*m] = b2 + d2
+	x[start+3*m] = b2 - d2
+	x[start+4*m] = b1 - d1
+}
+
+// setOmega stores the roots of unity exp(-2 pi i r / radix) for the generic butterfly.
+func (p *dftPlan) setOmega(radix int) {
+	omega := p.scratch[2*radix : 3*radix]
+	for r := range omega {
+		omega[r] = p.root(r * (p.size / radix))
+	}
+}
+
+// butterfly applies the twiddles exp(-2 pi i q j / length), given by the step
+// tw = j * size / length, to x[start + q*m] for q < radix and replaces them by their
+// radix-point DFT.
+func (p *dftPlan) butterfly(x []complex128, start, m, radix, tw int) {
+	in, out, omega := p.scratch[:radix], p.scratch[radix:2*radix], p.scratch[2*radix:3*radix]
+	for q := range in {
+		in[q] = x[start+q*m] * p.root(q*tw)
+	}
+	for r := range out {
+		var sum complex128
+		idx := 0
+		for _, a := range in {
+			sum += a * omega[idx]
+			if idx += r; idx >= radix {
+				idx -= radix
+			}
+		}
+		out[r] = sum
+	}
+	for r, v := range out {
+		x[start+r*m] = v
+	}
+}
+
+// bluestein computes DFTs of a fixed length n as a cyclic convolution of length
+// m >= 2n - 1, evaluated with a plan of 5-smooth length (Bluestein's chirp-z
+// algorithm). It uses jk = (j² + k² - (k-j)²) / 2:
+//
+//	X_k = c_k sum_j (x_j c_j) conj(c_(k-j)),  c_j = exp(-pi i j² / n)
+type bluestein struct {
+	n     int
+	chirp []complex128 // c_j for j < n
+	// kernel is the DFT of conj(c_j) wrapped around to length m, divided by m so that
+	// the inverse transform needs no scaling.
+	kernel []complex128
+	work   []complex128
+	plan   *dftPlan
+}
+
+func newBluestein(n int) *bluestein {
+	m := smoothLength(2*n - 1)
+	b := &bluestein{
+		n:      n,
+		chirp:  make([]complex128, n),
+		kernel: make([]complex128, m),
+		work:   make([]complex128, m),
+		plan:   newDFTPlan(m),
+	}
+	for j := range b.chirp {
+		// Reducing j² modulo 2n keeps the angle, and its rounding error, small.
+		s, c := math.Sincos(-math.Pi * float64(j*j%(2*n)) / float64(n))
+		b.chirp[j] = complex(c, s)
+	}
+	scale := complex(1/float64(m), 0)
+	b.plan.load(func(j int) complex128 {
+		switch {
+		case j < n:
+			return cmplx.Conj(b.chirp[j]) * scale
+		case j > m-n:
+			return cmplx.Conj(b.chirp[m-j]) * scale
+		}
+		return 0
+	})
+	b.plan.transform()
+	copy(b.kernel, b.plan.data)
+	return b
+}
+
+// bluesteinBytes returns the memory held by the Bluestein transform of length n.
+func bluesteinBytes(n int) int64 {
+	m := smoothLength(2*n - 1)
+	return 16*int64(n+2*m) + dftPlanBytes(m)
+}
+
+// butterfly is dftPlan.butterfly for radix n. The inverse transform of the
+// convolution is computed as conj(DFT(conj(.))).
+func (b *bluestein) butterfly(p *dftPlan, x []complex128, start, m, tw int) {
+	b.plan.load(func(j int) complex128 {
+		if j >= b.n {
+			return 0
+		}
+		return x[start+j*m] * p.root(j*tw) * b.chirp[j]
+	})
+	b.plan.transform()
+	for k, v := range b.plan.data {
+		b.work[k] = cmplx.Conj(v * b.kernel[k])
+	}
+	b.plan.load(func(j int) complex128 { return b.work[j] })
+	b.plan.transform()
+	for r, c := range b.chirp {
+		x[start+r*m] = cmplx.Conj(b.plan.data[r]) * c
+	}
 }
@@ -1,7 +1,12 @@
 package nist
 
 import (
+	"math"
+	"math/cmplx"
+	"sync"
 	"testing"
+
+	"gonum.org/v1/gonum/dsp/fourier"
 )
 
 func TestDiscreteFourierTransform(t *testing.T) {
@@ -19,3 +24,164 @@ func TestDiscreteFourierTransform(t *testing.T) {
 		}
 	})
 }
+
+// dftTestGonum is the original full-length real FFT implementation, kept as the
+// reference for the half-length transform.
+func dftTestGonum(bitstream []byte) (float64, bool) {
+	n := len(bitstream) * 8
+	series := make([]float64, n)
+	for i := 0; i < n; i++ {
+		series[i] = pm1(bitstream, i)
+	}
+
+	coeffs := fourier.NewFFT(n).Coefficients(nil, series)
+
+	upperBound := math.Sqrt(2.995732274 * float64(n))
+	count := 0
+	for i := 0; i < n/2; i++ {
+		if cmplx.Abs(coeffs[i]) < upperBound {
+			count++
+		}
+	}
+
+	d := (float64(count) - 0.95*float64(n)/2.0) / math.Sqrt(float64(n)/4.0*0.95*0.05)
+	pValue := math.Erfc(math.Abs(d) / math.Sqrt2)
+	return pValue, pValue >= Alpha
+}
+
+func TestDFTPlanMatchesNaiveDFT(t *testing.T) {
+	// Powers of two, lengths with radix 3, 5, 7 and large prime factors, which take the
+	// Bluestein path from bluesteinMinRadix on.
+	for _, size := range []int{1, 2, 4, 8, 12, 20, 28, 30, 36, 44, 60, 61, 105, 4 * 97, 67 * 67, 71 * 73, 4099, 1024} {
+		p := newDFTPlan(size)
+		in := make([]complex128, size)
+		for j := range in {
+			in[j] = complex(math.Sin(float64(j*j+1)), math.Cos(float64(3*j)))
+		}
+		p.load(func(j int) complex128 { return in[j] })
+		p.transform()
+
+		roots := make([]complex128, size)
+		for t := range roots {
+			roots[t] = cmplx.Exp(complex(0, -2*math.Pi*float64(t)/float64(size)))
+		}
+		for k := 0; k < size; k++ {
+			var want complex128
+			for j, v := range in {
+				want += v * roots[j*k%size]
+			}
+			if got := p.data[k]; cmplx.Abs(got-want) > 1e-9*float64(size) {
+				t.Fatalf("size %d, k=%d: got %v, want %v", size, k, got, want)
+			}
+		}
+	}
+}
+
+func TestRealSpectrumMatchesGonum(t *testing.T) {
+	for _, nBytes := range []int{1, 3, 125, 1000, 12345, 4099} {
+		data := pseudoRandomBytes(nBytes, uint64(nBytes))
+		n := nBytes * 8
+		series := make([]float64, n)
+		for i := range series {
+			series[i] = pm1(data, i)
+		}
+		coeffs := fourier.NewFFT(n).Coefficients(nil, series)
+
+		calls := 0
+		realSpectrum(data, func(k int, mag float64) {
+			calls++
+			if want := cmplx.Abs(coeffs[k]); math.Abs(mag-want) > 1e-9*math.Sqrt(float64(n)) {
+				t.Fatalf("n=%d, k=%d: got %v, want %v", n, k, mag, want)
+			}
+		})
+		if calls != n/2 {
+			t.Fatalf("n=%d: expected %d magnitudes, got %d", n, n/2, calls)
+		}
+	}
+}
+
+func TestDiscreteFourierTransformMatchesGonum(t *testing.T) {
+	for _, nBytes := range []int{125, 1000, 125000} {
+		data := pseudoRandomBytes(nBytes, 99)
+		got, gotPass := DiscreteFourierTransformTest(data)
+		want, wantPass := dftTestGonum(data)
+		if got != want || gotPass != wantPass {
+			t.Fatalf("n=%d: got p=%v, gonum p=%v", nBytes*8, got, want)
+		}
+	}
+}
+
+func TestDiscreteFourierTransformConcurrent(t *testing.T) {
+	data := pseudoRandomBytes(4000, 5)
+	want, _ := DiscreteFourierTransformTest(data)
+
+	var wg sync.WaitGroup
+	errs := make(chan float64, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if p, _ := DiscreteFourierTransformTest(data); p != want {
+				errs <- p
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for p := range errs {
+		t.Errorf("concurrent run gave p=%v, want %v", p, want)
+	}
+}
+
+func TestDFTMemoryBytes(t *testing.T) {
+	if got := DFTMemoryBytes(MaxBits); got < 16*MaxBits || got > 16*MaxBits+1024 {
+		t.Errorf("DFTMemoryBytes(MaxBits) = %d, want about %d", got, 16*MaxBits)
+	}
+
+	// The estimate covers every buffer of the plan, including the Bluestein transforms.
+	for _, nBytes := range []int{125, 1000, 4099, 48017, 67 * 71} {
+		bits := nBytes * 8
+		if got, want := DFTMemoryBytes(bits), planBytes(newDFTPlan(bits/2)); got != want {
+			t.Errorf("%d bits: DFTMemoryBytes = %d, plan holds %d", bits, got, want)
+		}
+		if got := DFTMemoryBytes(bits); got > 40*int64(bits) {
+			t.Errorf("%d bits: DFTMemoryBytes = %d, more than 40 bytes per bit", bits, got)
+		}
+	}
+}
+
+// planBytes sums the buffers allocated by a plan.
+func planBytes(p *dftPlan) int64 {
+	bytes := 16 * int64(len(p.data)+len(p.twiddle)+len(p.scratch))
+	for _, b := range p.bluestein {
+		bytes += 16*int64(len(b.chirp)+len(b.kernel)+len(b.work)) + planBytes(b.plan)
+	}
+	return bytes
+}
+
+func TestDiscreteFourierTransformPrimeLength(t *testing.T) {
+	// 48017 bytes give a half-length transform of 4 * 48017 points. With the direct
+	// butterfly this took minutes; the gonum reference is too slow to compare here.
+	data := pseudoRandomBytes(48017, 7)
+	calls := 0
+	realSpectrum(data, func(k int, mag float64) {
+		calls++
+		if math.IsNaN(mag) || mag < 0 || mag > float64(len(data)*8) {
+			t.Fatalf("k=%d: magnitude %v out of range", k, mag)
+		}
+	})
+	if calls != len(data)*4 {
+		t.Fatalf("expected %d magnitudes, got %d", len(data)*4, calls)
+	}
+	if p, _ := DiscreteFourierTransformTest(data); p <= 0 || p > 1 {
+		t.Fatalf("p-value out of range: %v", p)
+	}
+}
+
+func TestSmoothLength(t *testing.T) {
+	for n, want := range map[int]int{1: 1, 7: 8, 11: 12, 31: 32, 97: 100, 193: 200, 8197: 8640} {
+		if got := smoothLength(n); got != want {
+			t.Errorf("smoothLength(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
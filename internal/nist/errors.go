@@ -0,0 +1,31 @@
+package nist
+
+import "fmt"
+
+// ParameterError reports a test parameter outside the range accepted by SP 800-22.
+type ParameterError struct {
+	Test  string
+	Param string
+	Value int
+	Min   int
+	Max   int // 0 means unbounded
+}
+
+func (e *ParameterError) Error() string {
+	if e.Max == 0 {
+		return fmt.Sprintf("%s: parameter %s out of range: %d (must be at least %d)", e.Test, e.Param, e.Value, e.Min)
+	}
+	return fmt.Sprintf("%s: parameter %s out of range: %d (must be %d-%d)", e.Test, e.Param, e.Value, e.Min, e.Max)
+}
+
+// InsufficientBitsError reports a sequence that is too short for the chosen parameters.
+type InsufficientBitsError struct {
+	Test   string
+	Bits   int
+	Need   int
+	Reason string
+}
+
+func (e *InsufficientBitsError) Error() string {
+	return fmt.Sprintf("%s: insufficient bits: got %d, need at least %d (%s)", e.Test, e.Bits, e.Need, e.Reason)
+}
@@ -8,9 +8,13 @@ import (
 // FrequencyTest implements the NIST Monobit (Frequency) test.
 // It returns the p-value and whether it passes at Alpha.
 func FrequencyTest(bitstream []byte) (float64, bool) {
+	return minPValue(frequencyTails(bitstream))
+}
+
+func frequencyTails(bitstream []byte) []Tail {
 	n := len(bitstream) * 8
 	if n == 0 {
-		return 0, false
+		return nil
 	}
 
 	ones := 0
@@ -20,7 +24,5 @@ func FrequencyTest(bitstream []byte) (float64, bool) {
 
 	sum := float64(2*ones - n)
 	sObs := math.Abs(sum) / math.Sqrt(float64(n))
-	pValue := math.Erfc(sObs / math.Sqrt2)
-
-	return pValue, pValue >= Alpha
+	return []Tail{{Kind: ErfcTail, X: sObs / math.Sqrt2}}
 }
@@ -1,100 +1,282 @@
 package nist
 
 import (
+	"fmt"
 	"math"
+	"math/bits"
+	"runtime"
+	"sync"
+)
 
-	"gonum.org/v1/gonum/mathext"
+const (
+	// LinearComplexityMinBlockLength and LinearComplexityMaxBlockLength bound the block
+	// length M accepted by the reference implementation.
+	LinearComplexityMinBlockLength = 500
+	LinearComplexityMaxBlockLength = 5000
+	// LinearComplexityMinBlocks is the minimum number of blocks N.
+	LinearComplexityMinBlocks = 200
+	// LinearComplexityClasses is the number of classes K+1 of the chi-square statistic.
+	LinearComplexityClasses = 7
 )
 
-// LinearComplexityTest implements the NIST Linear Complexity test.
-// It returns the p-value and whether it passes at Alpha.
-func LinearComplexityTest(bitstream []byte, M int) (float64, bool) {
-	bits := expandBits(bitstream)
-	n := len(bits)
+// LinearComplexityReferencePi holds the rounded class probabilities hard-coded in the
+// NIST reference implementation, which produced the published Appendix B p-values.
+var LinearComplexityReferencePi = [LinearComplexityClasses]float64{0.01047, 0.03125, 0.125, 0.5, 0.25, 0.0625, 0.020833}
+
+// LinearComplexityResult holds the outcome of the Linear Complexity test.
+type LinearComplexityResult struct {
+	PValue    float64
+	Passed    bool
+	Tail      Tail
+	Blocks    int
+	Nu        [LinearComplexityClasses]int     // observed class counts ν_0..ν_6
+	Pi        [LinearComplexityClasses]float64 // class probabilities π_0..π_6
+	ChiSquare float64
+}
 
-	N := n / M
-	if N == 0 {
+// LinearComplexityTest implements the NIST Linear Complexity test with the exact class
+// probabilities for M. It returns the p-value and whether it passes at Alpha; invalid
+// parameters or too short sequences yield (0, false).
+func LinearComplexityTest(bitstream []byte, M int) (float64, bool) {
+	res, err := LinearComplexity(bitstream, M)
+	if err != nil {
 		return 0, false
 	}
+	return res.PValue, res.Passed
+}
 
-	K := 6
-	pi := []float64{0.01047, 0.03125, 0.125, 0.5, 0.25, 0.0625, 0.020833}
-	nu := make([]float64, K+1)
-
-	for ii := 0; ii < N; ii++ {
-		C := make([]uint8, M)
-		B := make([]uint8, M)
-		T := make([]uint8, M)
-		P := make([]uint8, M)
-		L := 0
-		m := -1
-		d := uint8(0)
-		C[0] = 1
-		B[0] = 1
-
-		N_ := 0
-		for N_ < M {
-			d = bits[ii*M+N_]
-			for i := 1; i <= L; i++ {
-				d ^= C[i] & bits[ii*M+N_-i]
-			}
-			if d == 1 {
-				copy(T, C)
-				for j := 0; j < M; j++ {
-					if B[j] == 1 {
-						P[j+N_-m] = 1
-					}
-				}
-				for i := 0; i < M; i++ {
-					C[i] ^= P[i]
-					P[i] = 0
-				}
-				if L <= N_/2 {
-					L = N_ + 1 - L
-					m = N_
-					copy(B, T)
-				}
-			}
-			N_++
-		}
+// LinearComplexity runs the Linear Complexity test with the exact class probabilities
+// for M. It returns a *ParameterError if M is outside 500-5000 and an
+// *InsufficientBitsError if the sequence holds fewer than 200 blocks.
+func LinearComplexity(bitstream []byte, M int) (*LinearComplexityResult, error) {
+	if err := checkLinearComplexity(len(bitstream)*8, M); err != nil {
+		return nil, err
+	}
+	return linearComplexityWith(bitstream, M, LinearComplexityPi(M)), nil
+}
+
+// LinearComplexityReference runs the Linear Complexity test with the rounded class
+// probabilities of the NIST reference implementation. It reproduces the Appendix B
+// p-values and is meant for comparisons with the NIST C suite.
+func LinearComplexityReference(bitstream []byte, M int) (*LinearComplexityResult, error) {
+	if err := checkLinearComplexity(len(bitstream)*8, M); err != nil {
+		return nil, err
+	}
+	return linearComplexityWith(bitstream, M, LinearComplexityReferencePi), nil
+}
+
+// LinearComplexityPi returns the exact probabilities π_0..π_6 of the classes of
+// T = (-1)^M (L - μ) + 2/9 for a random M-bit block. A block has linear complexity L
+// with probability 2^-M for L = 0 and 2^(min(2L-1, 2(M-L)) - M) otherwise.
+func LinearComplexityPi(M int) [LinearComplexityClasses]float64 {
+	var pi [LinearComplexityClasses]float64
+	if M <= 0 {
+		return pi
+	}
 
-		sign := 1.0
-		if (M+1)%2 == 0 {
-			sign = -1.0
+	mean, sign := linearComplexityMean(M)
+	for L := 0; L <= M; L++ {
+		exp := 0
+		if L > 0 {
+			exp = min(2*L-1, 2*(M-L))
 		}
-		mean := float64(M)/2.0 + (9.0+sign)/36.0 - (float64(M)/3.0+2.0/9.0)/math.Pow(2, float64(M))
-		if M%2 == 0 {
-			sign = 1.0
-		} else {
-			sign = -1.0
+		pi[linearComplexityClass(sign*(float64(L)-mean)+2.0/9.0)] += math.Ldexp(1, exp-M)
+	}
+	return pi
+}
+
+// checkLinearComplexity validates the block length and the number of blocks.
+func checkLinearComplexity(n, M int) error {
+	if M < LinearComplexityMinBlockLength || M > LinearComplexityMaxBlockLength {
+		return &ParameterError{
+			Test:  "linear_complexity",
+			Param: "block_length",
+			Value: M,
+			Min:   LinearComplexityMinBlockLength,
+			Max:   LinearComplexityMaxBlockLength,
 		}
-		Tval := sign*(float64(L)-mean) + 2.0/9.0
-
-		switch {
-		case Tval <= -2.5:
-			nu[0]++
-		case Tval <= -1.5:
-			nu[1]++
-		case Tval <= -0.5:
-			nu[2]++
-		case Tval <= 0.5:
-			nu[3]++
-		case Tval <= 1.5:
-			nu[4]++
-		case Tval <= 2.5:
-			nu[5]++
-		default:
-			nu[6]++
+	}
+	if n/M < LinearComplexityMinBlocks {
+		return &InsufficientBitsError{
+			Test:   "linear_complexity",
+			Bits:   n,
+			Need:   LinearComplexityMinBlocks * M,
+			Reason: fmt.Sprintf("%d blocks of %d bits", LinearComplexityMinBlocks, M),
 		}
 	}
+	return nil
+}
+
+// linearComplexityWith computes the test statistic against the class probabilities pi.
+func linearComplexityWith(bitstream []byte, M int, pi [LinearComplexityClasses]float64) *LinearComplexityResult {
+	N := len(bitstream) * 8 / M
+	res := &LinearComplexityResult{Blocks: N, Pi: pi}
+
+	mean, sign := linearComplexityMean(M)
+	for _, L := range blockLinearComplexities(bitstream, M, N) {
+		res.Nu[linearComplexityClass(sign*(float64(L)-mean)+2.0/9.0)]++
+	}
 
-	chi2 := 0.0
-	for i := 0; i < K+1; i++ {
+	for i := range res.Nu {
 		expected := float64(N) * pi[i]
-		diff := nu[i] - expected
-		chi2 += diff * diff / expected
+		diff := float64(res.Nu[i]) - expected
+		res.ChiSquare += diff * diff / expected
 	}
 
-	pValue := mathext.GammaIncRegComp(float64(K)/2.0, chi2/2.0)
-	return pValue, pValue >= Alpha
+	K := LinearComplexityClasses - 1
+	res.Tail = Tail{Kind: GammaTail, A: float64(K) / 2.0, X: res.ChiSquare / 2.0}
+	res.PValue = res.Tail.PValue()
+	res.Passed = res.PValue >= Alpha
+	return res
+}
+
+// linearComplexityMean returns the expected linear complexity μ of a random M-bit block
+// and the sign (-1)^M of the statistic T.
+func linearComplexityMean(M int) (mean, sign float64) {
+	sign = 1.0
+	if (M+1)%2 == 0 {
+		sign = -1.0
+	}
+	mean = float64(M)/2.0 + (9.0+sign)/36.0 - (float64(M)/3.0+2.0/9.0)/math.Pow(2, float64(M))
+	if M%2 == 0 {
+		sign = 1.0
+	} else {
+		sign = -1.0
+	}
+	return mean, sign
+}
+
+// linearComplexityClass returns the class index 0..6 of the statistic T.
+func linearComplexityClass(T float64) int {
+	switch {
+	case T <= -2.5:
+		return 0
+	case T <= -1.5:
+		return 1
+	case T <= -0.5:
+		return 2
+	case T <= 0.5:
+		return 3
+	case T <= 1.5:
+		return 4
+	case T <= 2.5:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// blockLinearComplexities returns the linear complexity of each of the N consecutive
+// M-bit blocks. The blocks are independent and are distributed over GOMAXPROCS workers,
+// each reusing its own Berlekamp-Massey buffers.
+func blockLinearComplexities(bitstream []byte, M, N int) []int {
+	ls := make([]int, N)
+
+	workers := min(runtime.GOMAXPROCS(0), N)
+	if workers <= 1 {
+		s := newBMScratch(M)
+		for i := range ls {
+			ls[i] = s.linearComplexity(bitstream, i*M)
+		}
+		return ls
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			s := newBMScratch(M)
+			for i := w; i < N; i += workers {
+				ls[i] = s.linearComplexity(bitstream, i*M)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return ls
+}
+
+// bmScratch holds the packed buffers of the Berlekamp-Massey algorithm for one block
+// length. Polynomials store coefficient i in bit i%64 of word i/64.
+type bmScratch struct {
+	m   int
+	rev []uint64 // block bits reversed: bit j holds s_{m-1-j}; one word of zero padding
+	c   []uint64 // connection polynomial C(x)
+	b   []uint64 // copy of C(x) before the last length change
+	t   []uint64 // temporary copy of C(x)
+}
+
+func newBMScratch(m int) *bmScratch {
+	words := m/64 + 1
+	return &bmScratch{
+		m:   m,
+		rev: make([]uint64, words+1),
+		c:   make([]uint64, words),
+		b:   make([]uint64, words),
+		t:   make([]uint64, words),
+	}
+}
+
+// linearComplexity returns the length of the shortest LFSR generating the m bits of
+// bitstream starting at bit offset start.
+func (s *bmScratch) linearComplexity(bitstream []byte, start int) int {
+	m := s.m
+	clear(s.rev)
+	for j := 0; j < m; j++ {
+		if bitAt(bitstream, start+m-1-j) == 1 {
+			s.rev[j>>6] |= 1 << (j & 63)
+		}
+	}
+
+	clear(s.c)
+	clear(s.b)
+	s.c[0], s.b[0] = 1, 1
+
+	L, last := 0, -1
+	for n := 0; n < m; n++ {
+		// d = sum_{i=0..L} c_i s_{n-i}; s_{n-i} is bit (m-1-n)+i of rev.
+		off := m - 1 - n
+		w, sh := off>>6, uint(off&63)
+		var acc uint64
+		for k := 0; k <= L>>6; k++ {
+			word := s.rev[w+k] >> sh
+			if sh != 0 {
+				word |= s.rev[w+k+1] << (64 - sh)
+			}
+			acc ^= s.c[k] & word
+		}
+		if bits.OnesCount64(acc)&1 == 0 {
+			continue
+		}
+
+		// C(x) = C(x) + x^(n-last) B(x), keeping the previous C(x) if the length changes.
+		grow := L <= n/2
+		if grow {
+			copy(s.t, s.c)
+		}
+		xorShifted(s.c, s.b, n-last)
+		if grow {
+			L = n + 1 - L
+			last = n
+			s.b, s.t = s.t, s.b
+		}
+	}
+	return L
+}
+
+// xorShifted sets dst ^= src << shift, discarding bits beyond the length of dst.
+func xorShifted(dst, src []uint64, shift int) {
+	w, sh := shift>>6, uint(shift&63)
+	if sh == 0 {
+		for k := 0; k+w < len(dst); k++ {
+			dst[k+w] ^= src[k]
+		}
+		return
+	}
+	var carry uint64
+	for k := 0; k+w < len(dst); k++ {
+		dst[k+w] ^= src[k]<<sh | carry
+		carry = src[k] >> (64 - sh)
+	}
 }
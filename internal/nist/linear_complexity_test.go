@@ -1,7 +1,12 @@
 package nist
 
 import (
+	"errors"
+	"math"
+	"runtime"
 	"testing"
+
+	"gonum.org/v1/gonum/mathext"
 )
 
 func TestLinearComplexity(t *testing.T) {
@@ -14,7 +19,7 @@ func TestLinearComplexity(t *testing.T) {
 	})
 
 	t.Run("valid_input", func(t *testing.T) {
-		data := make([]byte, 10000)
+		data := make([]byte, 25000)
 		for i := range data {
 			data[i] = byte(i % 256)
 		}
@@ -24,3 +29,262 @@ func TestLinearComplexity(t *testing.T) {
 		}
 	})
 }
+
+// linearComplexityBitwise is the original bit-by-bit Berlekamp-Massey implementation,
+// kept as the reference for the packed version. P is oversized so that tiny blocks do
+// not index past the end; coefficients beyond M never affect the result.
+func linearComplexityBitwise(bits []uint8) int {
+	M := len(bits)
+	C := make([]uint8, M)
+	B := make([]uint8, M)
+	T := make([]uint8, M)
+	P := make([]uint8, 2*M+1)
+	L := 0
+	m := -1
+	C[0] = 1
+	B[0] = 1
+
+	for N_ := 0; N_ < M; N_++ {
+		d := bits[N_]
+		for i := 1; i <= L; i++ {
+			d ^= C[i] & bits[N_-i]
+		}
+		if d == 1 {
+			copy(T, C)
+			for j := 0; j < M; j++ {
+				if B[j] == 1 {
+					P[j+N_-m] = 1
+				}
+			}
+			for i := 0; i < M; i++ {
+				C[i] ^= P[i]
+			}
+			clear(P)
+			if L <= N_/2 {
+				L = N_ + 1 - L
+				m = N_
+				copy(B, T)
+			}
+		}
+	}
+	return L
+}
+
+// linearComplexityTestBitwise is the original LinearComplexityTest, with the rounded
+// class probabilities, on top of the bitwise Berlekamp-Massey implementation.
+func linearComplexityTestBitwise(bitstream []byte, M int) (float64, bool) {
+	bits := expandBits(bitstream)
+	N := len(bits) / M
+
+	K := 6
+	pi := []float64{0.01047, 0.03125, 0.125, 0.5, 0.25, 0.0625, 0.020833}
+	nu := make([]float64, K+1)
+
+	for ii := 0; ii < N; ii++ {
+		L := linearComplexityBitwise(bits[ii*M : (ii+1)*M])
+
+		sign := 1.0
+		if (M+1)%2 == 0 {
+			sign = -1.0
+		}
+		mean := float64(M)/2.0 + (9.0+sign)/36.0 - (float64(M)/3.0+2.0/9.0)/math.Pow(2, float64(M))
+		if M%2 == 0 {
+			sign = 1.0
+		} else {
+			sign = -1.0
+		}
+		Tval := sign*(float64(L)-mean) + 2.0/9.0
+
+		switch {
+		case Tval <= -2.5:
+			nu[0]++
+		case Tval <= -1.5:
+			nu[1]++
+		case Tval <= -0.5:
+			nu[2]++
+		case Tval <= 0.5:
+			nu[3]++
+		case Tval <= 1.5:
+			nu[4]++
+		case Tval <= 2.5:
+			nu[5]++
+		default:
+			nu[6]++
+		}
+	}
+
+	chi2 := 0.0
+	for i := 0; i < K+1; i++ {
+		expected := float64(N) * pi[i]
+		diff := nu[i] - expected
+		chi2 += diff * diff / expected
+	}
+
+	pValue := mathext.GammaIncRegComp(float64(K)/2.0, chi2/2.0)
+	return pValue, pValue >= Alpha
+}
+
+func pseudoRandomBytes(n int, seed uint64) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		out[i] = byte(seed >> 32)
+	}
+	return out
+}
+
+func TestPackedBerlekampMasseyMatchesBitwise(t *testing.T) {
+	for _, M := range []int{1, 2, 63, 64, 65, 127, 128, 500, 1000, 1031, 5000} {
+		data := pseudoRandomBytes((4*M+7)/8+1, uint64(M)*2654435761+1)
+		bits := expandBits(data)
+		s := newBMScratch(M)
+
+		for blk := 0; (blk+1)*M <= len(bits) && blk < 4; blk++ {
+			want := linearComplexityBitwise(bits[blk*M : (blk+1)*M])
+			if got := s.linearComplexity(data, blk*M); got != want {
+				t.Fatalf("M=%d block %d: got L=%d, want %d", M, blk, got, want)
+			}
+		}
+	}
+}
+
+func TestPackedBerlekampMasseySpecialSequences(t *testing.T) {
+	const M = 200
+	patterns := map[string]func(i int) uint8{
+		"zeros":     func(i int) uint8 { return 0 },
+		"ones":      func(i int) uint8 { return 1 },
+		"last_one":  func(i int) uint8 { return boolBit(i == M-1) },
+		"first_one": func(i int) uint8 { return boolBit(i == 0) },
+		"alternate": func(i int) uint8 { return uint8(i & 1) },
+		"period_7":  func(i int) uint8 { return boolBit(i%7 < 3) },
+	}
+
+	for name, f := range patterns {
+		t.Run(name, func(t *testing.T) {
+			bits := make([]uint8, M)
+			data := make([]byte, M/8)
+			for i := range bits {
+				bits[i] = f(i)
+				data[i/8] |= bits[i] << (7 - i%8)
+			}
+			want := linearComplexityBitwise(bits)
+			if got := newBMScratch(M).linearComplexity(data, 0); got != want {
+				t.Fatalf("got L=%d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func boolBit(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestLinearComplexityPValueBitExact(t *testing.T) {
+	data := pseudoRandomBytes(125000, 42)
+	for _, M := range []int{500, 1000} {
+		res, err := LinearComplexityReference(data, M)
+		if err != nil {
+			t.Fatalf("M=%d: %v", M, err)
+		}
+		want, wantPass := linearComplexityTestBitwise(data, M)
+		if res.PValue != want || res.Passed != wantPass {
+			t.Fatalf("M=%d: packed p=%v, bitwise p=%v", M, res.PValue, want)
+		}
+	}
+}
+
+func TestLinearComplexityParallelDeterministic(t *testing.T) {
+	data := pseudoRandomBytes(62500, 7)
+
+	prev := runtime.GOMAXPROCS(1)
+	serial := blockLinearComplexities(data, 500, 1000)
+	runtime.GOMAXPROCS(4)
+	parallel := blockLinearComplexities(data, 500, 1000)
+	runtime.GOMAXPROCS(prev)
+
+	for i := range serial {
+		if serial[i] != parallel[i] {
+			t.Fatalf("block %d: serial L=%d, parallel L=%d", i, serial[i], parallel[i])
+		}
+	}
+}
+
+func TestLinearComplexityPiExact(t *testing.T) {
+	// Enumerate every block of a small length and classify it with the bitwise reference.
+	for _, M := range []int{9, 10, 13} {
+		var counts [LinearComplexityClasses]float64
+		mean, sign := linearComplexityMean(M)
+		bits := make([]uint8, M)
+		for v := 0; v < 1<<M; v++ {
+			for i := range bits {
+				bits[i] = uint8(v >> i & 1)
+			}
+			L := linearComplexityBitwise(bits)
+			counts[linearComplexityClass(sign*(float64(L)-mean)+2.0/9.0)]++
+		}
+
+		pi := LinearComplexityPi(M)
+		for i := range pi {
+			if want := counts[i] / float64(int(1)<<M); pi[i] != want {
+				t.Fatalf("M=%d: pi[%d]=%v, want %v", M, i, pi[i], want)
+			}
+		}
+	}
+
+	// For large M the probabilities approach 1/96, 1/32, 1/8, 1/2, 1/4, 1/16 and 1/48.
+	limit := []float64{1.0 / 96, 1.0 / 32, 1.0 / 8, 1.0 / 2, 1.0 / 4, 1.0 / 16, 1.0 / 48}
+	for _, M := range []int{500, 501, 5000} {
+		pi := LinearComplexityPi(M)
+		sum := 0.0
+		for i := range pi {
+			sum += pi[i]
+			if math.Abs(pi[i]-limit[i]) > 1e-12 {
+				t.Errorf("M=%d: pi[%d]=%v, want %v", M, i, pi[i], limit[i])
+			}
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Errorf("M=%d: probabilities sum to %v", M, sum)
+		}
+	}
+}
+
+func TestLinearComplexityValidation(t *testing.T) {
+	data := make([]byte, 12500) // 100000 bits
+
+	for _, M := range []int{0, 499, 5001} {
+		_, err := LinearComplexity(data, M)
+		var perr *ParameterError
+		if !errors.As(err, &perr) {
+			t.Fatalf("M=%d: expected *ParameterError, got %v", M, err)
+		}
+		if perr.Value != M || perr.Param != "block_length" {
+			t.Errorf("M=%d: unexpected error fields %+v", M, perr)
+		}
+	}
+
+	_, err := LinearComplexity(data, 501)
+	var short *InsufficientBitsError
+	if !errors.As(err, &short) {
+		t.Fatalf("expected *InsufficientBitsError, got %v", err)
+	}
+	if short.Bits != 100000 || short.Need != 200*501 {
+		t.Errorf("unexpected error fields %+v", short)
+	}
+
+	res, err := LinearComplexity(data, 500)
+	if err != nil {
+		t.Fatalf("200 blocks rejected: %v", err)
+	}
+	total := 0
+	for _, nu := range res.Nu {
+		total += nu
+	}
+	if res.Blocks != 200 || total != 200 {
+		t.Errorf("expected 200 classified blocks, got N=%d, sum(nu)=%d", res.Blocks, total)
+	}
+}
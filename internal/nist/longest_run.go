@@ -1,18 +1,18 @@
 package nist
 
-import (
-	"math"
-
-	"gonum.org/v1/gonum/mathext"
-)
+import "math"
 
 // LongestRunOfOnesTest implements the NIST Longest Run of Ones test.
 // It returns the p-value and whether it passes at Alpha.
 func LongestRunOfOnesTest(bitstream []byte) (float64, bool) {
+	return minPValue(longestRunOfOnesTails(bitstream))
+}
+
+func longestRunOfOnesTails(bitstream []byte) []Tail {
 	bits := expandBits(bitstream)
 	n := len(bits)
 	if n < 128 {
-		return 0, false
+		return nil
 	}
 
 	var K, M int
@@ -36,7 +36,7 @@ func LongestRunOfOnesTest(bitstream []byte) (float64, bool) {
 
 	N := n / M
 	if N == 0 {
-		return 0, false
+		return nil
 	}
 
 	nu := make([]float64, K+1)
@@ -75,7 +75,5 @@ func LongestRunOfOnesTest(bitstream []byte) (float64, bool) {
 		chiSquared += math.Pow(nu[i]-float64(N)*pi[i], 2) / (float64(N) * pi[i])
 	}
 
-	pValue := mathext.GammaIncRegComp(float64(K)/2.0, chiSquared/2.0)
-
-	return pValue, pValue >= Alpha
+	return []Tail{{Kind: GammaTail, A: float64(K) / 2.0, X: chiSquared / 2.0}}
 }
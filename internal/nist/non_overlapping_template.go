@@ -1,22 +1,29 @@
 package nist
 
-import (
-	"math"
-
-	"gonum.org/v1/gonum/mathext"
-)
+import "math"
 
 // NonOverlappingTemplateTest implements the NIST Non-overlapping Template Matching test for m=9.
 // It returns the minimum p-value across all templates and whether it passes at Alpha.
 func NonOverlappingTemplateTest(bitstream []byte, m int) (float64, bool) {
+	return minPValue(nonOverlappingTemplateTails(bitstream, m))
+}
+
+// NonOverlappingTemplatePValues returns one p-value per aperiodic template for m=9,
+// in the order of the NIST template file (the first entry is 000000001).
+// It returns nil if the parameters are not supported or the input is too short.
+func NonOverlappingTemplatePValues(bitstream []byte, m int) []float64 {
+	return pValues(nonOverlappingTemplateTails(bitstream, m))
+}
+
+func nonOverlappingTemplateTails(bitstream []byte, m int) []Tail {
 	if m != 9 {
-		return 0, false
+		return nil
 	}
 
 	bits := expandBits(bitstream)
 	n := len(bits)
 	if n < m {
-		return 0, false
+		return nil
 	}
 
 	const (
@@ -26,7 +33,7 @@ func NonOverlappingTemplateTest(bitstream []byte, m int) (float64, bool) {
 
 	M := n / N
 	if M == 0 {
-		return 0, false
+		return nil
 	}
 
 	lambda := float64(M-m+1) / math.Pow(2, float64(m))
@@ -45,7 +52,7 @@ func NonOverlappingTemplateTest(bitstream []byte, m int) (float64, bool) {
 	}
 	pi[K] = 1 - sum
 
-	minP := 1.0
+	tails := make([]Tail, len(template9))
 	for t := 0; t < len(template9); t++ {
 		Wj := make([]int, N)
 		for block := 0; block < N; block++ {
@@ -72,13 +79,10 @@ func NonOverlappingTemplateTest(bitstream []byte, m int) (float64, bool) {
 			chi2 += diff * diff
 		}
 
-		p := mathext.GammaIncRegComp(float64(N)/2.0, chi2/2.0)
-		if p < minP {
-			minP = p
-		}
+		tails[t] = Tail{Kind: GammaTail, A: float64(N) / 2.0, X: chi2 / 2.0}
 	}
 
-	return minP, minP >= Alpha
+	return tails
 }
 
 func logGamma(x float64) float64 {
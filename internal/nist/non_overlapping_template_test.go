@@ -28,4 +28,20 @@ func TestNonOverlappingTemplate(t *testing.T) {
 			t.Fatalf("pass flag inconsistent with p-value %.6f", p)
 		}
 	})
+
+	t.Run("one_p_value_per_template", func(t *testing.T) {
+		data := make([]byte, 10000)
+		state := uint64(7)
+		for i := range data {
+			state = state*6364136223846793005 + 1442695040888963407
+			data[i] = byte(state >> 56)
+		}
+		pValues := NonOverlappingTemplatePValues(data, 9)
+		if len(pValues) != len(template9) {
+			t.Fatalf("expected %d p-values, got %d", len(template9), len(pValues))
+		}
+		if NonOverlappingTemplatePValues(data, 10) != nil {
+			t.Fatal("expected nil for unsupported template size")
+		}
+	})
 }
@@ -1,30 +1,73 @@
 package nist
 
 import (
+	"fmt"
 	"math"
+)
 
-	"gonum.org/v1/gonum/mathext"
+const (
+	// OverlappingTemplateMinLength and OverlappingTemplateMaxLength bound the template
+	// length m.
+	OverlappingTemplateMinLength = 2
+	OverlappingTemplateMaxLength = 16
+	// OverlappingTemplateBlockLength and OverlappingTemplateDegreesOfFreedom are the block
+	// length M and the number of degrees of freedom K recommended by SP 800-22.
+	OverlappingTemplateBlockLength      = 1032
+	OverlappingTemplateDegreesOfFreedom = 5
 )
 
-// OverlappingTemplateTest implements the NIST Overlapping Template Matching test.
-// It returns the p-value and whether it passes at Alpha.
+// OverlappingTemplateRev1aPi holds the class probabilities π_0..π_5 that SP 800-22 Rev 1a
+// Section 3.8 gives for the all-ones template with m = 9, M = 1032 and K = 5.
+var OverlappingTemplateRev1aPi = [...]float64{0.364091, 0.185659, 0.139381, 0.100571, 0.070432, 0.139865}
+
+// OverlappingTemplateResult holds the outcome of the Overlapping Template Matching test.
+type OverlappingTemplateResult struct {
+	PValue    float64
+	Passed    bool
+	Tail      Tail
+	Blocks    int
+	Nu        []int     // observed class counts ν_0..ν_K
+	Pi        []float64 // class probabilities π_0..π_K
+	ChiSquare float64
+}
+
+// OverlappingTemplateTest implements the NIST Overlapping Template Matching test for the
+// all-ones template of length m with M = 1032, K = 5 and the exact class probabilities.
+// It returns the p-value and whether it passes at Alpha; invalid parameters or too
+// short sequences yield (0, false).
 func OverlappingTemplateTest(bitstream []byte, m int) (float64, bool) {
-	bits := expandBits(bitstream)
-	n := len(bits)
-	if n < m {
+	res, err := OverlappingTemplate(bitstream, OnesTemplate(m), OverlappingTemplateBlockLength, OverlappingTemplateDegreesOfFreedom)
+	if err != nil {
 		return 0, false
 	}
+	return res.PValue, res.Passed
+}
 
-	const K = 5
-	M := 1032
-	N := n / M
-	if N == 0 {
-		return 0, false
+// OverlappingTemplate runs the Overlapping Template Matching test for template (one bit
+// per element, 0 or 1) with block length M and K degrees of freedom, using the class
+// probabilities computed by OverlappingTemplatePi. It returns a *ParameterError for a
+// template length outside 2-16, template elements other than 0 and 1, a block shorter
+// than the template or K < 1, and an
+// *InsufficientBitsError if the sequence is shorter than one block.
+func OverlappingTemplate(bitstream []byte, template []uint8, M, K int) (*OverlappingTemplateResult, error) {
+	if err := checkOverlappingTemplate(len(bitstream)*8, template, M, K); err != nil {
+		return nil, err
 	}
+	return overlappingTemplateWith(bitstream, template, M, OverlappingTemplatePi(template, M, K)), nil
+}
 
-	lambda := float64(M-m+1) / math.Pow(2, float64(m))
-	eta := lambda / 2.0
+// OverlappingTemplateReference runs the test for the all-ones template of length m with
+// M = 1032, K = 5 and the class probabilities of the approximation used by the original
+// NIST code, which produced the published Appendix B p-values. It is meant for
+// comparisons with the NIST C suite.
+func OverlappingTemplateReference(bitstream []byte, m int) (*OverlappingTemplateResult, error) {
+	const M, K = OverlappingTemplateBlockLength, OverlappingTemplateDegreesOfFreedom
+	template := OnesTemplate(m)
+	if err := checkOverlappingTemplate(len(bitstream)*8, template, M, K); err != nil {
+		return nil, err
+	}
 
+	eta := float64(M-m+1) / math.Pow(2, float64(m)) / 2.0
 	pi := make([]float64, K+1)
 	sum := 0.0
 	for i := 0; i < K; i++ {
@@ -33,38 +76,166 @@ func OverlappingTemplateTest(bitstream []byte, m int) (float64, bool) {
 	}
 	pi[K] = 1 - sum
 
-	nu := make([]int, K+1)
-	for block := 0; block < N; block++ {
-		wObs := 0
-		for j := 0; j < M-m+1; j++ {
-			match := true
-			for k := 0; k < m; k++ {
-				if bits[block*M+j+k] == 0 {
-					match = false
-					break
+	return overlappingTemplateWith(bitstream, template, M, pi), nil
+}
+
+// OnesTemplate returns the all-ones template of length m.
+func OnesTemplate(m int) []uint8 {
+	t := make([]uint8, max(m, 0))
+	for i := range t {
+		t[i] = 1
+	}
+	return t
+}
+
+// OverlappingTemplatePi returns the exact probabilities π_0..π_K that template occurs
+// 0, 1, ..., K-1 and at least K times, overlaps included, in a random M-bit block. The
+// distribution is propagated bit by bit through the string-matching automaton of the
+// template, so it holds for any template and does not rely on the Poisson-type
+// approximation of the original specification.
+func OverlappingTemplatePi(template []uint8, M, K int) []float64 {
+	m := len(template)
+	pi := make([]float64, K+1)
+	if m == 0 || K < 1 {
+		return pi
+	}
+	next, border := templateAutomaton(template)
+
+	// dist[s*(K+1)+c] is the probability of automaton state s (matched prefix length)
+	// after c occurrences, counts capped at K.
+	dist := make([]float64, m*(K+1))
+	tmp := make([]float64, len(dist))
+	dist[0] = 1
+	for step := 0; step < M; step++ {
+		clear(tmp)
+		for s := 0; s < m; s++ {
+			for c := 0; c <= K; c++ {
+				p := dist[s*(K+1)+c]
+				if p == 0 {
+					continue
+				}
+				for b := 0; b < 2; b++ {
+					ns, nc := next[s][b], c
+					if ns == m {
+						ns, nc = border, min(c+1, K)
+					}
+					tmp[ns*(K+1)+nc] += p / 2
 				}
 			}
-			if match {
-				wObs++
+		}
+		dist, tmp = tmp, dist
+	}
+
+	for s := 0; s < m; s++ {
+		for c := 0; c <= K; c++ {
+			pi[c] += dist[s*(K+1)+c]
+		}
+	}
+	return pi
+}
+
+// templateAutomaton returns the transitions of the Knuth-Morris-Pratt automaton of
+// template over states 0..m-1, where state m denotes a match, and the state after a
+// match: the length of the longest proper border of the template.
+func templateAutomaton(template []uint8) (next [][2]int, border int) {
+	m := len(template)
+	fail := make([]int, m+1) // fail[i]: longest proper border of template[:i]
+	for i, k := 1, 0; i < m; i++ {
+		for k > 0 && template[i] != template[k] {
+			k = fail[k]
+		}
+		if template[i] == template[k] {
+			k++
+		}
+		fail[i+1] = k
+	}
+
+	next = make([][2]int, m)
+	for s := 0; s < m; s++ {
+		for b := 0; b < 2; b++ {
+			k := s
+			for k > 0 && template[k] != uint8(b) {
+				k = fail[k]
+			}
+			if template[k] == uint8(b) {
+				k++
 			}
+			next[s][b] = k
 		}
+	}
+	return next, fail[m]
+}
 
-		if wObs <= 4 {
-			nu[wObs]++
-		} else {
-			nu[K]++
+// checkOverlappingTemplate validates the parameters and the number of blocks.
+func checkOverlappingTemplate(n int, template []uint8, M, K int) error {
+	m := len(template)
+	if m < OverlappingTemplateMinLength || m > OverlappingTemplateMaxLength {
+		return &ParameterError{
+			Test:  "overlapping_template",
+			Param: "template_length",
+			Value: m,
+			Min:   OverlappingTemplateMinLength,
+			Max:   OverlappingTemplateMaxLength,
+		}
+	}
+	for _, b := range template {
+		if b > 1 {
+			return &ParameterError{Test: "overlapping_template", Param: "template", Value: int(b), Min: 0, Max: 1}
+		}
+	}
+	if M < m {
+		return &ParameterError{Test: "overlapping_template", Param: "block_length", Value: M, Min: m}
+	}
+	if K < 1 {
+		return &ParameterError{Test: "overlapping_template", Param: "degrees_of_freedom", Value: K, Min: 1}
+	}
+	if n < M {
+		return &InsufficientBitsError{
+			Test:   "overlapping_template",
+			Bits:   n,
+			Need:   M,
+			Reason: fmt.Sprintf("one block of %d bits", M),
+		}
+	}
+	return nil
+}
+
+// overlappingTemplateWith computes the test statistic against the class probabilities
+// pi, whose length is K+1.
+func overlappingTemplateWith(bitstream []byte, template []uint8, M int, pi []float64) *OverlappingTemplateResult {
+	m := len(template)
+	K := len(pi) - 1
+	N := len(bitstream) * 8 / M
+	res := &OverlappingTemplateResult{Blocks: N, Nu: make([]int, K+1), Pi: pi}
+
+	var want uint64
+	for _, b := range template {
+		want = want<<1 | uint64(b&1)
+	}
+	mask := uint64(1)<<m - 1
+
+	for block := 0; block < N; block++ {
+		wObs := 0
+		var window uint64
+		for j := 0; j < M; j++ {
+			window = (window<<1 | uint64(bitAt(bitstream, block*M+j))) & mask
+			if j >= m-1 && window == want {
+				wObs++
+			}
 		}
+		res.Nu[min(wObs, K)]++
 	}
 
-	chi2 := 0.0
-	for i := 0; i < K+1; i++ {
+	for i := range res.Nu {
 		expected := float64(N) * pi[i]
-		diff := float64(nu[i]) - expected
-		chi2 += diff * diff / expected
+		diff := float64(res.Nu[i]) - expected
+		res.ChiSquare += diff * diff / expected
 	}
 
-	pValue := mathext.GammaIncRegComp(float64(K)/2.0, chi2/2.0)
-	return pValue, pValue >= Alpha
+	res.Tail = Tail{Kind: GammaTail, A: float64(K) / 2.0, X: res.ChiSquare / 2.0}
+	res.PValue = res.Tail.PValue()
+	res.Passed = res.PValue >= Alpha
+	return res
 }
 
 func prHelper(u int, eta float64) float64 {
@@ -1,7 +1,11 @@
 package nist
 
 import (
+	"errors"
+	"math"
 	"testing"
+
+	"gonum.org/v1/gonum/mathext"
 )
 
 func TestOverlappingTemplate(t *testing.T) {
@@ -16,3 +20,168 @@ func TestOverlappingTemplate(t *testing.T) {
 		}
 	})
 }
+
+// overlappingTemplateTestBitwise is the original implementation on expanded bits with
+// the approximated class probabilities, kept as the reference for the rolling window.
+func overlappingTemplateTestBitwise(bitstream []byte, m int) (float64, bool) {
+	bits := expandBits(bitstream)
+	n := len(bits)
+
+	const K = 5
+	M := 1032
+	N := n / M
+
+	lambda := float64(M-m+1) / math.Pow(2, float64(m))
+	eta := lambda / 2.0
+
+	pi := make([]float64, K+1)
+	sum := 0.0
+	for i := 0; i < K; i++ {
+		pi[i] = prHelper(i, eta)
+		sum += pi[i]
+	}
+	pi[K] = 1 - sum
+
+	nu := make([]int, K+1)
+	for block := 0; block < N; block++ {
+		wObs := 0
+		for j := 0; j < M-m+1; j++ {
+			match := true
+			for k := 0; k < m; k++ {
+				if bits[block*M+j+k] == 0 {
+					match = false
+					break
+				}
+			}
+			if match {
+				wObs++
+			}
+		}
+
+		if wObs <= 4 {
+			nu[wObs]++
+		} else {
+			nu[K]++
+		}
+	}
+
+	chi2 := 0.0
+	for i := 0; i < K+1; i++ {
+		expected := float64(N) * pi[i]
+		diff := float64(nu[i]) - expected
+		chi2 += diff * diff / expected
+	}
+
+	pValue := mathext.GammaIncRegComp(float64(K)/2.0, chi2/2.0)
+	return pValue, pValue >= Alpha
+}
+
+func TestOverlappingTemplateReferenceMatchesBitwise(t *testing.T) {
+	data := pseudoRandomBytes(125000, 21)
+	for _, m := range []int{2, 9, 10, 16} {
+		res, err := OverlappingTemplateReference(data, m)
+		if err != nil {
+			t.Fatalf("m=%d: %v", m, err)
+		}
+		want, wantPass := overlappingTemplateTestBitwise(data, m)
+		if res.PValue != want || res.Passed != wantPass {
+			t.Fatalf("m=%d: got p=%v, bitwise p=%v", m, res.PValue, want)
+		}
+	}
+}
+
+func TestOverlappingTemplatePiRev1a(t *testing.T) {
+	pi := OverlappingTemplatePi(OnesTemplate(9), OverlappingTemplateBlockLength, OverlappingTemplateDegreesOfFreedom)
+	for i, want := range OverlappingTemplateRev1aPi {
+		if math.Abs(pi[i]-want) > 5e-7 {
+			t.Errorf("pi[%d] = %.7f, Rev 1a gives %.6f", i, pi[i], want)
+		}
+	}
+}
+
+func TestOverlappingTemplatePiExact(t *testing.T) {
+	// Enumerate every block of a small length and count overlapping matches directly.
+	const M, K = 16, 4
+	templates := [][]uint8{{1, 1}, {0, 1}, {1, 0, 1}, {1, 1, 0, 1, 1}, {0, 0, 0, 0}, {1, 0, 0, 1, 0, 0}}
+	for _, tmpl := range templates {
+		m := len(tmpl)
+		counts := make([]float64, K+1)
+		for v := 0; v < 1<<M; v++ {
+			w := 0
+			for j := 0; j+m <= M; j++ {
+				match := true
+				for k := 0; k < m; k++ {
+					if uint8(v>>(M-1-j-k)&1) != tmpl[k] {
+						match = false
+						break
+					}
+				}
+				if match {
+					w++
+				}
+			}
+			counts[min(w, K)]++
+		}
+
+		pi := OverlappingTemplatePi(tmpl, M, K)
+		for i := range pi {
+			if want := counts[i] / (1 << M); math.Abs(pi[i]-want) > 1e-15 {
+				t.Errorf("template %v: pi[%d] = %v, want %v", tmpl, i, pi[i], want)
+			}
+		}
+	}
+}
+
+func TestOverlappingTemplateConfigurable(t *testing.T) {
+	data := pseudoRandomBytes(125000, 22)
+
+	// Count occurrences of 0110 per 100-bit block directly.
+	tmpl := []uint8{0, 1, 1, 0}
+	res, err := OverlappingTemplate(data, tmpl, 100, 3)
+	if err != nil {
+		t.Fatalf("OverlappingTemplate failed: %v", err)
+	}
+	nu := make([]int, 4)
+	for block := 0; block < 10000; block++ {
+		w := 0
+		for j := 0; j+4 <= 100; j++ {
+			if readBits(data, block*100+j, 4) == 0b0110 {
+				w++
+			}
+		}
+		nu[min(w, 3)]++
+	}
+	if res.Blocks != 10000 || len(res.Pi) != 4 {
+		t.Fatalf("unexpected result shape: N=%d, %d classes", res.Blocks, len(res.Pi))
+	}
+	for i := range nu {
+		if res.Nu[i] != nu[i] {
+			t.Errorf("nu[%d] = %d, want %d", i, res.Nu[i], nu[i])
+		}
+	}
+	if res.PValue <= 0 || res.PValue > 1 {
+		t.Errorf("p-value out of range: %v", res.PValue)
+	}
+
+	invalid := []struct {
+		tmpl []uint8
+		M, K int
+	}{
+		{[]uint8{1}, 1032, 5},
+		{OnesTemplate(17), 1032, 5},
+		{[]uint8{1, 2, 1}, 1032, 5},
+		{OnesTemplate(9), 8, 5},
+		{OnesTemplate(9), 1032, 0},
+	}
+	for _, c := range invalid {
+		var perr *ParameterError
+		if _, err := OverlappingTemplate(data, c.tmpl, c.M, c.K); !errors.As(err, &perr) {
+			t.Errorf("template %v M=%d K=%d: expected *ParameterError, got %v", c.tmpl, c.M, c.K, err)
+		}
+	}
+
+	var short *InsufficientBitsError
+	if _, err := OverlappingTemplate(data[:100], OnesTemplate(9), 1032, 5); !errors.As(err, &short) {
+		t.Errorf("expected *InsufficientBitsError, got %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+package nist
+
+import (
+	"math"
+	"math/big"
+)
+
+// bigPrec is the precision in bits of the arbitrary-precision tail functions; the
+// series below add the bits lost to cancellation on top of it.
+const bigPrec = 256
+
+// bigErfcCutoff is the argument beyond which erfc is below the smallest float64.
+const bigErfcCutoff = 27.3
+
+// PrecisePValue evaluates the tail with math/big at 256 bits and rounds the result to
+// float64. It is orders of magnitude slower than PValue and meant for validation runs.
+// Gamma tails require 2A to be an integer, which holds for every SP 800-22 test;
+// other values yield NaN.
+func (t Tail) PrecisePValue() float64 {
+	var p *big.Float
+	switch t.Kind {
+	case ErfcTail:
+		if t.X < 0 {
+			p = bigErfc(bigFloat(-t.X))
+			p.Sub(bigFloat(2), p)
+		} else {
+			p = bigErfc(bigFloat(t.X))
+		}
+	case GammaTail:
+		p = bigGammaQ(t.A, t.X)
+	case ExpTail:
+		p = bigExp(bigFloat(-t.X), bigPrec)
+	case CusumTail:
+		return bigCumulativeSumsPValue(int(t.A), int(t.X))
+	}
+	if p == nil {
+		return math.NaN()
+	}
+	f, _ := p.Float64()
+	return f
+}
+
+func bigFloat(x float64) *big.Float {
+	return new(big.Float).SetPrec(bigPrec).SetFloat64(x)
+}
+
+// bigAtanInv returns atan(1/x) for an integer x > 1 at precision prec.
+func bigAtanInv(x int64, prec uint) *big.Float {
+	xf := new(big.Float).SetPrec(prec).SetInt64(x)
+	x2 := new(big.Float).SetPrec(prec).Mul(xf, xf)
+	pow := new(big.Float).SetPrec(prec).Quo(big.NewFloat(1).SetPrec(prec), xf)
+	sum := new(big.Float).SetPrec(prec).Set(pow)
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -int(prec))
+	for k := int64(1); ; k++ {
+		pow.Quo(pow, x2)
+		term := new(big.Float).SetPrec(prec).Quo(pow, new(big.Float).SetInt64(2*k+1))
+		if k%2 == 1 {
+			sum.Sub(sum, term)
+		} else {
+			sum.Add(sum, term)
+		}
+		if term.Cmp(eps) < 0 {
+			return sum
+		}
+	}
+}
+
+// bigPi returns π by Machin's formula π = 16 atan(1/5) - 4 atan(1/239).
+func bigPi(prec uint) *big.Float {
+	a := new(big.Float).SetPrec(prec).Mul(big.NewFloat(16), bigAtanInv(5, prec))
+	b := new(big.Float).SetPrec(prec).Mul(big.NewFloat(4), bigAtanInv(239, prec))
+	return a.Sub(a, b)
+}
+
+// bigSqrtPi returns √π.
+func bigSqrtPi(prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec).Sqrt(bigPi(prec))
+}
+
+// bigErfc returns erfc(x) for x >= 0 from the Taylor series of erf. The terms grow to
+// about e^(x²) and 1 - erf cancels another e^(x²), so twice that many bits are added.
+func bigErfc(x *big.Float) *big.Float {
+	xf, _ := x.Float64()
+	if xf > bigErfcCutoff {
+		return bigFloat(0)
+	}
+	prec := bigPrec + uint(2*xf*xf*1.45) + 64
+
+	x = new(big.Float).SetPrec(prec).Set(x)
+	x2 := new(big.Float).SetPrec(prec).Mul(x, x)
+	pow := new(big.Float).SetPrec(prec).Set(x) // (-1)^n x^(2n+1) / n!
+	sum := new(big.Float).SetPrec(prec).Set(x)
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -int(prec))
+	for n := int64(1); ; n++ {
+		pow.Mul(pow, x2)
+		pow.Quo(pow, new(big.Float).SetInt64(-n))
+		term := new(big.Float).SetPrec(prec).Quo(pow, new(big.Float).SetInt64(2*n+1))
+		sum.Add(sum, term)
+		if n > int64(xf*xf) && new(big.Float).Abs(term).Cmp(eps) < 0 {
+			break
+		}
+	}
+
+	erf := sum.Mul(sum, big.NewFloat(2)).Quo(sum, bigSqrtPi(prec))
+	return new(big.Float).SetPrec(bigPrec).Sub(big.NewFloat(1), erf)
+}
+
+// bigExpNeg returns e^-x for x >= 0.
+func bigExpNeg(x *big.Float) *big.Float {
+	return bigExp(new(big.Float).Neg(x), bigPrec)
+}
+
+// bigExp returns e^x with a relative error of about 2^-prec. The argument is halved s
+// times into (-1, 1) for the Taylor series and the result squared s times, which costs
+// s bits of precision that are added to the working precision.
+func bigExp(x *big.Float, prec uint) *big.Float {
+	s := max(x.MantExp(nil), 0)
+	wp := prec + uint(s) + 64
+
+	y := new(big.Float).SetMantExp(x, -s) // exact, with the precision of x
+	y.SetPrec(wp)
+	term := new(big.Float).SetPrec(wp).SetInt64(1)
+	sum := new(big.Float).SetPrec(wp).SetInt64(1)
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -int(wp))
+	for n := int64(1); new(big.Float).Abs(term).Cmp(eps) >= 0; n++ {
+		term.Mul(term, y).Quo(term, new(big.Float).SetInt64(n))
+		sum.Add(sum, term)
+	}
+	for ; s > 0; s-- {
+		sum.Mul(sum, sum)
+	}
+	return new(big.Float).SetPrec(prec).Set(sum)
+}
+
+// bigLn2 returns ln 2 = sum_k 1 / (k 2^k).
+func bigLn2(prec uint) *big.Float {
+	wp := prec + 16
+	sum := new(big.Float).SetPrec(wp)
+	for k := 1; k <= int(wp); k++ {
+		term := new(big.Float).SetPrec(wp).SetInt64(1)
+		term.SetMantExp(term, -k)
+		sum.Add(sum, term.Quo(term, new(big.Float).SetInt64(int64(k))))
+	}
+	return sum
+}
+
+// bigLog returns ln x for x > 0. With x = m 2^e and m in [0.5, 1), ln m is refined
+// from its float64 value by Halley's iteration y += 2 (m - e^y) / (m + e^y), which
+// triples the number of correct bits per step.
+func bigLog(x *big.Float, prec uint) *big.Float {
+	wp := prec + 32
+	m := new(big.Float).SetPrec(wp)
+	e := x.MantExp(m)
+
+	mf, _ := m.Float64()
+	y := new(big.Float).SetPrec(wp).SetFloat64(math.Log(mf))
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -int(prec))
+	for {
+		ey := bigExp(y, wp)
+		num := new(big.Float).SetPrec(wp).Sub(m, ey)
+		den := new(big.Float).SetPrec(wp).Add(m, ey)
+		delta := num.Quo(num, den)
+		delta.Mul(delta, big.NewFloat(2))
+		y.Add(y, delta)
+		if delta.Sign() == 0 || new(big.Float).Abs(delta).Cmp(eps) < 0 {
+			break
+		}
+	}
+
+	ln2e := new(big.Float).SetPrec(wp).Mul(bigLn2(wp), new(big.Float).SetInt64(int64(e)))
+	return y.Add(y, ln2e)
+}
+
+// stirlingBernoulli holds B_2k / (2k (2k-1)) for k = 1..15 as numerator and denominator.
+var stirlingBernoulli = [...][2]int64{
+	{1, 12}, {-1, 360}, {1, 1260}, {-1, 1680}, {1, 1188},
+	{-691, 360360}, {1, 156}, {-3617, 122400}, {43867, 244188}, {-174611, 125400},
+	{77683, 5796}, {-236364091, 1506960}, {657931, 300}, {-3392780147, 93960}, {1723168255201, 2492028},
+}
+
+// stirlingMinArg is the argument from which the truncated Stirling series of ln Γ is
+// accurate to more than bigPrec bits.
+const stirlingMinArg = 1024
+
+// bigLogGamma returns ln Γ(a) for a > 0. The argument is shifted to at least
+// stirlingMinArg with Γ(a) = Γ(a+N) / (a (a+1) ... (a+N-1)) and the Stirling series
+// is summed there.
+func bigLogGamma(a float64, prec uint) *big.Float {
+	wp := prec + 64
+	z := new(big.Float).SetPrec(wp).SetFloat64(a)
+	shift := new(big.Float).SetPrec(wp).SetInt64(1)
+	for a < stirlingMinArg {
+		shift.Mul(shift, z)
+		z.Add(z, big.NewFloat(1))
+		a++
+	}
+
+	// (z - 1/2) ln z - z + ln(2π) / 2
+	lnZ := bigLog(z, wp)
+	res := new(big.Float).SetPrec(wp).Sub(z, big.NewFloat(0.5))
+	res.Mul(res, lnZ).Sub(res, z)
+	twoPi := bigPi(wp)
+	twoPi.Mul(twoPi, big.NewFloat(2))
+	half := bigLog(twoPi, wp)
+	res.Add(res, half.Quo(half, big.NewFloat(2)))
+
+	// + sum_k B_2k / (2k (2k-1) z^(2k-1))
+	z2 := new(big.Float).SetPrec(wp).Mul(z, z)
+	pow := new(big.Float).SetPrec(wp).Set(z)
+	for _, b := range stirlingBernoulli {
+		term := new(big.Float).SetPrec(wp).SetInt64(b[0])
+		term.Quo(term, new(big.Float).SetPrec(wp).SetInt64(b[1])).Quo(term, pow)
+		res.Add(res, term)
+		pow.Mul(pow, z2)
+	}
+
+	return res.Sub(res, bigLog(shift, wp))
+}
+
+// bigGammaQ returns the regularized upper incomplete gamma function Q(a, x) for a > 0
+// with 2a an integer. Below x = a+1 it sums the series of P(a, x) and returns 1 - P,
+// which is at least about 1/2 there; above it evaluates the continued fraction of Q
+// with Lentz's method. The prefactor x^a e^-x / Γ(a) is formed in logarithms.
+func bigGammaQ(a, x float64) *big.Float {
+	if a <= 0 || a != math.Round(2*a)/2 || math.IsNaN(x) {
+		return nil
+	}
+	if x <= 0 {
+		return bigFloat(1)
+	}
+
+	// The logarithm of the prefactor reaches about a ln x + x in magnitude; its bits
+	// before the binary point are lost when it is exponentiated.
+	wp := bigPrec + 64 + uint(math.Log2(a*math.Abs(math.Log(x))+x+2))
+	bx := new(big.Float).SetPrec(wp).SetFloat64(x)
+	ba := new(big.Float).SetPrec(wp).SetFloat64(a)
+
+	lnPre := bigLog(bx, wp)
+	lnPre.Mul(lnPre, ba).Sub(lnPre, bx).Sub(lnPre, bigLogGamma(a, wp))
+	pre := bigExp(lnPre, wp)
+
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -int(wp))
+	one := new(big.Float).SetPrec(wp).SetInt64(1)
+
+	if x < a+1 {
+		// P(a, x) = pre / a * sum_n x^n / ((a+1) ... (a+n))
+		term := new(big.Float).SetPrec(wp).Set(one)
+		sum := new(big.Float).SetPrec(wp).Set(one)
+		den := new(big.Float).SetPrec(wp).Set(ba)
+		for {
+			den.Add(den, one)
+			term.Mul(term, bx).Quo(term, den)
+			sum.Add(sum, term)
+			if term.Cmp(new(big.Float).Mul(sum, eps)) < 0 {
+				break
+			}
+		}
+		p := sum.Mul(sum, pre).Quo(sum, ba)
+		return new(big.Float).SetPrec(bigPrec).Sub(one, p)
+	}
+
+	// Q(a, x) = pre / (x+1-a - 1(1-a) / (x+3-a - 2(2-a) / (x+5-a - ...)))
+	tiny := new(big.Float).SetMantExp(big.NewFloat(1), -2*int(wp))
+	b := new(big.Float).SetPrec(wp).Sub(bx, ba)
+	b.Add(b, one)
+	c := new(big.Float).SetPrec(wp).Quo(one, tiny)
+	d := new(big.Float).SetPrec(wp).Quo(one, b)
+	h := new(big.Float).SetPrec(wp).Set(d)
+	for i := int64(1); ; i++ {
+		// a_i = -i (i - a)
+		an := new(big.Float).SetPrec(wp).SetInt64(i)
+		an.Sub(an, ba).Mul(an, new(big.Float).SetInt64(-i))
+		b.Add(b, big.NewFloat(2))
+
+		d.Mul(an, d).Add(d, b)
+		if d.Sign() == 0 {
+			d.Set(tiny)
+		}
+		c.Quo(an, c).Add(c, b)
+		if c.Sign() == 0 {
+			c.Set(tiny)
+		}
+		d.Quo(one, d)
+		del := new(big.Float).SetPrec(wp).Mul(d, c)
+		h.Mul(h, del)
+
+		if new(big.Float).Abs(del.Sub(del, one)).Cmp(eps) < 0 {
+			break
+		}
+	}
+	return new(big.Float).SetPrec(bigPrec).Mul(h, pre)
+}
+
+// bigPhi returns the standard normal distribution function at x.
+func bigPhi(x *big.Float) *big.Float {
+	half := bigFloat(0.5)
+	arg := new(big.Float).SetPrec(bigPrec).Quo(x, new(big.Float).SetPrec(bigPrec).Sqrt(bigFloat(2)))
+	if x.Sign() < 0 {
+		return new(big.Float).Mul(half, bigErfc(arg.Neg(arg)))
+	}
+	tail := new(big.Float).Mul(half, bigErfc(arg))
+	return tail.Sub(bigFloat(1), tail)
+}
+
+// bigCumulativeSumsPValue evaluates the Cumulative Sums p-value series of SP 800-22
+// Section 3.13 directly at high precision.
+func bigCumulativeSumsPValue(n, z int) float64 {
+	if n <= 0 || z <= 0 {
+		return math.NaN()
+	}
+	sqrtN := new(big.Float).SetPrec(bigPrec).Sqrt(bigFloat(float64(n)))
+	cache := map[int]*big.Float{}
+	phi := func(j int) *big.Float {
+		if v, ok := cache[j]; ok {
+			return v
+		}
+		arg := new(big.Float).SetPrec(bigPrec).SetInt64(int64(j) * int64(z))
+		v := bigPhi(arg.Quo(arg, sqrtN))
+		cache[j] = v
+		return v
+	}
+	// Terms beyond the cutoff are exactly 0 at float64 resolution of the result.
+	limit := int(bigErfcCutoff*math.Sqrt2*math.Sqrt(float64(n))/float64(z)) + 4
+
+	p := bigFloat(1)
+	finish := (n/z - 1) / 4
+	for k := (-n/z + 1) / 4; k <= finish; k++ {
+		if absInt(4*k) > limit {
+			continue
+		}
+		p.Sub(p, phi(4*k+1))
+		p.Add(p, phi(4*k-1))
+	}
+	for k := (-n/z - 3) / 4; k <= finish; k++ {
+		if absInt(4*k) > limit {
+			continue
+		}
+		p.Add(p, phi(4*k+3))
+		p.Sub(p, phi(4*k+1))
+	}
+	f, _ := p.Float64()
+	return f
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// precisePValue evaluates every tail at arbitrary precision, clamped to [0, 1] like the
+// float64 p-values. PValue is the smallest of them, the counterpart of p, and
+// Discrepancy the largest difference from p or from the float64 p-value of a tail.
+func precisePValue(tails []Tail, p float64) *PrecisePValue {
+	res := &PrecisePValue{SubTests: make([]float64, len(tails))}
+	for i, t := range tails {
+		precise, _ := clampPValue(t.PrecisePValue())
+		fast, _ := clampPValue(t.PValue())
+		res.SubTests[i] = precise
+		res.Discrepancy = max(res.Discrepancy, math.Abs(fast-precise))
+		if i == 0 || precise < res.PValue {
+			res.PValue = precise
+		}
+	}
+	res.Discrepancy = max(res.Discrepancy, math.Abs(p-res.PValue))
+	return res
+}
@@ -0,0 +1,189 @@
+package nist
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+)
+
+// bigRelErr returns |got - want| / |want| at full precision.
+func bigRelErr(got, want *big.Float) float64 {
+	d := new(big.Float).SetPrec(bigPrec).Sub(got, want)
+	d.Quo(d, want)
+	f, _ := d.Abs(d).Float64()
+	return f
+}
+
+func TestBigExpLog(t *testing.T) {
+	for _, x := range []float64{-700, -30.5, -1, -1e-9, 0.25, 1, 3, 100, 5000} {
+		bx := bigFloat(x)
+		d := new(big.Float).Sub(bigLog(bigExp(bx, bigPrec), bigPrec), bx)
+		if f, _ := d.Float64(); math.Abs(f) > 1e-70*math.Max(1, math.Abs(x)) {
+			t.Errorf("ln(exp(%v)) - %v = %g", x, x, f)
+		}
+	}
+	if got, _ := bigExp(bigFloat(1), bigPrec).Float64(); got != math.E {
+		t.Errorf("exp(1) = %.17g, want %.17g", got, math.E)
+	}
+	if got, _ := bigLog(bigFloat(2), bigPrec).Float64(); got != math.Ln2 {
+		t.Errorf("ln 2 = %.17g, want %.17g", got, math.Ln2)
+	}
+}
+
+func TestBigLogGamma(t *testing.T) {
+	// Γ(1/2) = √π and Γ(n) = (n-1)!.
+	lnSqrtPi := bigLog(bigSqrtPi(bigPrec), bigPrec)
+	if e := bigRelErr(bigLogGamma(0.5, bigPrec), lnSqrtPi); e > 1e-70 {
+		t.Errorf("ln Γ(1/2): relative error %g", e)
+	}
+	fact := new(big.Float).SetPrec(bigPrec).SetInt64(1)
+	for n := 2; n <= 200; n++ {
+		want := bigLog(fact, bigPrec)
+		if n > 2 {
+			if e := bigRelErr(bigLogGamma(float64(n), bigPrec), want); e > 1e-70 {
+				t.Errorf("ln Γ(%d): relative error %g", n, e)
+			}
+		}
+		fact.Mul(fact, new(big.Float).SetInt64(int64(n)))
+	}
+	if got, _ := bigLogGamma(32768, bigPrec).Float64(); relErr(got, logGamma(32768)) > 1e-14 {
+		t.Errorf("ln Γ(32768) = %.17g, want %.17g", got, logGamma(32768))
+	}
+}
+
+func TestBigGammaQClosedForms(t *testing.T) {
+	// Q(1, x) = e^-x, Q(1/2, x) = erfc(√x), Q(3, x) = e^-x (1 + x + x²/2) and
+	// Q(5/2, x) = erfc(√x) + e^-x (2√x + 4x√x/3) / √π, on both sides of x = a + 1.
+	sqrtPi := bigSqrtPi(bigPrec)
+	for _, x := range []float64{0.01, 0.5, 1.2, 2, 3.4, 10, 50, 200, 600} {
+		bx := bigFloat(x)
+		sx := new(big.Float).SetPrec(bigPrec).Sqrt(bx)
+		e := bigExpNeg(bx)
+
+		q3 := new(big.Float).SetPrec(bigPrec).Mul(bx, bx)
+		q3.Quo(q3, bigFloat(2)).Add(q3, bx).Add(q3, bigFloat(1))
+		q3.Mul(q3, e)
+
+		poly := new(big.Float).SetPrec(bigPrec).Mul(bx, bigFloat(4))
+		poly.Quo(poly, bigFloat(3)).Add(poly, bigFloat(2)).Mul(poly, sx)
+		poly.Quo(poly, sqrtPi).Mul(poly, e)
+		q25 := bigErfc(sx)
+		q25.Add(q25, poly)
+
+		for _, c := range []struct {
+			a    float64
+			want *big.Float
+		}{
+			{1, e},
+			{0.5, bigErfc(sx)},
+			{3, q3},
+			{2.5, q25},
+		} {
+			if err := bigRelErr(bigGammaQ(c.a, x), c.want); err > 1e-60 {
+				t.Errorf("Q(%v, %v): relative error %g", c.a, x, err)
+			}
+		}
+	}
+}
+
+func TestPrecisePValueMatchesFast(t *testing.T) {
+	tails := []Tail{
+		{Kind: ErfcTail, X: 0},
+		{Kind: ErfcTail, X: 0.3},
+		{Kind: ErfcTail, X: 4},
+		{Kind: ErfcTail, X: 25},
+		{Kind: ErfcTail, X: -0.5},
+		{Kind: ExpTail, X: 0.1},
+		{Kind: ExpTail, X: 40},
+		{Kind: CusumTail, A: 10000, X: 150},
+		{Kind: CusumTail, A: 1000000, X: 500},
+	}
+	// The chi-square tails of every test: K/2 = 0.5 (Frequency within a Block with one
+	// block), 2.5, 3, 3.5 and 4 as well as the Serial degrees of freedom up to m = 16.
+	for _, a := range []float64{0.5, 1, 2.5, 3, 3.5, 4, 8, 64, 1024, 32768} {
+		for _, f := range []float64{0.2, 0.9, 1, 1.1, 2, 4} {
+			tails = append(tails, Tail{Kind: GammaTail, A: a, X: f * a})
+		}
+	}
+	for _, tail := range tails {
+		fast, precise := tail.PValue(), tail.PrecisePValue()
+		if math.IsNaN(precise) || relErr(fast, precise) > 1e-9 {
+			t.Errorf("%+v: float64 %.17g, precise %.17g", tail, fast, precise)
+		}
+	}
+}
+
+func TestPrecisePValueLargeDegreesOfFreedom(t *testing.T) {
+	// Around the mean of a chi-square variable with 2^16 degrees of freedom, Q(a, x) is
+	// close to the normal tail of (x - a) / √a with a skewness correction of order 1/√a.
+	const a = 32768.0
+	for _, z := range []float64{-3, 0, 3} {
+		x := a + z*math.Sqrt(a)
+		got := Tail{Kind: GammaTail, A: a, X: x}.PrecisePValue()
+		want := 0.5 * math.Erfc(z/math.Sqrt2)
+		if math.Abs(got-want) > 5e-3 {
+			t.Errorf("Q(%v, %v) = %v, want about %v", a, x, got, want)
+		}
+	}
+}
+
+func TestPrecisePValueInvalid(t *testing.T) {
+	for _, tail := range []Tail{
+		{Kind: GammaTail, A: 1.3, X: 2},
+		{Kind: GammaTail, A: 0, X: 2},
+		{Kind: TailKind(99)},
+	} {
+		if p := tail.PrecisePValue(); !math.IsNaN(p) {
+			t.Errorf("%+v: got %v, want NaN", tail, p)
+		}
+	}
+	if p := (Tail{Kind: GammaTail, A: 2, X: 0}).PrecisePValue(); p != 1 {
+		t.Errorf("Q(2, 0) = %v, want 1", p)
+	}
+}
+
+func TestRegistryRunPrecise(t *testing.T) {
+	data := pseudoRandomBytes(50000, 7)
+	names := []string{"frequency_monobit", "cumulative_sums", "binary_matrix_rank", "serial", "linear_complexity", "non_overlapping_template"}
+
+	fast, err := DefaultRegistry.Run(context.Background(), data, RunOptions{Tests: names})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	precise, err := DefaultRegistry.Run(context.Background(), data, RunOptions{Tests: names, Precise: true})
+	if err != nil {
+		t.Fatalf("precise Run failed: %v", err)
+	}
+
+	for i, r := range precise {
+		t.Run(r.Name, func(t *testing.T) {
+			if fast[i].Precise != nil {
+				t.Error("Precise set without RunOptions.Precise")
+			}
+			if r.PValue != fast[i].PValue || r.Passed != fast[i].Passed {
+				t.Errorf("precise mode changed the result: (%v, %v), want (%v, %v)", r.PValue, r.Passed, fast[i].PValue, fast[i].Passed)
+			}
+			if r.Precise == nil {
+				t.Fatal("Precise not set")
+			}
+			subTests := r.SubTestPValues()
+			if len(r.Precise.SubTests) != len(subTests) {
+				t.Fatalf("%d precise sub-test p-values, want %d", len(r.Precise.SubTests), len(subTests))
+			}
+			want := math.Abs(r.PValue - r.Precise.PValue)
+			for j, p := range r.Precise.SubTests {
+				want = max(want, math.Abs(subTests[j]-p))
+				if p < r.Precise.PValue {
+					t.Errorf("sub-test %d: precise p-value %g below the smallest %g", j, p, r.Precise.PValue)
+				}
+			}
+			if r.Precise.Discrepancy != want {
+				t.Errorf("Discrepancy = %g, want %g", r.Precise.Discrepancy, want)
+			}
+			if r.Precise.Discrepancy > 1e-9 {
+				t.Errorf("float64 %.17g and precise %.17g p-values disagree", r.PValue, r.Precise.PValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package nist
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mathext"
+)
+
+// TailKind selects the distribution function of a Tail.
+type TailKind int
+
+const (
+	// ErfcTail is the complementary error function erfc(X).
+	ErfcTail TailKind = iota
+	// GammaTail is the regularized upper incomplete gamma function Q(A, X), i.e. the
+	// chi-square tail with 2A degrees of freedom at 2X.
+	GammaTail
+	// ExpTail is e^-X, the chi-square tail with 2 degrees of freedom at 2X.
+	ExpTail
+	// CusumTail is the Cumulative Sums series for A steps and maximal excursion X.
+	CusumTail
+)
+
+// Tail is a p-value given by the reference distribution of a test statistic. PValue
+// evaluates it in float64 and PrecisePValue at arbitrary precision, so that the
+// accuracy of the special functions can be verified independently of the statistic.
+type Tail struct {
+	Kind TailKind
+	A, X float64
+}
+
+// PValue evaluates the tail in float64.
+func (t Tail) PValue() float64 {
+	switch t.Kind {
+	case ErfcTail:
+		return math.Erfc(t.X)
+	case GammaTail:
+		return mathext.GammaIncRegComp(t.A, t.X)
+	case ExpTail:
+		return math.Exp(-t.X)
+	case CusumTail:
+		return cumulativeSumsPValue(int(t.A), t.X)
+	default:
+		return math.NaN()
+	}
+}
+
+// minPValue returns the smallest p-value of tails and whether it passes at Alpha, or
+// (0, false) if there are none.
+func minPValue(tails []Tail) (float64, bool) {
+	if len(tails) == 0 {
+		return 0, false
+	}
+	p := tails[0].PValue()
+	for _, t := range tails[1:] {
+		if q := t.PValue(); q < p {
+			p = q
+		}
+	}
+	return p, p >= Alpha
+}
+
+// pValues evaluates each tail in float64; it returns nil for no tails.
+func pValues(tails []Tail) []float64 {
+	if tails == nil {
+		return nil
+	}
+	ps := make([]float64, len(tails))
+	for i, t := range tails {
+		ps[i] = t.PValue()
+	}
+	return ps
+}
+
+// normalDiff returns Φ(b) - Φ(a) for a <= b. Both tails are evaluated with Erfc so
+// that differences of values close to 0 or 1 do not cancel.
+func normalDiff(a, b float64) float64 {
+	switch {
+	case a >= 0:
+		return 0.5 * (math.Erfc(a/math.Sqrt2) - math.Erfc(b/math.Sqrt2))
+	case b <= 0:
+		return 0.5 * (math.Erfc(-b/math.Sqrt2) - math.Erfc(-a/math.Sqrt2))
+	default:
+		return 1 - 0.5*(math.Erfc(-a/math.Sqrt2)+math.Erfc(b/math.Sqrt2))
+	}
+}
+
+// compensatedSum accumulates float64 values with Neumaier's variant of Kahan summation.
+type compensatedSum struct {
+	sum, c float64
+}
+
+func (s *compensatedSum) add(x float64) {
+	t := s.sum + x
+	if math.Abs(s.sum) >= math.Abs(x) {
+		s.c += (s.sum - t) + x
+	} else {
+		s.c += (x - t) + s.sum
+	}
+	s.sum = t
+}
+
+func (s *compensatedSum) value() float64 {
+	return s.sum + s.c
+}
+
+// clampPValue limits p to [0, 1] and reports whether it had to be clamped. Series of
+// tail probabilities can leave the interval by a few ulps through rounding; the
+// closed-form tails (Erfc, GammaIncRegComp, Exp of a non-positive value) cannot.
+func clampPValue(p float64) (float64, bool) {
+	switch {
+	case p < 0:
+		return 0, true
+	case p > 1:
+		return 1, true
+	case math.IsNaN(p):
+		return 0, true
+	default:
+		return p, false
+	}
+}
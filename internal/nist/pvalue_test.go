@@ -0,0 +1,117 @@
+package nist
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"gonum.org/v1/gonum/mathext"
+)
+
+// relErr returns |got - want| relative to want, or the absolute error if want is zero.
+func relErr(got, want float64) float64 {
+	if want == 0 {
+		return math.Abs(got)
+	}
+	return math.Abs(got-want) / math.Abs(want)
+}
+
+func TestCumulativeSumsPValueMatchesBig(t *testing.T) {
+	ns := []int{100, 10000, 1000000, 10000000}
+	if testing.Short() {
+		ns = ns[:2]
+	}
+	for _, n := range ns {
+		for _, c := range []float64{0.1, 0.5, 1, 2, 4, 8, 16, 37} {
+			z := max(1, int(math.Round(c*math.Sqrt(float64(n)))))
+			if z > n {
+				continue
+			}
+			want := bigCumulativeSumsPValue(n, z)
+			got := cumulativeSumsPValue(n, float64(z))
+			if got < 0 || got > 1 {
+				t.Errorf("n=%d z=%d: p=%v outside [0, 1]", n, z, got)
+			}
+			// Absolute accuracy near 1, relative accuracy in the lower tail.
+			if relErr(got, want) > 1e-12 && math.Abs(got-want) > 1e-14 {
+				t.Errorf("n=%d z=%d: got %.17g, want %.17g", n, z, got, want)
+			}
+		}
+	}
+}
+
+func TestNormalDiffMatchesBig(t *testing.T) {
+	pairs := [][2]float64{
+		{-30, -20}, {-9, -8.5}, {-3, -1}, {-1, 1}, {-0.001, 0.002},
+		{0.5, 0.6}, {2, 3}, {8, 8.25}, {20, 25}, {37, 38},
+	}
+	for _, ab := range pairs {
+		a, b := ab[0], ab[1]
+		ref := bigPhi(bigFloat(b))
+		ref.Sub(ref, bigPhi(bigFloat(a)))
+		want, _ := ref.Float64()
+		if got := normalDiff(a, b); relErr(got, want) > 1e-13 {
+			t.Errorf("normalDiff(%v, %v) = %.17g, want %.17g", a, b, got, want)
+		}
+	}
+}
+
+func TestErfcTailMatchesBig(t *testing.T) {
+	// The Frequency, Runs, Spectral, Universal and Random Excursions Variant p-values
+	// are erfc of a non-negative statistic.
+	for _, x := range []float64{0, 0.1, 1, 3, 5, 10, 20, 26} {
+		want, _ := bigErfc(bigFloat(x)).Float64()
+		if got := math.Erfc(x); relErr(got, want) > 1e-13 {
+			t.Errorf("Erfc(%v) = %.17g, want %.17g", x, got, want)
+		}
+	}
+}
+
+func TestChiSquareTailMatchesBig(t *testing.T) {
+	// Q(3, x) = e^-x (1 + x + x²/2) and Q(5/2, x) = erfc(√x) + e^-x (2√x + 4x√x/3) / √π
+	// cover the Linear Complexity and Overlapping Template (K = 6) and Random
+	// Excursions (K = 5) tails.
+	sqrtPi := bigSqrtPi(bigPrec)
+	for _, x := range []float64{0.5, 2, 10, 50, 200, 600} {
+		bx := bigFloat(x)
+		e := bigExpNeg(bx)
+
+		q3 := bigFloat(1 + x + x*x/2)
+		q3.Mul(q3, e)
+		want, _ := q3.Float64()
+		if got := mathext.GammaIncRegComp(3, x); relErr(got, want) > 1e-12 {
+			t.Errorf("Q(3, %v) = %.17g, want %.17g", x, got, want)
+		}
+
+		sx := new(big.Float).SetPrec(bigPrec).Sqrt(bx)
+		poly := new(big.Float).SetPrec(bigPrec).Mul(bigFloat(4.0/3*x), sx)
+		poly.Add(poly, new(big.Float).Mul(bigFloat(2), sx))
+		poly.Quo(poly, sqrtPi).Mul(poly, e)
+		q25 := bigErfc(sx)
+		q25.Add(q25, poly)
+		want, _ = q25.Float64()
+		if got := mathext.GammaIncRegComp(2.5, x); relErr(got, want) > 1e-12 {
+			t.Errorf("Q(2.5, %v) = %.17g, want %.17g", x, got, want)
+		}
+	}
+}
+
+func TestClampPValue(t *testing.T) {
+	cases := []struct {
+		in, want float64
+		clamped  bool
+	}{
+		{0.5, 0.5, false},
+		{0, 0, false},
+		{1, 1, false},
+		{-1e-17, 0, true},
+		{1 + 1e-15, 1, true},
+		{math.NaN(), 0, true},
+	}
+	for _, c := range cases {
+		got, clamped := clampPValue(c.in)
+		if got != c.want || clamped != c.clamped {
+			t.Errorf("clampPValue(%v) = (%v, %v), want (%v, %v)", c.in, got, clamped, c.want, c.clamped)
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package nist
 
-import (
-	"math"
-
-	"gonum.org/v1/gonum/mathext"
-)
+import "math"
 
 // RandomExcursionsTest implements the NIST Random Excursions test.
 // It returns the minimum p-value across the 8 states and whether it passes at Alpha.
 func RandomExcursionsTest(bitstream []byte) (float64, bool) {
+	return minPValue(randomExcursionsTails(bitstream))
+}
+
+// RandomExcursionsPValues returns one p-value per state x = -4..-1, +1..+4, in that order.
+// It returns nil if the sequence has too few cycles (J < 500).
+func RandomExcursionsPValues(bitstream []byte) []float64 {
+	return pValues(randomExcursionsTails(bitstream))
+}
+
+func randomExcursionsTails(bitstream []byte) []Tail {
 	bits := expandBits(bitstream)
 	n := len(bits)
 
@@ -27,7 +33,7 @@ func RandomExcursionsTest(bitstream []byte) (float64, bool) {
 
 	constraint := int(math.Max(0.005*math.Sqrt(float64(n)), 500))
 	if J < constraint {
-		return 0, false
+		return nil
 	}
 
 	cycle := make([]int, J+1)
@@ -49,7 +55,6 @@ func RandomExcursionsTest(bitstream []byte) (float64, bool) {
 		{0.875, 0.015625, 0.013671875, 0.01196289063, 0.0104675293, 0.0732727051},
 	}
 
-	minP := 1.0
 	cycleStart := 0
 	cycleStop := cycle[1]
 
@@ -86,6 +91,7 @@ func RandomExcursionsTest(bitstream []byte) (float64, bool) {
 		}
 	}
 
+	tails := make([]Tail, 0, len(stateX))
 	for i := 0; i < 8; i++ {
 		x := stateX[i]
 		idx := int(math.Abs(float64(x)))
@@ -98,11 +104,8 @@ func RandomExcursionsTest(bitstream []byte) (float64, bool) {
 			diff := nu[k][i] - expected
 			sum += diff * diff / expected
 		}
-		p := mathext.GammaIncRegComp(2.5, sum/2.0)
-		if p < minP {
-			minP = p
-		}
+		tails = append(tails, Tail{Kind: GammaTail, A: 2.5, X: sum / 2.0})
 	}
 
-	return minP, minP >= Alpha
+	return tails
 }
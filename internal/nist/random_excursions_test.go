@@ -30,4 +30,17 @@ func TestRandomExcursions(t *testing.T) {
 			t.Fatalf("expecting periodic walk to fail uniformity, got p=%.6f", p)
 		}
 	})
+
+	t.Run("p_values_per_state", func(t *testing.T) {
+		data := make([]byte, 125)
+		for i := range data {
+			data[i] = 0xAA
+		}
+		if got := len(RandomExcursionsPValues(data)); got != 8 {
+			t.Fatalf("expected 8 p-values, got %d", got)
+		}
+		if RandomExcursionsPValues(make([]byte, 100)) != nil {
+			t.Fatal("expected nil for insufficient cycles")
+		}
+	})
 }
@@ -5,6 +5,16 @@ import "math"
 // RandomExcursionsVariantTest implements the NIST Random Excursions Variant test.
 // It returns the minimum p-value across the 18 states and whether it passes at Alpha.
 func RandomExcursionsVariantTest(bitstream []byte) (float64, bool) {
+	return minPValue(randomExcursionsVariantTails(bitstream))
+}
+
+// RandomExcursionsVariantPValues returns one p-value per state x = -9..-1, +1..+9, in that order.
+// It returns nil if the sequence has too few cycles (J < 500).
+func RandomExcursionsVariantPValues(bitstream []byte) []float64 {
+	return pValues(randomExcursionsVariantTails(bitstream))
+}
+
+func randomExcursionsVariantTails(bitstream []byte) []Tail {
 	bits := expandBits(bitstream)
 	n := len(bits)
 
@@ -23,12 +33,11 @@ func RandomExcursionsVariantTest(bitstream []byte) (float64, bool) {
 
 	constraint := int(math.Max(0.005*math.Sqrt(float64(n)), 500))
 	if J < constraint {
-		return 0, false
+		return nil
 	}
 
 	stateX := []int{-9, -8, -7, -6, -5, -4, -3, -2, -1, 1, 2, 3, 4, 5, 6, 7, 8, 9}
-	minP := 1.0
-
+	tails := make([]Tail, 0, len(stateX))
 	for _, x := range stateX {
 		count := 0
 		for i := 0; i < n; i++ {
@@ -36,11 +45,9 @@ func RandomExcursionsVariantTest(bitstream []byte) (float64, bool) {
 				count++
 			}
 		}
-		p := math.Erfc(math.Abs(float64(count)-float64(J)) / math.Sqrt(2*float64(J)*(4*math.Abs(float64(x))-2)))
-		if p < minP {
-			minP = p
-		}
+		arg := math.Abs(float64(count)-float64(J)) / math.Sqrt(2*float64(J)*(4*math.Abs(float64(x))-2))
+		tails = append(tails, Tail{Kind: ErfcTail, X: arg})
 	}
 
-	return minP, minP >= Alpha
+	return tails
 }
@@ -26,4 +26,17 @@ func TestRandomExcursionsVariant(t *testing.T) {
 			t.Fatalf("expecting periodic walk to fail uniformity, got p=%.6f", p)
 		}
 	})
+
+	t.Run("p_values_per_state", func(t *testing.T) {
+		data := make([]byte, 125)
+		for i := range data {
+			data[i] = 0xAA
+		}
+		if got := len(RandomExcursionsVariantPValues(data)); got != 18 {
+			t.Fatalf("expected 18 p-values, got %d", got)
+		}
+		if RandomExcursionsVariantPValues(make([]byte, 100)) != nil {
+			t.Fatal("expected nil for insufficient cycles")
+		}
+	})
 }
@@ -0,0 +1,312 @@
+package nist
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName names the tracer of the test spans, taken from the global tracer provider,
+// which does nothing unless tracing has been set up.
+const tracerName = "github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+
+// Test is a statistical test that can be registered with a Registry. Built-in and
+// custom tests share the same runner, selection and reporting.
+type Test interface {
+	// Name returns the unique test name used in results and for selection (e.g. "runs").
+	Name() string
+	// MinBits returns the shortest sequence the test accepts with its default parameters.
+	MinBits() int
+	// Params describes the tunable parameters; it may be empty.
+	Params() []ParamSpec
+	// Run executes the test on a bitstream packed MSB-first. params contains a value for
+	// every entry of Params.
+	Run(ctx context.Context, bitstream []byte, params Params) (TestResult, error)
+}
+
+// ParamsValidator is implemented by tests whose parameters have constraints beyond the
+// bounds of each ParamSpec, e.g. one parameter depending on another.
+type ParamsValidator interface {
+	// ValidateParams checks a complete set of resolved parameters.
+	ValidateParams(params Params) error
+}
+
+// ParamSpec describes an integer test parameter.
+type ParamSpec struct {
+	Name        string
+	Description string
+	Default     int
+	Min         int
+	Max         int // 0 means unbounded
+}
+
+// Params maps parameter names to values.
+type Params map[string]int
+
+// RunOptions selects tests and overrides parameters for Registry.Run.
+type RunOptions struct {
+	// Tests lists the tests to run in registration order; all tests if empty.
+	Tests []string
+	// Params holds parameter overrides per test name. Missing values use the defaults.
+	Params map[string]Params
+	// Precise additionally evaluates the p-values with arbitrary-precision tail
+	// functions and reports them in TestResult.Precise. It is meant for validation
+	// runs and is considerably slower.
+	Precise bool
+}
+
+// Registry holds statistical tests in registration order.
+type Registry struct {
+	mu     sync.RWMutex
+	tests  []Test
+	byName map[string]Test
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]Test)}
+}
+
+// DefaultRegistry contains the 15 SP 800-22 tests and is used by RunAllTests.
+var DefaultRegistry = newDefaultRegistry()
+
+// Register adds a test to the default registry.
+func Register(t Test) error {
+	return DefaultRegistry.Register(t)
+}
+
+// Register adds a test. Names must be non-empty and unique and parameter defaults
+// must satisfy their own bounds.
+func (r *Registry) Register(t Test) error {
+	name := t.Name()
+	if name == "" {
+		return fmt.Errorf("test name must not be empty")
+	}
+	for _, spec := range t.Params() {
+		if err := spec.check(spec.Default); err != nil {
+			return fmt.Errorf("test %s: invalid default: %w", name, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byName[name]; exists {
+		return fmt.Errorf("test already registered: %s", name)
+	}
+	r.tests = append(r.tests, t)
+	r.byName[name] = t
+	return nil
+}
+
+// Lookup returns the test registered under name.
+func (r *Registry) Lookup(name string) (Test, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.byName[name]
+	return t, ok
+}
+
+// Tests returns the registered tests in registration order.
+func (r *Registry) Tests() []Test {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Test(nil), r.tests...)
+}
+
+// Names returns the registered test names in registration order.
+func (r *Registry) Names() []string {
+	tests := r.Tests()
+	names := make([]string, len(tests))
+	for i, t := range tests {
+		names[i] = t.Name()
+	}
+	return names
+}
+
+// Validate checks that opts selects registered tests and that every parameter override
+// is known and within bounds.
+func (r *Registry) Validate(opts RunOptions) error {
+	_, _, err := r.resolve(opts)
+	return err
+}
+
+// Run executes the selected tests in registration order. A test whose MinBits exceeds
+// the sequence length is reported as skipped with a negative p-value.
+func (r *Registry) Run(ctx context.Context, bitstream []byte, opts RunOptions) ([]TestResult, error) {
+	selected, params, err := r.resolve(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	numBits := len(bitstream) * 8
+	results := make([]TestResult, 0, len(selected))
+	for i, t := range selected {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		res, err := runTest(ctx, t, bitstream, numBits, params[i], opts.Precise)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// runTest runs one test in its own span, which records the parameters and the outcome.
+func runTest(ctx context.Context, t Test, bitstream []byte, numBits int, params Params, precise bool) (TestResult, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "nist."+t.Name(), trace.WithAttributes(testAttributes(t.Name(), params)...))
+	defer span.End()
+
+	if numBits < t.MinBits() {
+		res := TestResult{
+			Name:    t.Name(),
+			PValue:  -1,
+			Warning: fmt.Sprintf("skipped: needs at least %d bits", t.MinBits()),
+		}
+		span.SetAttributes(attribute.Bool("nist.skipped", true))
+		return res, nil
+	}
+
+	res, err := t.Run(ctx, bitstream, params)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return TestResult{}, fmt.Errorf("test %s: %w", t.Name(), err)
+	}
+	res.Name = t.Name()
+	if pValues := res.SubTestPValues(); pValues != nil {
+		// Every consumer of the result shares this verdict, see SubTestsFailed.
+		res.Passed = !SubTestsFailed(pValues)
+		res.Proportion = float64(passedCount(pValues)) / float64(len(pValues))
+	}
+	if precise && len(res.Tails) > 0 {
+		res.Precise = precisePValue(res.Tails, res.PValue)
+	}
+
+	span.SetAttributes(
+		attribute.Float64("nist.p_value", res.PValue),
+		attribute.Bool("nist.passed", res.Passed),
+		attribute.Bool("nist.skipped", res.PValue < 0),
+	)
+	if res.Warning != "" {
+		span.SetAttributes(attribute.String("nist.warning", res.Warning))
+	}
+	return res, nil
+}
+
+// testAttributes returns the span attributes naming a test and its parameters, in
+// parameter name order.
+func testAttributes(name string, params Params) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("nist.test", name)}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, attribute.Int("nist.param."+k, params[k]))
+	}
+	return attrs
+}
+
+// resolve returns the selected tests and their resolved parameters.
+func (r *Registry) resolve(opts RunOptions) ([]Test, []Params, error) {
+	selected, err := r.selectTests(opts.Tests)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name := range opts.Params {
+		if _, ok := r.Lookup(name); !ok {
+			return nil, nil, fmt.Errorf("parameters for unknown test: %s", name)
+		}
+	}
+
+	params := make([]Params, len(selected))
+	for i, t := range selected {
+		if params[i], err = ResolveParams(t, opts.Params[t.Name()]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return selected, params, nil
+}
+
+// selectTests returns the named tests in registration order, or all tests if names is empty.
+func (r *Registry) selectTests(names []string) ([]Test, error) {
+	all := r.Tests()
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := r.Lookup(name); !ok {
+			return nil, fmt.Errorf("unknown test: %s", name)
+		}
+		want[name] = true
+	}
+
+	selected := make([]Test, 0, len(want))
+	for _, t := range all {
+		if want[t.Name()] {
+			selected = append(selected, t)
+		}
+	}
+	return selected, nil
+}
+
+// ResolveParams merges overrides with the defaults of t and validates the result,
+// including the constraints of a ParamsValidator.
+func ResolveParams(t Test, overrides Params) (Params, error) {
+	specs := t.Params()
+	resolved := make(Params, len(specs))
+	known := make(map[string]ParamSpec, len(specs))
+	for _, spec := range specs {
+		resolved[spec.Name] = spec.Default
+		known[spec.Name] = spec
+	}
+
+	// Sorted for deterministic error messages.
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		spec, ok := known[k]
+		if !ok {
+			return nil, fmt.Errorf("test %s: unknown parameter: %s", t.Name(), k)
+		}
+		if err := spec.check(overrides[k]); err != nil {
+			return nil, fmt.Errorf("test %s: %w", t.Name(), err)
+		}
+		resolved[k] = overrides[k]
+	}
+	if v, ok := t.(ParamsValidator); ok {
+		if err := v.ValidateParams(resolved); err != nil {
+			return nil, fmt.Errorf("test %s: %w", t.Name(), err)
+		}
+	}
+	return resolved, nil
+}
+
+// check validates a value against the bounds of the parameter.
+func (p ParamSpec) check(v int) error {
+	if v < p.Min || (p.Max > 0 && v > p.Max) {
+		if p.Max > 0 {
+			return fmt.Errorf("parameter %s out of range: %d (must be %d-%d)", p.Name, v, p.Min, p.Max)
+		}
+		return fmt.Errorf("parameter %s out of range: %d (must be at least %d)", p.Name, v, p.Min)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package nist
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// stubTest is a configurable Test used to exercise the registry.
+type stubTest struct {
+	name    string
+	minBits int
+	params  []ParamSpec
+	err     error
+	got     Params
+}
+
+func (s *stubTest) Name() string        { return s.name }
+func (s *stubTest) MinBits() int        { return s.minBits }
+func (s *stubTest) Params() []ParamSpec { return s.params }
+
+func (s *stubTest) Run(_ context.Context, bitstream []byte, params Params) (TestResult, error) {
+	s.got = params
+	if s.err != nil {
+		return TestResult{}, s.err
+	}
+	return TestResult{PValue: float64(len(bitstream)) / 1000, Passed: true}, nil
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register(&stubTest{name: "a"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := r.Register(&stubTest{name: "a"}); err == nil {
+		t.Error("expected error for duplicate name")
+	}
+	if err := r.Register(&stubTest{name: ""}); err == nil {
+		t.Error("expected error for empty name")
+	}
+	bad := &stubTest{name: "b", params: []ParamSpec{{Name: "m", Default: 1, Min: 2, Max: 4}}}
+	if err := r.Register(bad); err == nil {
+		t.Error("expected error for default outside bounds")
+	}
+
+	if _, ok := r.Lookup("a"); !ok {
+		t.Error("expected registered test to be found")
+	}
+	if _, ok := r.Lookup("b"); ok {
+		t.Error("rejected test must not be registered")
+	}
+}
+
+func TestRegistryRunSelectionAndOrder(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"first", "second", "third"} {
+		if err := r.Register(&stubTest{name: name}); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	if got := r.Names(); !reflect.DeepEqual(got, []string{"first", "second", "third"}) {
+		t.Fatalf("unexpected names: %v", got)
+	}
+
+	results, err := r.Run(context.Background(), make([]byte, 10), RunOptions{Tests: []string{"third", "first"}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Name != "first" || results[1].Name != "third" {
+		t.Fatalf("expected selected tests in registration order, got %+v", results)
+	}
+
+	if _, err := r.Run(context.Background(), make([]byte, 10), RunOptions{Tests: []string{"fourth"}}); err == nil {
+		t.Error("expected error for unknown test")
+	}
+}
+
+func TestRegistryRunParams(t *testing.T) {
+	r := NewRegistry()
+	test := &stubTest{name: "p", params: []ParamSpec{
+		{Name: "m", Default: 3, Min: 2, Max: 8},
+		{Name: "k", Default: 5, Min: 1},
+	}}
+	if err := r.Register(test); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if _, err := r.Run(context.Background(), make([]byte, 10), RunOptions{}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !reflect.DeepEqual(test.got, Params{"m": 3, "k": 5}) {
+		t.Fatalf("expected defaults, got %v", test.got)
+	}
+
+	opts := RunOptions{Params: map[string]Params{"p": {"m": 8}}}
+	if _, err := r.Run(context.Background(), make([]byte, 10), opts); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !reflect.DeepEqual(test.got, Params{"m": 8, "k": 5}) {
+		t.Fatalf("expected override, got %v", test.got)
+	}
+
+	invalid := []map[string]Params{
+		{"p": {"m": 9}},
+		{"p": {"k": 0}},
+		{"p": {"x": 1}},
+		{"q": {"m": 3}},
+	}
+	for _, params := range invalid {
+		if _, err := r.Run(context.Background(), make([]byte, 10), RunOptions{Params: params}); err == nil {
+			t.Errorf("expected error for params %v", params)
+		}
+	}
+}
+
+func TestRegistryRunSkipsShortInput(t *testing.T) {
+	r := NewRegistry()
+	long := &stubTest{name: "long", minBits: 1000}
+	if err := r.Register(long); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	results, err := r.Run(context.Background(), make([]byte, 10), RunOptions{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if results[0].PValue >= 0 || results[0].Passed || results[0].Warning == "" {
+		t.Fatalf("expected skipped result, got %+v", results[0])
+	}
+	if long.got != nil {
+		t.Fatal("skipped test must not run")
+	}
+}
+
+func TestRegistryRunErrors(t *testing.T) {
+	r := NewRegistry()
+	boom := errors.New("boom")
+	if err := r.Register(&stubTest{name: "failing", err: boom}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if _, err := r.Run(context.Background(), make([]byte, 10), RunOptions{}); !errors.Is(err, boom) {
+		t.Fatalf("expected wrapped test error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.Run(ctx, make([]byte, 10), RunOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRegistryRunSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	orig := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(orig)
+
+	r := NewRegistry()
+	for _, test := range []*stubTest{
+		{name: "p", params: []ParamSpec{{Name: "m", Default: 3, Min: 2, Max: 8}}},
+		{name: "long", minBits: 1000},
+		{name: "failing", err: errors.New("boom")},
+	} {
+		if err := r.Register(test); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "rpc")
+	if _, err := r.Run(ctx, make([]byte, 10), RunOptions{}); err == nil {
+		t.Fatal("expected error from failing test")
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("got %d spans, want 3 test spans and the parent", len(spans))
+	}
+	attrs := func(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range s.Attributes {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+	for _, s := range spans[:3] {
+		if s.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of the RPC span", s.Name)
+		}
+	}
+
+	p := attrs(spans[0])
+	if spans[0].Name != "nist.p" || p["nist.test"].AsString() != "p" || p["nist.param.m"].AsInt64() != 3 ||
+		p["nist.p_value"].AsFloat64() != 0.01 || !p["nist.passed"].AsBool() {
+		t.Errorf("unexpected span %s: %v", spans[0].Name, spans[0].Attributes)
+	}
+	if long := attrs(spans[1]); !long["nist.skipped"].AsBool() {
+		t.Errorf("expected skipped span: %v", spans[1].Attributes)
+	}
+	if spans[2].Status.Code != codes.Error || len(spans[2].Events) == 0 {
+		t.Errorf("expected error status and event on %s: %+v", spans[2].Name, spans[2].Status)
+	}
+}
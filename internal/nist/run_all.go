@@ -1,16 +1,39 @@
 package nist
 
 import (
+	"context"
 	"fmt"
 )
 
 // TestResult represents the outcome of a single NIST test.
 type TestResult struct {
-	Name       string
-	PValue     float64
-	Passed     bool
+	Name   string
+	PValue float64
+	// Passed is the verdict of SubTestsFailed when the result comes from Registry.Run.
+	Passed bool
+	// Proportion is the fraction of the sub-test p-values >= Alpha, from Registry.Run.
 	Proportion float64
 	Warning    string
+	// Histogram holds the observed class counts when requested (e.g. ν_0..ν_6 of the
+	// Linear Complexity test).
+	Histogram []int
+	// Tails holds the reference distribution tails whose minimum is PValue; tests that
+	// do not expose them leave it empty.
+	Tails []Tail
+	// Precise holds the arbitrary-precision p-value when RunOptions.Precise is set.
+	Precise *PrecisePValue
+}
+
+// PrecisePValue is a p-value evaluated with arbitrary-precision tail functions and its
+// absolute difference from the float64 p-value.
+type PrecisePValue struct {
+	PValue float64
+	// Discrepancy is the largest absolute difference between a float64 p-value and its
+	// precise counterpart, over PValue and the sub-tests.
+	Discrepancy float64
+	// SubTests holds the precise p-value of every sub-test, in the order of
+	// TestResult.SubTestPValues; PValue is the smallest of them.
+	SubTests []float64
 }
 
 const (
@@ -20,7 +43,8 @@ const (
 	MaxBits = 10000000
 )
 
-// RunAllTests executes the full NIST SP 800-22 battery in pure Go.
+// RunAllTests executes every test of the default registry (the full NIST SP 800-22
+// battery unless custom tests were registered) with default parameters.
 func RunAllTests(bitstream []byte) ([]TestResult, error) {
 	numBits := len(bitstream) * 8
 	if numBits < MinBits {
@@ -30,113 +54,5 @@ func RunAllTests(bitstream []byte) ([]TestResult, error) {
 		return nil, fmt.Errorf("too many bits: got %d, maximum %d", numBits, MaxBits)
 	}
 
-	results := make([]TestResult, 0, 15)
-
-	appendResult := func(name string, p float64, passed bool, warning string) {
-		r := TestResult{
-			Name:       name,
-			PValue:     p,
-			Passed:     passed,
-			Proportion: 0,
-			Warning:    warning,
-		}
-		if passed {
-			r.Proportion = 1.0
-		}
-		results = append(results, r)
-	}
-
-	// 1. Frequency (Monobit)
-	p, pass := FrequencyTest(bitstream)
-	appendResult("frequency_monobit", p, pass, "")
-
-	// 2. Block Frequency (M = 128)
-	p, pass = BlockFrequencyTest(bitstream, 128)
-	warn := ""
-	if !pass && p == 0 {
-		warn = "insufficient bits for block size"
-	}
-	appendResult("block_frequency", p, pass, warn)
-
-	// 3. Cumulative Sums
-	p, pass = CumulativeSumsTest(bitstream)
-	appendResult("cumulative_sums", p, pass, "")
-
-	// 4. Runs
-	p, pass = RunsTest(bitstream)
-	warn = ""
-	if p == 0 && !pass {
-		warn = "Pi estimator criteria not met"
-	}
-	appendResult("runs", p, pass, warn)
-
-	// 5. Longest Run of Ones
-	p, pass = LongestRunOfOnesTest(bitstream)
-	warn = ""
-	if p == 0 && !pass {
-		warn = "insufficient bits for test"
-	}
-	appendResult("longest_run", p, pass, warn)
-
-	// 6. Binary Matrix Rank
-	p, pass = BinaryMatrixRankTest(bitstream)
-	warn = ""
-	if p == 0 && !pass {
-		warn = "insufficient bits for 32x32 matrices"
-	}
-	appendResult("binary_matrix_rank", p, pass, warn)
-
-	// 7. Discrete Fourier Transform
-	p, pass = DiscreteFourierTransformTest(bitstream)
-	appendResult("discrete_fourier_transform", p, pass, "")
-
-	// 8. Non-overlapping Template (m = 9)
-	p, pass = NonOverlappingTemplateTest(bitstream, 9)
-	warn = ""
-	if p == 0 && !pass {
-		warn = "only m=9 supported or insufficient bits"
-	}
-	appendResult("non_overlapping_template", p, pass, warn)
-
-	// 9. Overlapping Template (m = 9)
-	p, pass = OverlappingTemplateTest(bitstream, 9)
-	appendResult("overlapping_template", p, pass, "")
-
-	// 10. Universal Statistical
-	p, pass = UniversalStatisticalTest(bitstream)
-	warn = ""
-	if p == 0 && !pass {
-		warn = "insufficient bits or invalid parameters"
-	}
-	appendResult("universal_statistical", p, pass, warn)
-
-	// 11. Approximate Entropy (m = 10)
-	p, pass = ApproximateEntropyTest(bitstream, 10)
-	appendResult("approximate_entropy", p, pass, "")
-
-	// 12. Random Excursions
-	p, pass = RandomExcursionsTest(bitstream)
-	warn = ""
-	if p == 0 && !pass {
-		warn = "insufficient cycles (J < 500) or fail"
-	}
-	appendResult("random_excursions", p, pass, warn)
-
-	// 13. Random Excursions Variant
-	p, pass = RandomExcursionsVariantTest(bitstream)
-	warn = ""
-	if p == 0 && !pass {
-		warn = "insufficient cycles (J < 500) or fail"
-	}
-	appendResult("random_excursions_variant", p, pass, warn)
-
-	// 14. Serial (m = 16)
-	p, pass = SerialTest(bitstream, 16)
-	appendResult("serial", p, pass, "")
-
-	// 15. Linear Complexity (M = 500)
-	p, pass = LinearComplexityTest(bitstream, 500)
-	appendResult("linear_complexity", p, pass, "")
-
-	return results, nil
+	return DefaultRegistry.Run(context.Background(), bitstream, RunOptions{})
 }
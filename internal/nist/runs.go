@@ -8,9 +8,13 @@ import (
 // RunsTest implements the NIST Runs test.
 // It returns the p-value and whether it passes at Alpha.
 func RunsTest(bitstream []byte) (float64, bool) {
+	return minPValue(runsTails(bitstream))
+}
+
+func runsTails(bitstream []byte) []Tail {
 	n := len(bitstream) * 8
 	if n == 0 {
-		return 0, false
+		return nil
 	}
 
 	ones := 0
@@ -21,7 +25,7 @@ func RunsTest(bitstream []byte) (float64, bool) {
 	pi := float64(ones) / float64(n)
 	if math.Abs(pi-0.5) > 2.0/math.Sqrt(float64(n)) {
 		// Precondition for the runs test is not met.
-		return 0, false
+		return nil
 	}
 
 	runs := 1
@@ -36,7 +40,5 @@ func RunsTest(bitstream []byte) (float64, bool) {
 
 	erfcArg := math.Abs(float64(runs)-2.0*float64(n)*pi*(1-pi)) /
 		(2.0 * math.Sqrt(2*float64(n)) * pi * (1 - pi))
-	pValue := math.Erfc(erfcArg)
-
-	return pValue, pValue >= Alpha
+	return []Tail{{Kind: ErfcTail, X: erfcArg}}
 }
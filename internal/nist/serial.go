@@ -1,31 +1,42 @@
 package nist
 
-import (
-	"math"
+import "math"
 
-	"gonum.org/v1/gonum/mathext"
-)
+// SerialMaxBlockLength is the largest block length m of the Serial test. Patterns are
+// counted in O(n) memory for any m up to it.
+const SerialMaxBlockLength = 32
 
 // SerialTest implements the NIST Serial test with a fixed block length m.
 // It returns the minimum p-value across the two computed statistics and whether it passes at Alpha.
 func SerialTest(bitstream []byte, m int) (float64, bool) {
-	bits := expandBits(bitstream)
-	n := len(bits)
-	if n == 0 || m < 2 {
-		return 0, false
+	return minPValue(serialTails(bitstream, m))
+}
+
+// SerialPValues returns the two Serial test p-values, derived from the
+// first (del psi^2_m) and second (del^2 psi^2_m) differences respectively.
+func SerialPValues(bitstream []byte, m int) (p1, p2 float64) {
+	tails := serialTails(bitstream, m)
+	if tails == nil {
+		return 0, 0
+	}
+	return tails[0].PValue(), tails[1].PValue()
+}
+
+func serialTails(bitstream []byte, m int) []Tail {
+	n := len(bitstream) * 8
+	if n == 0 || m < 2 || m > SerialMaxBlockLength {
+		return nil
 	}
 
-	psim0 := psi2(bits, m)
-	psim1 := psi2(bits, m-1)
-	psim2 := psi2(bits, m-2)
+	psim0 := psi2(bitstream, m)
+	psim1 := psi2(bitstream, m-1)
+	psim2 := psi2(bitstream, m-2)
 
 	del1 := psim0 - psim1
 	del2 := psim0 - 2.0*psim1 + psim2
 
-	p1 := mathext.GammaIncRegComp(math.Pow(2, float64(m-1))/2.0, del1/2.0)
-	p2 := mathext.GammaIncRegComp(math.Pow(2, float64(m-2))/2.0, del2/2.0)
-
-	pValue := math.Min(p1, p2)
-
-	return pValue, pValue >= Alpha
+	return []Tail{
+		{Kind: GammaTail, A: math.Pow(2, float64(m-1)) / 2.0, X: del1 / 2.0},
+		{Kind: GammaTail, A: math.Pow(2, float64(m-2)) / 2.0, X: del2 / 2.0},
+	}
 }
@@ -1,7 +1,11 @@
 package nist
 
 import (
+	"math"
+	"slices"
 	"testing"
+
+	"gonum.org/v1/gonum/mathext"
 )
 
 func TestSerial(t *testing.T) {
@@ -28,4 +32,122 @@ func TestSerial(t *testing.T) {
 			t.Fatalf("pass flag inconsistent with p-value %.6f", p)
 		}
 	})
+
+	t.Run("p_values_match_minimum", func(t *testing.T) {
+		data := make([]byte, 10000)
+		state := uint64(4242)
+		for i := range data {
+			state = state*6364136223846793005 + 1442695040888963407
+			data[i] = byte(state >> 56)
+		}
+		p1, p2 := SerialPValues(data, 5)
+		p, _ := SerialTest(data, 5)
+		if p != math.Min(p1, p2) {
+			t.Fatalf("expected min(%.6f, %.6f), got %.6f", p1, p2, p)
+		}
+	})
+}
+
+// psi2Bitwise is the original psi_m computation with modular indexing, kept as the
+// reference for patternCounts.
+func psi2Bitwise(bits []uint8, m int) float64 {
+	if m <= 0 {
+		return 0
+	}
+
+	n := len(bits)
+	P := make([]int, (1<<(m+1))-1)
+	for i := 0; i < n; i++ {
+		k := 1
+		for j := 0; j < m; j++ {
+			if bits[(i+j)%n] == 0 {
+				k *= 2
+			} else {
+				k = 2*k + 1
+			}
+		}
+		P[k-1]++
+	}
+
+	sum := 0.0
+	for i := (1 << m) - 1; i < (1<<(m+1))-1; i++ {
+		sum += math.Pow(float64(P[i]), 2)
+	}
+	return sum*math.Pow(2, float64(m))/float64(n) - float64(n)
+}
+
+// serialPValuesBitwise is the original SerialPValues on top of psi2Bitwise.
+func serialPValuesBitwise(bitstream []byte, m int) (p1, p2 float64) {
+	bits := expandBits(bitstream)
+	psim0 := psi2Bitwise(bits, m)
+	psim1 := psi2Bitwise(bits, m-1)
+	psim2 := psi2Bitwise(bits, m-2)
+
+	del1 := psim0 - psim1
+	del2 := psim0 - 2.0*psim1 + psim2
+
+	p1 = mathext.GammaIncRegComp(math.Pow(2, float64(m-1))/2.0, del1/2.0)
+	p2 = mathext.GammaIncRegComp(math.Pow(2, float64(m-2))/2.0, del2/2.0)
+	return p1, p2
+}
+
+func TestSerialMatchesBitwise(t *testing.T) {
+	data := pseudoRandomBytes(125000, 41)
+	for _, m := range []int{2, 3, 9, 16} {
+		p1, p2 := SerialPValues(data, m)
+		w1, w2 := serialPValuesBitwise(data, m)
+		if p1 != w1 || p2 != w2 {
+			t.Fatalf("m=%d: got (%v, %v), bitwise (%v, %v)", m, p1, p2, w1, w2)
+		}
+	}
+}
+
+func TestPatternCounts(t *testing.T) {
+	// Short sequences: windows wrap around, several times when m > n.
+	for _, tc := range []struct {
+		size int
+		m    int
+	}{{1, 3}, {1, 12}, {2, 16}, {1000, 8}, {1000, 21}, {1000, 40}} {
+		data := pseudoRandomBytes(tc.size, uint64(tc.size*64+tc.m))
+		bits := expandBits(data)
+		n := len(bits)
+
+		want := map[uint64]int{}
+		for i := 0; i < n; i++ {
+			var v uint64
+			for j := 0; j < tc.m; j++ {
+				v = v<<1 | uint64(bits[(i+j)%n])
+			}
+			want[v]++
+		}
+		keys := make([]uint64, 0, len(want))
+		for k := range want {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		var got []int
+		patternCounts(data, tc.m, func(count int) { got = append(got, count) })
+		if len(got) != len(keys) {
+			t.Fatalf("n=%d m=%d: %d patterns, want %d", n, tc.m, len(got), len(keys))
+		}
+		for i, k := range keys {
+			if got[i] != want[k] {
+				t.Fatalf("n=%d m=%d: pattern %b counted %d times, want %d", n, tc.m, k, got[i], want[k])
+			}
+		}
+	}
+}
+
+func TestSerialLargeBlockLength(t *testing.T) {
+	data := pseudoRandomBytes(125000, 42)
+	for _, m := range []int{24, 32} {
+		p1, p2 := SerialPValues(data, m)
+		if p1 < 0 || p1 > 1 || p2 < 0 || p2 > 1 {
+			t.Errorf("m=%d: p-values out of range: %v, %v", m, p1, p2)
+		}
+	}
+	if p1, p2 := SerialPValues(data, 33); p1 != 0 || p2 != 0 {
+		t.Errorf("expected rejection for m=33")
+	}
 }
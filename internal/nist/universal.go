@@ -1,77 +1,160 @@
 package nist
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
-// UniversalStatisticalTest implements Maurer's Universal Statistical test.
-// It returns the p-value and whether it passes at Alpha.
+const (
+	// UniversalMinBlockLength and UniversalMaxBlockLength bound the block length L for
+	// which SP 800-22 tabulates the expected value and variance of the statistic.
+	UniversalMinBlockLength = 6
+	UniversalMaxBlockLength = 16
+	// UniversalInitBlocksPerPattern is the minimum number of initialization blocks Q
+	// per possible L-bit pattern: Q >= 10 * 2^L.
+	UniversalInitBlocksPerPattern = 10
+	// UniversalTestBlocksPerPattern is the number of test blocks K per possible L-bit
+	// pattern required by SP 800-22: K >= 1000 * 2^L.
+	UniversalTestBlocksPerPattern = 1000
+)
+
+// Expected value and variance of the Universal statistic for L = 6..16 (SP 800-22
+// Section 2.9.4), indexed by L.
+var (
+	universalExpected = [...]float64{0, 0, 0, 0, 0, 0, 5.2177052, 6.1962507, 7.1836656, 8.1764248, 9.1723243, 10.170032, 11.168765, 12.16807, 13.167693, 14.167488, 15.167379}
+	universalVariance = [...]float64{0, 0, 0, 0, 0, 0, 2.954, 3.125, 3.238, 3.311, 3.356, 3.384, 3.401, 3.41, 3.416, 3.419, 3.421}
+)
+
+// UniversalResult holds the outcome of the Universal Statistical test.
+type UniversalResult struct {
+	PValue float64
+	Passed bool
+	Tail   Tail
+	L      int     // block length
+	Q      int     // initialization blocks
+	K      int     // test blocks
+	Phi    float64 // mean log2 distance f_n
+}
+
+// UniversalStatisticalTest implements Maurer's Universal Statistical test with L chosen
+// from the sequence length and Q = 10 * 2^L. It returns the p-value and whether it
+// passes at Alpha; sequences shorter than 387,840 bits yield (0, false).
 func UniversalStatisticalTest(bitstream []byte) (float64, bool) {
-	bits := expandBits(bitstream)
-	n := len(bits)
-
-	L := 5
-	switch {
-	case n >= 1059061760:
-		L = 16
-	case n >= 496435200:
-		L = 15
-	case n >= 231669760:
-		L = 14
-	case n >= 107560960:
-		L = 13
-	case n >= 49643520:
-		L = 12
-	case n >= 22753280:
-		L = 11
-	case n >= 10342400:
-		L = 10
-	case n >= 4654080:
-		L = 9
-	case n >= 2068480:
-		L = 8
-	case n >= 904960:
-		L = 7
-	case n >= 387840:
-		L = 6
-	default:
-		L = 5
+	res, err := UniversalStatistical(bitstream, 0, 0)
+	if err != nil {
+		return 0, false
 	}
+	return res.PValue, res.Passed
+}
 
-	Q := 10 * (1 << L)
-	K := n/L - Q
-	if L < 6 || L > 16 || Q < 10*(1<<L) || K <= 0 {
-		return 0, false
+// UniversalStatistical runs Maurer's Universal Statistical test with block length L and
+// Q initialization blocks. L = 0 selects the largest L whose bit requirement the
+// sequence meets and Q = 0 uses 10 * 2^L. It returns a *ParameterError if L is outside
+// 6-16 or Q < 10 * 2^L and an *InsufficientBitsError if fewer than 1000 * 2^L test
+// blocks remain after the initialization blocks.
+func UniversalStatistical(bitstream []byte, L, Q int) (*UniversalResult, error) {
+	n := len(bitstream) * 8
+
+	if L == 0 {
+		if L = UniversalBlockLength(n); L == 0 {
+			return nil, universalShort(n, UniversalMinBlockLength, universalMinInitBlocks(UniversalMinBlockLength))
+		}
+	}
+	if L < UniversalMinBlockLength || L > UniversalMaxBlockLength {
+		return nil, &ParameterError{
+			Test:  "universal_statistical",
+			Param: "block_length",
+			Value: L,
+			Min:   UniversalMinBlockLength,
+			Max:   UniversalMaxBlockLength,
+		}
+	}
+	if Q == 0 {
+		Q = universalMinInitBlocks(L)
+	}
+	if Q < universalMinInitBlocks(L) {
+		return nil, &ParameterError{
+			Test:  "universal_statistical",
+			Param: "init_blocks",
+			Value: Q,
+			Min:   universalMinInitBlocks(L),
+		}
+	}
+	if n/L-Q < UniversalTestBlocksPerPattern<<L {
+		return nil, universalShort(n, L, Q)
 	}
 
-	expected := [...]float64{0, 0, 0, 0, 0, 0, 5.2177052, 6.1962507, 7.1836656, 8.1764248, 9.1723243, 10.170032, 11.168765, 12.16807, 13.167693, 14.167488, 15.167379}
-	variance := [...]float64{0, 0, 0, 0, 0, 0, 2.954, 3.125, 3.238, 3.311, 3.356, 3.384, 3.401, 3.41, 3.416, 3.419, 3.421}
+	return universalStatistical(bitstream, L, Q), nil
+}
 
-	T := make([]int, 1<<L)
-	for i := 0; i < len(T); i++ {
-		T[i] = 0
+// UniversalBlockLength returns the block length L that SP 800-22 recommends for a
+// sequence of n bits, i.e. the largest L with n >= L * (10 + 1000) * 2^L, or 0 if n is
+// below the requirement of L = 6.
+func UniversalBlockLength(n int) int {
+	for L := UniversalMaxBlockLength; L >= UniversalMinBlockLength; L-- {
+		if n >= universalMinBits(L, universalMinInitBlocks(L)) {
+			return L
+		}
 	}
+	return 0
+}
 
+// UniversalMinBits returns the length a sequence needs for block length L with Q
+// initialization blocks (0 selects 10 * 2^L) and the minimum number of test blocks.
+func UniversalMinBits(L, Q int) int {
+	if Q == 0 {
+		Q = universalMinInitBlocks(L)
+	}
+	return universalMinBits(L, Q)
+}
+
+func universalMinInitBlocks(L int) int {
+	return UniversalInitBlocksPerPattern << L
+}
+
+// universalMinBits returns the bits needed for Q initialization blocks and the minimum
+// number of test blocks of length L.
+func universalMinBits(L, Q int) int {
+	return L * (Q + UniversalTestBlocksPerPattern<<L)
+}
+
+func universalShort(n, L, Q int) *InsufficientBitsError {
+	return &InsufficientBitsError{
+		Test:   "universal_statistical",
+		Bits:   n,
+		Need:   universalMinBits(L, Q),
+		Reason: fmt.Sprintf("L = %d: %d initialization and %d test blocks", L, Q, UniversalTestBlocksPerPattern<<L),
+	}
+}
+
+// universalStatistical computes the test statistic for validated L and Q.
+func universalStatistical(bitstream []byte, L, Q int) *UniversalResult {
+	K := len(bitstream)*8/L - Q
+
+	T := make([]int, 1<<L)
 	for i := 1; i <= Q; i++ {
-		decRep := 0
-		for j := 0; j < L; j++ {
-			decRep = decRep*2 + int(bits[(i-1)*L+j])
-		}
-		T[decRep] = i
+		T[readBits(bitstream, (i-1)*L, L)] = i
 	}
 
 	sum := 0.0
 	for i := Q + 1; i <= Q+K; i++ {
-		decRep := 0
-		for j := 0; j < L; j++ {
-			decRep = decRep*2 + int(bits[(i-1)*L+j])
-		}
+		decRep := readBits(bitstream, (i-1)*L, L)
 		sum += math.Log(float64(i-T[decRep])) / math.Log(2)
 		T[decRep] = i
 	}
 
 	phi := sum / float64(K)
-	sigma := (0.7 - 0.8/float64(L) + (4+32/float64(L))*math.Pow(float64(K), -3/float64(L))/15) * math.Sqrt(variance[L]/float64(K))
-	arg := math.Abs(phi-expected[L]) / (math.Sqrt2 * sigma)
-	pValue := math.Erfc(arg)
+	sigma := (0.7 - 0.8/float64(L) + (4+32/float64(L))*math.Pow(float64(K), -3/float64(L))/15) * math.Sqrt(universalVariance[L]/float64(K))
+	tail := Tail{Kind: ErfcTail, X: math.Abs(phi-universalExpected[L]) / (math.Sqrt2 * sigma)}
+	pValue := tail.PValue()
 
-	return pValue, pValue >= Alpha
+	return &UniversalResult{
+		PValue: pValue,
+		Passed: pValue >= Alpha,
+		Tail:   tail,
+		L:      L,
+		Q:      Q,
+		K:      K,
+		Phi:    phi,
+	}
 }
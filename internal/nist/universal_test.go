@@ -1,6 +1,9 @@
 package nist
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"testing"
 )
 
@@ -24,3 +27,133 @@ func TestUniversalStatistical(t *testing.T) {
 		}
 	})
 }
+
+// universalStatisticalTestBitwise is the original implementation on expanded bits, kept
+// as the reference for the packed block reads.
+func universalStatisticalTestBitwise(bitstream []byte) (float64, bool) {
+	bits := expandBits(bitstream)
+	n := len(bits)
+
+	L := UniversalBlockLength(n)
+	if L == 0 {
+		return 0, false
+	}
+	Q := 10 * (1 << L)
+	K := n/L - Q
+
+	T := make([]int, 1<<L)
+	for i := 1; i <= Q; i++ {
+		decRep := 0
+		for j := 0; j < L; j++ {
+			decRep = decRep*2 + int(bits[(i-1)*L+j])
+		}
+		T[decRep] = i
+	}
+
+	sum := 0.0
+	for i := Q + 1; i <= Q+K; i++ {
+		decRep := 0
+		for j := 0; j < L; j++ {
+			decRep = decRep*2 + int(bits[(i-1)*L+j])
+		}
+		sum += math.Log(float64(i-T[decRep])) / math.Log(2)
+		T[decRep] = i
+	}
+
+	phi := sum / float64(K)
+	sigma := (0.7 - 0.8/float64(L) + (4+32/float64(L))*math.Pow(float64(K), -3/float64(L))/15) * math.Sqrt(universalVariance[L]/float64(K))
+	pValue := math.Erfc(math.Abs(phi-universalExpected[L]) / (math.Sqrt2 * sigma))
+	return pValue, pValue >= Alpha
+}
+
+func TestUniversalMatchesBitwise(t *testing.T) {
+	for _, size := range []int{48480, 125000, 300000} {
+		data := pseudoRandomBytes(size, uint64(size))
+		got, gotPass := UniversalStatisticalTest(data)
+		want, wantPass := universalStatisticalTestBitwise(data)
+		if got != want || gotPass != wantPass {
+			t.Fatalf("%d bytes: packed p=%v, bitwise p=%v", size, got, want)
+		}
+	}
+}
+
+func TestUniversalBlockLength(t *testing.T) {
+	// Thresholds of SP 800-22 Section 2.9.7.
+	thresholds := []int{387840, 904960, 2068480, 4654080, 10342400, 22753280, 49643520, 107560960, 231669760, 496435200, 1059061760}
+	if got := UniversalBlockLength(thresholds[0] - 1); got != 0 {
+		t.Errorf("below L = 6: got L=%d", got)
+	}
+	for i, n := range thresholds {
+		L := UniversalMinBlockLength + i
+		if got := UniversalBlockLength(n); got != L {
+			t.Errorf("n=%d: got L=%d, want %d", n, got, L)
+		}
+		if got := UniversalBlockLength(n - 1); got != L-1 && L > UniversalMinBlockLength {
+			t.Errorf("n=%d: got L=%d, want %d", n-1, got, L-1)
+		}
+	}
+}
+
+func TestUniversalMinBits(t *testing.T) {
+	if got := UniversalMinBits(10, 0); got != 10342400 {
+		t.Errorf("L=10: got %d bits, want 10342400", got)
+	}
+	if got := UniversalMinBits(6, 1000); got != 6*(1000+64000) {
+		t.Errorf("L=6, Q=1000: got %d bits, want %d", got, 6*(1000+64000))
+	}
+}
+
+func TestUniversalEachBlockLength(t *testing.T) {
+	maxL := UniversalMaxBlockLength
+	if testing.Short() {
+		maxL = 12
+	}
+	for L := UniversalMinBlockLength; L <= maxL; L++ {
+		t.Run(fmt.Sprintf("L=%d", L), func(t *testing.T) {
+			n := L * (11 << L) * 100 // Q + K = 1100 * 2^L blocks
+			data := pseudoRandomBytes(n/8, uint64(L)*0x9e3779b97f4a7c15)
+
+			res, err := UniversalStatistical(data, L, 0)
+			if err != nil {
+				t.Fatalf("UniversalStatistical failed: %v", err)
+			}
+			if res.L != L || res.Q != 10<<L || res.K != 1090<<L {
+				t.Fatalf("unexpected blocks: L=%d Q=%d K=%d", res.L, res.Q, res.K)
+			}
+			// For random blocks f_n is close to the tabulated expected value.
+			if math.Abs(res.Phi-universalExpected[L]) > 0.01 || res.PValue < 1e-4 {
+				t.Errorf("phi=%v (expected %v), p=%v", res.Phi, universalExpected[L], res.PValue)
+			}
+		})
+	}
+}
+
+func TestUniversalParameters(t *testing.T) {
+	data := pseudoRandomBytes(125000, 5) // 1,000,000 bits: L = 7
+
+	res, err := UniversalStatistical(data, 6, 2000)
+	if err != nil {
+		t.Fatalf("UniversalStatistical failed: %v", err)
+	}
+	if res.Q != 2000 || res.K != 1000000/6-2000 {
+		t.Errorf("unexpected blocks: Q=%d K=%d", res.Q, res.K)
+	}
+
+	for _, c := range []struct{ L, Q int }{{5, 0}, {17, 0}, {7, 1279}} {
+		var perr *ParameterError
+		if _, err := UniversalStatistical(data, c.L, c.Q); !errors.As(err, &perr) {
+			t.Errorf("L=%d Q=%d: expected *ParameterError, got %v", c.L, c.Q, err)
+		}
+	}
+
+	var short *InsufficientBitsError
+	if _, err := UniversalStatistical(data, 8, 0); !errors.As(err, &short) {
+		t.Fatalf("expected *InsufficientBitsError, got %v", err)
+	}
+	if short.Need != 2068480 {
+		t.Errorf("need %d bits, want 2068480", short.Need)
+	}
+	if _, err := UniversalStatistical(data[:1000], 0, 0); !errors.As(err, &short) || short.Need != MinBits {
+		t.Errorf("expected *InsufficientBitsError for %d bits, got %v", MinBits, err)
+	}
+}
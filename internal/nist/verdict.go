@@ -0,0 +1,79 @@
+package nist
+
+import "math"
+
+// ProportionSigma is the width in standard deviations of the acceptance range of the
+// proportion of passing p-values (SP 800-22 section 4.2.1).
+const ProportionSigma = 3
+
+// ProportionLimit returns the lower limit p̂ - sigma·sqrt(p̂(1-p̂)/n) of the proportion of
+// p-values >= Alpha out of n, with p̂ = 1 - Alpha.
+func ProportionLimit(n int, sigma float64) float64 {
+	p := 1 - Alpha
+	return p - sigma*math.Sqrt(p*(1-p)/float64(n))
+}
+
+// ProportionFailed reports whether passed of n p-values >= Alpha is a proportion below
+// the acceptance range. It is the only verdict rule: it judges the sub-tests of one
+// test on one sequence (SubTestsFailed) as well as the p-values of one test over many
+// sequences.
+func ProportionFailed(passed, n int) bool {
+	return n > 0 && float64(passed)/float64(n) < ProportionLimit(n, ProportionSigma)
+}
+
+// SubTestPValues returns one p-value per sub-test: the p-value of every tail for tests
+// that report several (e.g. one per template or excursion state), else PValue. Skipped
+// results (PValue < 0) have none.
+func (r TestResult) SubTestPValues() []float64 {
+	if r.PValue < 0 {
+		return nil
+	}
+	if len(r.Tails) > 1 {
+		return pValues(r.Tails)
+	}
+	return []float64{r.PValue}
+}
+
+// Failed reports whether the sub-tests of r reject randomness, see SubTestsFailed.
+// Skipped results do not fail.
+func (r TestResult) Failed() bool {
+	return SubTestsFailed(r.SubTestPValues())
+}
+
+// SubTestsFailed is the verdict of one test on one sequence. SP 800-22 judges every
+// p-value on its own, which fails most random sequences by the smallest of many
+// sub-tests (1 - 0.99^148 = 77% for the 148 templates). The p-values of the sub-tests
+// are instead judged like the p-values of many sequences in section 4.2.1: the test
+// fails if the proportion of them >= Alpha is below the acceptance range. With up to 9
+// sub-tests this requires every p-value to be >= Alpha, as SP 800-22 does; the 148
+// templates fail with 6 or more p-values below Alpha.
+func SubTestsFailed(pValues []float64) bool {
+	return ProportionFailed(passedCount(pValues), len(pValues))
+}
+
+// SuitePassed is the verdict of a run: at least one test of results ran and none of
+// them failed (see SubTestsFailed). Skipped results do not fail.
+func SuitePassed(results []TestResult) bool {
+	ran := false
+	for _, r := range results {
+		if r.PValue < 0 {
+			continue
+		}
+		if r.Failed() {
+			return false
+		}
+		ran = true
+	}
+	return ran
+}
+
+// passedCount returns the number of p-values >= Alpha.
+func passedCount(pValues []float64) int {
+	passed := 0
+	for _, p := range pValues {
+		if p >= Alpha {
+			passed++
+		}
+	}
+	return passed
+}
@@ -0,0 +1,126 @@
+package nist
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSubTestsFailed(t *testing.T) {
+	repeat := func(p float64, n int) []float64 {
+		ps := make([]float64, n)
+		for i := range ps {
+			ps[i] = p
+		}
+		return ps
+	}
+	with := func(ps []float64, values ...float64) []float64 {
+		return append(append([]float64(nil), ps...), values...)
+	}
+
+	tests := []struct {
+		name    string
+		pValues []float64
+		want    bool
+	}{
+		{"none", nil, false},
+		{"single pass", []float64{0.01}, false},
+		{"single fail", []float64{0.0099}, true},
+		{"one of two below alpha", []float64{0.005, 0.5}, true},
+		{"one of 8 below alpha", with(repeat(0.5, 7), 0.005), true},
+		{"one of 10 below alpha", with(repeat(0.5, 9), 0.005), false},
+		{"one of 18 below alpha", with(repeat(0.5, 17), 0.005), false},
+		{"two of 18 below alpha", with(repeat(0.5, 16), 0.005, 0.005), true},
+		{"five of 148 below alpha", with(repeat(0.5, 143), repeat(0.005, 5)...), false},
+		{"six of 148 below alpha", with(repeat(0.5, 142), repeat(0.005, 6)...), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SubTestsFailed(tt.pValues); got != tt.want {
+				t.Errorf("SubTestsFailed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubTestsFailedRate(t *testing.T) {
+	// Independent uniform p-values of 148 sub-tests, as for the non-overlapping template
+	// test, fail at about the rate of a single test instead of 1 - 0.99^148 = 77%.
+	rng := rand.New(rand.NewSource(1))
+	const trials = 5000
+	failed := 0
+	ps := make([]float64, 148)
+	for i := 0; i < trials; i++ {
+		for j := range ps {
+			ps[j] = rng.Float64()
+		}
+		if SubTestsFailed(ps) {
+			failed++
+		}
+	}
+	if rate := float64(failed) / trials; rate > 2*Alpha {
+		t.Errorf("false failure rate %.4f, want at most %.2f", rate, 2*Alpha)
+	}
+}
+
+func TestResultFailed(t *testing.T) {
+	skipped := TestResult{Name: "random_excursions", PValue: -1}
+	if skipped.SubTestPValues() != nil || skipped.Failed() {
+		t.Error("skipped result must have no sub-tests and must not fail")
+	}
+
+	single := TestResult{Name: "runs", PValue: 0.001, Tails: []Tail{{Kind: ErfcTail, X: 2.3}}}
+	if ps := single.SubTestPValues(); len(ps) != 1 || ps[0] != 0.001 || !single.Failed() {
+		t.Errorf("single result: p-values %v, failed %v", ps, single.Failed())
+	}
+
+	// Two tails, one with p = erfc(1.9) ≈ 0.0072 below Alpha
+	multi := TestResult{Name: "serial", PValue: 0.0072, Tails: []Tail{{Kind: ErfcTail, X: 1.9}, {Kind: ErfcTail, X: 0.1}}}
+	if ps := multi.SubTestPValues(); len(ps) != 2 || !multi.Failed() {
+		t.Errorf("multi result: p-values %v, failed %v", ps, multi.Failed())
+	}
+}
+
+func TestProportionLimit(t *testing.T) {
+	// SP 800-22 section 4.2.1: 0.99 ± 3·sqrt(0.99·0.01/1000) = 0.980561 for 1000 sequences
+	if got := ProportionLimit(1000, 3); math.Abs(got-0.980561) > 1e-6 {
+		t.Errorf("ProportionLimit(1000, 3) = %v, want 0.980561", got)
+	}
+	if ProportionLimit(100, 2) <= ProportionLimit(100, 3) {
+		t.Error("a narrower range must have a higher lower limit")
+	}
+	if ProportionFailed(0, 0) || !ProportionFailed(980, 1000) || ProportionFailed(981, 1000) {
+		t.Error("unexpected verdict at the limit of 1000 samples")
+	}
+}
+
+func TestSuitePassed(t *testing.T) {
+	skipped := TestResult{Name: "random_excursions", PValue: -1}
+	passed := TestResult{Name: "runs", PValue: 0.5, Tails: []Tail{{Kind: ErfcTail, X: 0.5}}}
+	failed := TestResult{Name: "frequency_monobit", PValue: 0.001, Tails: []Tail{{Kind: ErfcTail, X: 2.3}}}
+	// One of ten sub-tests below Alpha is within the proportion range
+	tails := []Tail{{Kind: ErfcTail, X: 1.9}}
+	for i := 0; i < 9; i++ {
+		tails = append(tails, Tail{Kind: ErfcTail, X: 0.1})
+	}
+	subTests := TestResult{Name: "cumulative_sums", PValue: tails[0].PValue(), Tails: tails}
+
+	tests := []struct {
+		name    string
+		results []TestResult
+		want    bool
+	}{
+		{"none", nil, false},
+		{"only skipped", []TestResult{skipped}, false},
+		{"passed and skipped", []TestResult{passed, skipped}, true},
+		{"one failed", []TestResult{passed, failed}, false},
+		{"sub-test below Alpha", []TestResult{passed, subTests}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SuitePassed(tt.results); got != tt.want {
+				t.Errorf("SuitePassed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package notify sends alerts about failed analyses and drifting sources to outbound
+// notifiers such as an HTTP webhook or a JSON-lines file. Events are matched against
+// the configured rules and delivered asynchronously, so a slow receiver never delays a
+// request.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/metrics"
+)
+
+// Event kinds.
+const (
+	// KindRunFailed reports an analysis in which at least one test failed.
+	KindRunFailed = "run_failed"
+	// KindSourceState reports a change of the drift state of a source.
+	KindSourceState = "source_state"
+)
+
+// Rule names accepted by ParseRules.
+const (
+	// RuleRunFailed matches KindRunFailed events with at least Rules.RunMinFailures failed
+	// tests. It is not enabled by default: more than one in ten runs of a good source fails a
+	// test at the significance level of 0.01.
+	RuleRunFailed = "run_failed"
+	// RuleSourceWarn matches a source entering the WARN state.
+	RuleSourceWarn = "source_warn"
+	// RuleSourceFail matches a source entering the FAIL state.
+	RuleSourceFail = "source_fail"
+	// RuleSourceRecovered matches a source returning to OK from WARN or FAIL.
+	RuleSourceRecovered = "source_recovered"
+)
+
+// Drift states as reported in Event.State and Event.PreviousState.
+const (
+	StateOK   = "OK"
+	StateWarn = "WARN"
+	StateFail = "FAIL"
+)
+
+// Event is one notification. It is sent as JSON.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	RequestID string    `json:"request_id,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	// Source is the source label of the analysis or the drifting source.
+	Source string `json:"source,omitempty"`
+
+	// PassRate and FailedTests describe a failed run.
+	PassRate    float64  `json:"pass_rate,omitempty"`
+	FailedTests []string `json:"failed_tests,omitempty"`
+
+	// State and PreviousState describe a drift state change; Tests lists the tests
+	// that are not OK.
+	State         string   `json:"state,omitempty"`
+	PreviousState string   `json:"previous_state,omitempty"`
+	Tests         []string `json:"tests,omitempty"`
+}
+
+// Notifier delivers events to one destination.
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// Rules select the events that are sent.
+type Rules struct {
+	// RunMinFailures is the number of failed tests from which a run is reported; 0
+	// disables run notifications.
+	RunMinFailures  int
+	SourceWarn      bool
+	SourceFail      bool
+	SourceRecovered bool
+}
+
+// ParseRules returns the rules for the given rule names. runMinFailures applies if
+// RuleRunFailed is among them.
+func ParseRules(names []string, runMinFailures int) (Rules, error) {
+	var r Rules
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case RuleRunFailed:
+			if runMinFailures < 1 {
+				return Rules{}, fmt.Errorf("minimum number of failed tests must be at least 1, got %d", runMinFailures)
+			}
+			r.RunMinFailures = runMinFailures
+		case RuleSourceWarn:
+			r.SourceWarn = true
+		case RuleSourceFail:
+			r.SourceFail = true
+		case RuleSourceRecovered:
+			r.SourceRecovered = true
+		case "":
+		default:
+			return Rules{}, fmt.Errorf("unknown notification rule: %s", name)
+		}
+	}
+	return r, nil
+}
+
+// Match reports whether e is selected by the rules.
+func (r Rules) Match(e Event) bool {
+	switch e.Kind {
+	case KindRunFailed:
+		return r.RunMinFailures > 0 && len(e.FailedTests) >= r.RunMinFailures
+	case KindSourceState:
+		switch e.State {
+		case StateWarn:
+			return r.SourceWarn
+		case StateFail:
+			return r.SourceFail
+		case StateOK:
+			return r.SourceRecovered && (e.PreviousState == StateWarn || e.PreviousState == StateFail)
+		}
+	}
+	return false
+}
+
+// DefaultQueueSize is the number of events a Dispatcher buffers for delivery.
+const DefaultQueueSize = 100
+
+// Dispatcher delivers the events that match its rules to every notifier, one event at
+// a time in the background. Events are dropped while the queue is full.
+type Dispatcher struct {
+	mu    sync.Mutex
+	rules Rules
+
+	notifiers []Notifier
+	timeout   time.Duration
+
+	queue     chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewDispatcher starts a dispatcher. timeout bounds the delivery of one event to one
+// notifier, including its retries.
+func NewDispatcher(rules Rules, timeout time.Duration, notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{
+		rules:     rules,
+		notifiers: notifiers,
+		timeout:   timeout,
+		queue:     make(chan Event, DefaultQueueSize),
+		done:      make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// SetRules replaces the rules for the events dispatched from now on.
+func (d *Dispatcher) SetRules(rules Rules) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rules = rules
+}
+
+// Dispatch queues e if it matches the rules and reports whether it was queued.
+func (d *Dispatcher) Dispatch(e Event) bool {
+	d.mu.Lock()
+	rules := d.rules
+	d.mu.Unlock()
+	if !rules.Match(e) {
+		return false
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	select {
+	case d.queue <- e:
+		return true
+	default:
+		metrics.RecordNotification(e.Kind, "dropped")
+		log.Warn().
+			Str("kind", e.Kind).
+			Str("source", e.Source).
+			Msg("Notification queue full, event dropped")
+		return false
+	}
+}
+
+// Close delivers the queued events and stops the dispatcher. Dispatch must not be
+// called after Close.
+func (d *Dispatcher) Close() error {
+	d.closeOnce.Do(func() { close(d.queue) })
+	<-d.done
+
+	var errs []error
+	for _, n := range d.notifiers {
+		if c, ok := n.(interface{ Close() error }); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	for e := range d.queue {
+		for _, n := range d.notifiers {
+			d.deliver(n, e)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(n Notifier, e Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	if err := n.Notify(ctx, e); err != nil {
+		metrics.RecordNotification(e.Kind, "error")
+		log.Error().
+			Err(err).
+			Str("kind", e.Kind).
+			Str("source", e.Source).
+			Str("request_id", e.RequestID).
+			Msg("Notification delivery failed")
+		return
+	}
+	metrics.RecordNotification(e.Kind, "success")
+}
@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	r, err := ParseRules([]string{"run_failed", " source_fail ", "source_recovered"}, 2)
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if want := (Rules{RunMinFailures: 2, SourceFail: true, SourceRecovered: true}); r != want {
+		t.Errorf("ParseRules = %+v, want %+v", r, want)
+	}
+
+	if r, err := ParseRules(nil, 0); err != nil || r != (Rules{}) {
+		t.Errorf("ParseRules(nil) = %+v, %v; want no rules", r, err)
+	}
+	if _, err := ParseRules([]string{"run_passed"}, 1); err == nil {
+		t.Error("expected error for an unknown rule")
+	}
+	if _, err := ParseRules([]string{"run_failed"}, 0); err == nil {
+		t.Error("expected error for run_failed without a minimum number of failures")
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	r := Rules{RunMinFailures: 2, SourceFail: true, SourceRecovered: true}
+	tests := []struct {
+		name  string
+		event Event
+		want  bool
+	}{
+		{"run below minimum", Event{Kind: KindRunFailed, FailedTests: []string{"runs"}}, false},
+		{"run at minimum", Event{Kind: KindRunFailed, FailedTests: []string{"runs", "serial"}}, true},
+		{"source warn", Event{Kind: KindSourceState, State: StateWarn, PreviousState: StateOK}, false},
+		{"source fail", Event{Kind: KindSourceState, State: StateFail, PreviousState: StateWarn}, true},
+		{"source recovered", Event{Kind: KindSourceState, State: StateOK, PreviousState: StateFail}, true},
+		{"source first evaluated", Event{Kind: KindSourceState, State: StateOK, PreviousState: "UNKNOWN"}, false},
+		{"unknown kind", Event{Kind: "other"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Match(tt.event); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// recorder is a notifier that keeps the events it receives.
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+	closed bool
+}
+
+func (r *recorder) Notify(_ context.Context, e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return r.err
+}
+
+func (r *recorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	ok := &recorder{}
+	failing := &recorder{err: errors.New("unreachable")}
+	d := NewDispatcher(Rules{RunMinFailures: 1}, time.Second, ok, failing)
+
+	if d.Dispatch(Event{Kind: KindSourceState, State: StateFail}) {
+		t.Error("event not selected by the rules was queued")
+	}
+	if !d.Dispatch(Event{Kind: KindRunFailed, Source: "trng", FailedTests: []string{"runs"}}) {
+		t.Fatal("matching event was not queued")
+	}
+	d.SetRules(Rules{SourceFail: true})
+	if d.Dispatch(Event{Kind: KindRunFailed, Source: "trng", FailedTests: []string{"runs"}}) {
+		t.Error("event queued after its rule was removed")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A failing notifier does not keep the event from the others.
+	for _, r := range []*recorder{ok, failing} {
+		if len(r.events) != 1 || r.events[0].Source != "trng" || r.events[0].Time.IsZero() {
+			t.Errorf("events = %+v, want the run_failed event with its time", r.events)
+		}
+		if !r.closed {
+			t.Error("notifier not closed")
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	e := Event{Kind: KindSourceState, Source: "trng", State: StateFail, PreviousState: StateOK, Tests: []string{"runs"}}
+	if err := w.Notify(context.Background(), e); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "}\n") || strings.Count(buf.String(), "\n") != 1 {
+		t.Fatalf("expected one JSON line, got %q", buf.String())
+	}
+	var got Event
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.State != StateFail || got.Source != "trng" || len(got.Tests) != 1 {
+		t.Errorf("decoded %+v", got)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	for i := 0; i < 2; i++ {
+		w, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile failed: %v", err)
+		}
+		if err := w.Notify(context.Background(), Event{Kind: KindRunFailed}); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("expected the file to be appended to, got %d lines", n)
+	}
+
+	if _, err := OpenFile(filepath.Join(t.TempDir(), "missing", "file")); err == nil {
+		t.Error("expected error for a file in a missing directory")
+	}
+}
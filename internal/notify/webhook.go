@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers set on webhook requests.
+const (
+	// EventHeader carries Event.Kind.
+	EventHeader = "X-Nist-Event"
+	// TimestampHeader carries the Unix time of the delivery attempt in seconds.
+	TimestampHeader = "X-Nist-Timestamp"
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the timestamp, a dot
+	// and the body, keyed with the webhook secret. It is omitted without a secret.
+	SignatureHeader = "X-Nist-Signature"
+)
+
+// Webhook posts events as JSON to an HTTP endpoint. Failed deliveries (transport
+// errors, 429 and 5xx responses) are retried with exponential backoff.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+	// maxRetries is the number of retries after the first attempt.
+	maxRetries int
+	// backoff is the wait before the first retry; it doubles with every retry.
+	backoff time.Duration
+}
+
+// DefaultWebhookBackoff is the wait before the first retry of a webhook delivery.
+const DefaultWebhookBackoff = time.Second
+
+// NewWebhook returns a webhook notifier for url. An empty secret disables signing.
+func NewWebhook(url, secret string, maxRetries int, backoff time.Duration) *Webhook {
+	return &Webhook{
+		url:        url,
+		secret:     []byte(secret),
+		client:     &http.Client{},
+		maxRetries: maxRetries,
+		backoff:    backoff,
+	}
+}
+
+// Notify posts e, retrying until it is accepted, the retries are exhausted or ctx ends.
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(ctx, e.Kind, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.maxRetries {
+			return fmt.Errorf("webhook delivery failed after %d attempts: %w", attempt+1, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("webhook delivery failed after %d attempts: %w", attempt+1, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure may be retried.
+func (w *Webhook) post(ctx context.Context, kind string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, kind)
+	req.Header.Set(TimestampHeader, timestamp)
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, timestamp, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// Sign returns the hex HMAC-SHA256 of timestamp, a dot and body keyed with secret, as
+// sent in SignatureHeader. Receivers recompute it to authenticate a delivery and should
+// reject stale timestamps.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhook(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt fails with a retryable status.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		want := "sha256=" + Sign([]byte("s3cret"), r.Header.Get(TimestampHeader), body)
+		if got := r.Header.Get(SignatureHeader); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if got := r.Header.Get(EventHeader); got != KindRunFailed {
+			t.Errorf("event header = %q", got)
+		}
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil || e.Source != "trng" {
+			t.Errorf("decoded %+v, %v", e, err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(srv.URL, "s3cret", 2, time.Millisecond)
+	if err := wh.Notify(context.Background(), Event{Kind: KindRunFailed, Source: "trng"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int32
+	}{
+		{"server error is retried", http.StatusInternalServerError, 3},
+		{"rate limit is retried", http.StatusTooManyRequests, 3},
+		{"client error is not retried", http.StatusBadRequest, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if r.Header.Get(SignatureHeader) != "" {
+					t.Error("unexpected signature without a secret")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			wh := NewWebhook(srv.URL, "", 2, time.Millisecond)
+			if err := wh.Notify(context.Background(), Event{Kind: KindRunFailed}); err == nil {
+				t.Fatal("expected delivery error")
+			}
+			if n := calls.Load(); n != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, n)
+			}
+		})
+	}
+}
+
+func TestWebhookContextEndsBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := NewWebhook(srv.URL, "", 5, time.Hour).Notify(ctx, Event{Kind: KindRunFailed}); err == nil {
+		t.Fatal("expected delivery error")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("backoff not interrupted by the context, took %v", elapsed)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.{}" keyed with "key", computed independently.
+	const want = "9d713ed406bb7076d4123f0dc2c39d2df5c654ed4b0cd56b52c8b4c940bd63ae"
+	if got := Sign([]byte("key"), "1700000000", []byte("{}")); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
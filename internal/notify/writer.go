@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Stdout is the path that makes OpenFile write to standard output.
+const Stdout = "stdout"
+
+// Writer writes each event as one JSON line, for local testing of the rules or for
+// log shippers that forward the file.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+	c  io.Closer
+}
+
+// NewWriter returns a notifier that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// OpenFile returns a notifier that appends to the file at path, or writes to standard
+// output if path is Stdout.
+func OpenFile(path string) (*Writer, error) {
+	if path == Stdout {
+		return NewWriter(os.Stdout), nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open notification file: %w", err)
+	}
+	return &Writer{w: f, c: f}, nil
+}
+
+// Notify writes e.
+func (w *Writer) Notify(_ context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.w.Write(line); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file opened by OpenFile.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.c == nil {
+		return nil
+	}
+	return w.c.Close()
+}
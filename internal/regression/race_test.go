@@ -0,0 +1,8 @@
+//go:build race
+
+package regression
+
+func init() {
+	// The race detector slows the battery down about tenfold; keep to the short samples.
+	raceEnabled = true
+}
@@ -0,0 +1,286 @@
+// Package regression checks every p-value of the 15 SP 800-22 tests, including each
+// sub-test, against snapshots frozen from this implementation. The snapshots live in
+// testdata as one JSON file per bit source and are computed from the NIST sample data
+// sets and the Appendix D generators, so no data files need to be shipped. They catch
+// unintended changes of the computed values but are not independent of them. The
+// independent checks are the Appendix B values of the sample data sets, against which
+// the 10^6-bit snapshots are verified, and the outputs of the NIST C suite in
+// testdata/sts, frozen with tools/validate_nist_go_vs_c.go -freeze.
+package regression
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/generators"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/selftest"
+)
+
+// DefaultTolerance is the absolute p-value difference accepted against the frozen
+// snapshots. Go may fuse multiply-adds on arm64, ppc64le and s390x, and the incomplete
+// gamma function amplifies such rounding differences for large degrees of freedom, so
+// the snapshots of one platform do not reproduce bit for bit on another. The tolerance
+// is a tenth of the six decimal places of Appendix B and of the C suite: it absorbs the
+// rounding differences and catches every change visible at the published precision.
+const DefaultTolerance = 1e-7
+
+// STSOrigin is the origin of the reference files in testdata/sts, which hold p-values
+// written by the NIST C suite (STS 2.1.2) to its results.txt. The suite writes six
+// decimal places, so they are compared with a tolerance of at least 1e-6.
+const STSOrigin = "NIST STS 2.1.2 results.txt, converted with tools/validate_nist_go_vs_c.go -freeze"
+
+// Test computes all p-values of one SP 800-22 test with the parameters of the NIST C
+// suite, in the order in which the suite writes them to its results.txt.
+type Test struct {
+	// Name is the registry name of the test.
+	Name string
+	// STSDir is the directory of the test below experiments/AlgorithmTesting in the
+	// NIST C suite.
+	STSDir string
+	run    func(bitstream []byte) ([]float64, error)
+}
+
+// Tests lists the 15 tests in the order of the specification.
+var Tests = []Test{
+	{"frequency_monobit", "Frequency", one(nist.FrequencyTest)},
+	{"block_frequency", "BlockFrequency", one(func(b []byte) (float64, bool) { return nist.BlockFrequencyTest(b, 128) })},
+	{"cumulative_sums", "CumulativeSums", func(b []byte) ([]float64, error) {
+		forward, reverse := nist.CumulativeSumsPValues(b)
+		return []float64{forward, reverse}, nil
+	}},
+	{"runs", "Runs", one(nist.RunsTest)},
+	{"longest_run", "LongestRun", one(nist.LongestRunOfOnesTest)},
+	{"binary_matrix_rank", "Rank", one(nist.BinaryMatrixRankTest)},
+	{"discrete_fourier_transform", "FFT", one(nist.DiscreteFourierTransformTest)},
+	{"non_overlapping_template", "NonOverlappingTemplate", func(b []byte) ([]float64, error) {
+		return nonEmpty(nist.NonOverlappingTemplatePValues(b, 9), "fewer bits than 8 blocks of 9")
+	}},
+	{"overlapping_template", "OverlappingTemplate", func(b []byte) ([]float64, error) {
+		// The C suite approximates the class probabilities; compare against the same values.
+		res, err := nist.OverlappingTemplateReference(b, 9)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{res.PValue}, nil
+	}},
+	{"universal_statistical", "Universal", func(b []byte) ([]float64, error) {
+		res, err := nist.UniversalStatistical(b, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{res.PValue}, nil
+	}},
+	{"approximate_entropy", "ApproximateEntropy", func(b []byte) ([]float64, error) {
+		res, err := nist.ApproximateEntropy(b, 10)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{res.PValue}, nil
+	}},
+	{"random_excursions", "RandomExcursions", func(b []byte) ([]float64, error) {
+		return nonEmpty(nist.RandomExcursionsPValues(b), "fewer than 500 cycles")
+	}},
+	{"random_excursions_variant", "RandomExcursionsVariant", func(b []byte) ([]float64, error) {
+		return nonEmpty(nist.RandomExcursionsVariantPValues(b), "fewer than 500 cycles")
+	}},
+	{"serial", "Serial", func(b []byte) ([]float64, error) {
+		p1, p2 := nist.SerialPValues(b, 16)
+		return []float64{p1, p2}, nil
+	}},
+	{"linear_complexity", "LinearComplexity", func(b []byte) ([]float64, error) {
+		// The C suite uses rounded class probabilities; compare against the same table.
+		res, err := nist.LinearComplexityReference(b, 500)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{res.PValue}, nil
+	}},
+}
+
+// Variants lists the production variants of tests whose entry in Tests reproduces the
+// NIST C suite: the exact class probabilities of Linear Complexity and Overlapping
+// Template that the server uses. They have no counterpart in the C suite.
+var Variants = []Test{
+	{"overlapping_template_exact", "", func(b []byte) ([]float64, error) {
+		res, err := nist.OverlappingTemplate(b, nist.OnesTemplate(9),
+			nist.OverlappingTemplateBlockLength, nist.OverlappingTemplateDegreesOfFreedom)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{res.PValue}, nil
+	}},
+	{"linear_complexity_exact", "", func(b []byte) ([]float64, error) {
+		res, err := nist.LinearComplexity(b, 500)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{res.PValue}, nil
+	}},
+}
+
+func one(test func([]byte) (float64, bool)) func([]byte) ([]float64, error) {
+	return func(b []byte) ([]float64, error) {
+		p, _ := test(b)
+		return []float64{p}, nil
+	}
+}
+
+func nonEmpty(pValues []float64, reason string) ([]float64, error) {
+	if len(pValues) == 0 {
+		return nil, errors.New(reason)
+	}
+	return pValues, nil
+}
+
+// Result holds the p-values of one test. A test that cannot be evaluated on the
+// sample, e.g. Random Excursions without enough cycles, has no p-values and the reason
+// in Skipped.
+type Result struct {
+	Test    string    `json:"test"`
+	PValues []float64 `json:"p_values,omitempty"`
+	Skipped string    `json:"skipped,omitempty"`
+}
+
+// Compute runs every test on bitstream and returns the results in the order of Tests,
+// followed by the results of Variants.
+func Compute(bitstream []byte) []Result {
+	tests := append(append([]Test(nil), Tests...), Variants...)
+	results := make([]Result, len(tests))
+	for i, t := range tests {
+		results[i].Test = t.Name
+		pValues, err := t.run(bitstream)
+		if err != nil {
+			results[i].Skipped = err.Error()
+			continue
+		}
+		results[i].PValues = pValues
+	}
+	return results
+}
+
+// Sample holds the reference results for the first Bits bits of a source.
+type Sample struct {
+	Bits    int      `json:"bits"`
+	Results []Result `json:"results"`
+}
+
+// Reference is the content of one testdata file.
+type Reference struct {
+	// Source names the bit source, see SourceData.
+	Source string `json:"source"`
+	// Origin describes how the reference values were produced.
+	Origin string `json:"origin"`
+	// Tolerance is the accepted absolute difference per p-value.
+	Tolerance float64  `json:"tolerance"`
+	Samples   []Sample `json:"samples"`
+}
+
+// Load reads a reference file.
+func Load(path string) (*Reference, error) {
+	raw, err := os.ReadFile(path) //nolint:gosec // path chosen by the caller
+	if err != nil {
+		return nil, err
+	}
+	var ref Reference
+	if err := json.Unmarshal(raw, &ref); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if ref.Tolerance <= 0 {
+		return nil, fmt.Errorf("parse %s: tolerance must be positive", path)
+	}
+	return &ref, nil
+}
+
+// Save writes the reference file in the indented format kept in testdata.
+func (r *Reference) Save(path string) error {
+	raw, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0o600)
+}
+
+// MaxBits returns the length of the longest sample.
+func (r *Reference) MaxBits() int {
+	n := 0
+	for _, s := range r.Samples {
+		n = max(n, s.Bits)
+	}
+	return n
+}
+
+// SourceData returns the first nBits bits of a source: one of the NIST sample data sets
+// ("pi", "e", "sqrt2", "sqrt3") or an Appendix D generator with its default seed.
+func SourceData(source string, nBits int) ([]byte, error) {
+	for _, name := range selftest.Datasets {
+		if source == name {
+			return selftest.Expansion(source, nBits)
+		}
+	}
+	return generators.Generate(source, nil, nBits)
+}
+
+// Mismatch is a p-value outside the tolerance, or a difference in the number of
+// p-values or in whether a test was skipped.
+type Mismatch struct {
+	Test string
+	// Index is the position of the sub-test p-value, or -1 if the results differ in
+	// shape.
+	Index     int
+	Want, Got float64
+	Detail    string
+}
+
+// Compare returns the mismatches between the reference results want and the computed
+// results got.
+func Compare(want, got []Result, tolerance float64) []Mismatch {
+	byTest := make(map[string]Result, len(got))
+	for _, r := range got {
+		byTest[r.Test] = r
+	}
+
+	var mismatches []Mismatch
+	for _, w := range want {
+		g, ok := byTest[w.Test]
+		switch {
+		case !ok:
+			mismatches = append(mismatches, Mismatch{Test: w.Test, Index: -1, Detail: "not computed"})
+			continue
+		case (w.Skipped == "") != (g.Skipped == ""):
+			mismatches = append(mismatches, Mismatch{Test: w.Test, Index: -1, Detail: fmt.Sprintf("skipped: want %q, got %q", w.Skipped, g.Skipped)})
+			continue
+		case len(w.PValues) != len(g.PValues):
+			mismatches = append(mismatches, Mismatch{Test: w.Test, Index: -1, Detail: fmt.Sprintf("want %d p-values, got %d", len(w.PValues), len(g.PValues))})
+			continue
+		}
+		for i, p := range w.PValues {
+			if diff := math.Abs(g.PValues[i] - p); !(diff <= tolerance) {
+				mismatches = append(mismatches, Mismatch{Test: w.Test, Index: i, Want: p, Got: g.PValues[i]})
+			}
+		}
+	}
+	return mismatches
+}
+
+// WriteReport writes a table of the mismatches of one sample to w.
+func WriteReport(w io.Writer, source string, bits int, tolerance float64, mismatches []Mismatch) error {
+	if _, err := fmt.Fprintf(w, "%s, %d bits: %d p-values outside tolerance %g\n", source, bits, len(mismatches), tolerance); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEST\tINDEX\tREFERENCE\tCOMPUTED\t|DIFF|")
+	for _, m := range mismatches {
+		if m.Index < 0 {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t%s\n", m.Test, m.Detail)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.9f\t%.9f\t%.3g\n", m.Test, m.Index, m.Want, m.Got, math.Abs(m.Got-m.Want))
+	}
+	return tw.Flush()
+}
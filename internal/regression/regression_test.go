@@ -0,0 +1,327 @@
+package regression
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/selftest"
+)
+
+var (
+	update = flag.Bool("update", false, "regenerate the reference files in testdata from the current implementation")
+	report = flag.String("report", "", "write the diff report of all samples to this file")
+
+	// raceEnabled is set when the tests are built with the race detector.
+	raceEnabled = false
+)
+
+// sources lists the bit sources with reference files and sampleBits the sequence
+// lengths frozen for each: the 10^6 bits of Appendix B, a length that still admits the
+// Universal test (L = 6) and one below it.
+var (
+	sources = []string{
+		"pi", "e", "sqrt2", "sqrt3",
+		"lcg", "qcg1", "xor", "modexp", "micali_schnorr", "g_sha1",
+	}
+	sampleBits = []int{100000, 500000, 1000000}
+)
+
+func referencePath(source string) string {
+	return filepath.Join("testdata", source+".json")
+}
+
+func TestRegression(t *testing.T) {
+	if *update {
+		updateReferences(t)
+	}
+
+	var diff bytes.Buffer
+	for _, source := range sources {
+		ref, err := Load(referencePath(source))
+		if err != nil {
+			t.Fatalf("load reference: %v", err)
+		}
+		if ref.Source != source {
+			t.Fatalf("%s: reference is for source %q", referencePath(source), ref.Source)
+		}
+
+		nBits := ref.MaxBits()
+		if longSkipped() {
+			nBits = min(nBits, sampleBits[0])
+		}
+		data, err := SourceData(source, nBits)
+		if err != nil {
+			t.Fatalf("%s: %v", source, err)
+		}
+
+		for _, sample := range ref.Samples {
+			t.Run(fmt.Sprintf("%s/%d", source, sample.Bits), func(t *testing.T) {
+				if sample.Bits > nBits {
+					t.Skip("long sample in short mode or with the race detector")
+				}
+				if want := len(Tests) + len(Variants); len(sample.Results) != want {
+					t.Errorf("reference covers %d tests, want %d", len(sample.Results), want)
+				}
+
+				got := Compute(data[:sample.Bits/8])
+				mismatches := Compare(sample.Results, got, ref.Tolerance)
+				if len(mismatches) == 0 {
+					return
+				}
+				var buf bytes.Buffer
+				if err := WriteReport(&buf, source, sample.Bits, ref.Tolerance, mismatches); err != nil {
+					t.Fatal(err)
+				}
+				diff.Write(buf.Bytes())
+				t.Errorf("regression mismatch (regenerate with -update only after review):\n%s", buf.String())
+			})
+		}
+	}
+
+	if *report != "" && diff.Len() > 0 {
+		if err := os.WriteFile(*report, diff.Bytes(), 0o600); err != nil {
+			t.Fatalf("write report: %v", err)
+		}
+	}
+}
+
+// longSkipped reports whether only the shortest samples are checked, which keeps the
+// race detector and -short runs fast.
+func longSkipped() bool {
+	return testing.Short() || raceEnabled
+}
+
+func updateReferences(t *testing.T) {
+	t.Helper()
+	for _, source := range sources {
+		data, err := SourceData(source, sampleBits[len(sampleBits)-1])
+		if err != nil {
+			t.Fatalf("%s: %v", source, err)
+		}
+		ref := &Reference{
+			Source:    source,
+			Origin:    "frozen with go test ./internal/regression -update",
+			Tolerance: DefaultTolerance,
+		}
+		for _, bits := range sampleBits {
+			ref.Samples = append(ref.Samples, Sample{Bits: bits, Results: Compute(data[:bits/8])})
+		}
+		if err := ref.Save(referencePath(source)); err != nil {
+			t.Fatalf("save %s: %v", source, err)
+		}
+	}
+}
+
+func TestComputeCoversAllSubTests(t *testing.T) {
+	if longSkipped() {
+		t.Skip("long sample in short mode or with the race detector")
+	}
+	data, err := SourceData("e", 1000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{
+		"cumulative_sums":           2,
+		"non_overlapping_template":  148,
+		"random_excursions":         8,
+		"random_excursions_variant": 18,
+		"serial":                    2,
+	}
+
+	tests := append(append([]Test(nil), Tests...), Variants...)
+	results := Compute(data)
+	if len(results) != 17 {
+		t.Fatalf("got %d results, want 17", len(results))
+	}
+	for i, r := range results {
+		if r.Test != tests[i].Name {
+			t.Errorf("result %d is %s, want %s", i, r.Test, tests[i].Name)
+		}
+		if r.Skipped != "" {
+			t.Errorf("%s skipped: %s", r.Test, r.Skipped)
+		}
+		n, ok := want[r.Test]
+		if !ok {
+			n = 1
+		}
+		if len(r.PValues) != n {
+			t.Errorf("%s: got %d p-values, want %d", r.Test, len(r.PValues), n)
+		}
+	}
+}
+
+// appendixBSubTests maps the Appendix B checks of the self-test that are not the first
+// p-value of a test to their test and position.
+var appendixBSubTests = map[string]struct {
+	test  string
+	index int
+}{
+	"cumulative_sums_forward":            {"cumulative_sums", 0},
+	"cumulative_sums_reverse":            {"cumulative_sums", 1},
+	"non_overlapping_template_000000001": {"non_overlapping_template", 0},
+	"random_excursions_x+1":              {"random_excursions", 4},
+	"random_excursions_variant_x-1":      {"random_excursions_variant", 8},
+	"serial_1":                           {"serial", 0},
+	"serial_2":                           {"serial", 1},
+}
+
+// TestSnapshotsMatchAppendixB verifies the frozen 10^6-bit snapshots of the sample data
+// sets against the values published in Appendix B, so that a snapshot frozen from a
+// wrong implementation does not go unnoticed. It reads only the reference files.
+func TestSnapshotsMatchAppendixB(t *testing.T) {
+	for _, source := range selftest.Datasets {
+		ref, err := Load(referencePath(source))
+		if err != nil {
+			t.Fatalf("load reference: %v", err)
+		}
+		i := slices.IndexFunc(ref.Samples, func(s Sample) bool { return s.Bits == selftest.ReferenceBits })
+		if i < 0 {
+			t.Fatalf("%s: no sample of %d bits", source, selftest.ReferenceBits)
+		}
+		byTest := make(map[string][]float64)
+		for _, r := range ref.Samples[i].Results {
+			byTest[r.Test] = r.PValues
+		}
+
+		for check, want := range selftest.AppendixB(source) {
+			test, index := check, 0
+			if sub, ok := appendixBSubTests[check]; ok {
+				test, index = sub.test, sub.index
+			}
+			pValues := byTest[test]
+			if index >= len(pValues) {
+				t.Errorf("%s: snapshot has no p-value %d of %s", source, index, test)
+				continue
+			}
+			if diff := math.Abs(pValues[index] - want); diff > selftest.Tolerance {
+				t.Errorf("%s %s: snapshot %.9f, Appendix B %.6f", source, check, pValues[index], want)
+			}
+		}
+	}
+}
+
+// TestSTSReferences compares the computed p-values with the outputs of the NIST C suite
+// in testdata/sts, frozen with tools/validate_nist_go_vs_c.go -freeze.
+func TestSTSReferences(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "sts", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skip("no NIST STS outputs in testdata/sts")
+	}
+
+	for _, path := range paths {
+		ref, err := Load(path)
+		if err != nil {
+			t.Fatalf("load reference: %v", err)
+		}
+		if ref.Origin != STSOrigin {
+			t.Errorf("%s: origin %q is not the NIST C suite", path, ref.Origin)
+		}
+		nBits := ref.MaxBits()
+		if longSkipped() {
+			nBits = min(nBits, sampleBits[0])
+		}
+		data, err := SourceData(ref.Source, nBits)
+		if err != nil {
+			t.Fatalf("%s: %v", ref.Source, err)
+		}
+
+		for _, sample := range ref.Samples {
+			t.Run(fmt.Sprintf("%s/%d", ref.Source, sample.Bits), func(t *testing.T) {
+				if sample.Bits > nBits {
+					t.Skip("long sample in short mode or with the race detector")
+				}
+				mismatches := Compare(sample.Results, Compute(data[:sample.Bits/8]), ref.Tolerance)
+				if len(mismatches) == 0 {
+					return
+				}
+				var buf bytes.Buffer
+				if err := WriteReport(&buf, ref.Source, sample.Bits, ref.Tolerance, mismatches); err != nil {
+					t.Fatal(err)
+				}
+				t.Errorf("mismatch against the NIST C suite:\n%s", buf.String())
+			})
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want := []Result{
+		{Test: "a", PValues: []float64{0.5, 0.25}},
+		{Test: "b", PValues: []float64{0.1}},
+		{Test: "c", Skipped: "fewer than 500 cycles"},
+		{Test: "d", PValues: []float64{0.3}},
+		{Test: "e", PValues: []float64{0.7}},
+	}
+	got := []Result{
+		{Test: "a", PValues: []float64{0.5 + 1e-10, 0.2}},
+		{Test: "b", PValues: []float64{0.1, 0.2}},
+		{Test: "c", PValues: []float64{0.9}},
+		{Test: "e", PValues: []float64{0.7}},
+	}
+
+	mismatches := Compare(want, got, 1e-9)
+	if len(mismatches) != 4 {
+		t.Fatalf("got %d mismatches, want 4: %+v", len(mismatches), mismatches)
+	}
+	if m := mismatches[0]; m.Test != "a" || m.Index != 1 || m.Want != 0.25 || m.Got != 0.2 {
+		t.Errorf("unexpected p-value mismatch: %+v", m)
+	}
+	for i, test := range []string{"b", "c", "d"} {
+		if m := mismatches[i+1]; m.Test != test || m.Index != -1 || m.Detail == "" {
+			t.Errorf("unexpected shape mismatch: %+v", m)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, "pi", 1000, 1e-9, mismatches); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"pi, 1000 bits: 4 p-values outside tolerance 1e-09", "0.250000000", "0.200000000", "want 1 p-values, got 2", "not computed"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("report misses %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"syntax.json":    "{",
+		"tolerance.json": `{"source": "pi", "tolerance": 0}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestSourceData(t *testing.T) {
+	pi, err := SourceData("pi", 16)
+	if err != nil || pi[0] != 0xc9 || pi[1] != 0x0f {
+		t.Errorf("pi: got %x, %v", pi, err)
+	}
+	if _, err := SourceData("lcg", 64); err != nil {
+		t.Errorf("lcg: %v", err)
+	}
+	if _, err := SourceData("unknown", 64); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
@@ -0,0 +1,105 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	f, err := Open(path, 1, 2)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	// Two writes of 400 KB fit into a 1 MB file, so nine writes fill five files of
+	// which the newest three are kept.
+	chunk := bytes.Repeat([]byte("x"), 400000)
+	for i := 0; i < 9; i++ {
+		chunk[0] = byte('0' + i)
+		if _, err := f.Write(chunk); err != nil {
+			t.Fatalf("Write %d failed: %v", i, err)
+		}
+	}
+
+	for _, want := range []struct {
+		name  string
+		size  int
+		first byte
+	}{
+		{path + ".2", 800000, '4'},
+		{path + ".1", 800000, '6'},
+		{path, 400000, '8'},
+	} {
+		raw, err := os.ReadFile(want.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(raw) != want.size || raw[0] != want.first {
+			t.Errorf("%s: %d bytes starting with write %c, want %d starting with %c", want.name, len(raw), raw[0], want.size, want.first)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups, stat %s.3: %v", path, err)
+	}
+}
+
+func TestRotationKeepsAllBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	f, err := Open(path, 1, 0)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 600000)
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write(chunk); err != nil {
+			t.Fatalf("Write %d failed: %v", i, err)
+		}
+	}
+	for i := 1; i <= 4; i++ {
+		if _, err := os.Stat(fmt.Sprintf("%s.%d", path, i)); err != nil {
+			t.Errorf("backup %d missing: %v", i, err)
+		}
+	}
+}
+
+func TestAppendAndClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path, 0, 0)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := f.Write([]byte("appended\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+	if _, err := f.Write([]byte("late\n")); err == nil {
+		t.Error("expected error writing to a closed file")
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil || string(raw) != "existing\nappended\n" {
+		t.Fatalf("got %q, %v", raw, err)
+	}
+
+	if _, err := Open(filepath.Join(path, "sub"), 0, 0); err == nil {
+		t.Error("expected error for an unwritable path")
+	}
+}
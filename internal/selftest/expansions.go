@@ -0,0 +1,169 @@
+package selftest
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+)
+
+// Names of the NIST sample data sets that can be regenerated in Go.
+const (
+	DatasetPi    = "pi"
+	DatasetE     = "e"
+	DatasetSqrt2 = "sqrt2"
+	DatasetSqrt3 = "sqrt3"
+)
+
+// Datasets lists the sample data sets in the column order of SP 800-22 Appendix B.
+var Datasets = []string{DatasetPi, DatasetE, DatasetSqrt2, DatasetSqrt3}
+
+// guardBits are extra bits carried through fixed-point arithmetic to absorb truncation error.
+const guardBits = 64
+
+var (
+	expansionMu    sync.Mutex
+	expansionCache = map[string][]byte{}
+)
+
+// Expansion returns the first nBits bits of the binary expansion of the named constant,
+// packed MSB-first. As in the NIST data.* files, the expansion starts with the integer
+// part (e.g. "11" for pi, "10" for e). nBits must be a positive multiple of 8.
+// Results are cached per process.
+func Expansion(name string, nBits int) ([]byte, error) {
+	if nBits <= 0 || nBits%8 != 0 {
+		return nil, fmt.Errorf("invalid expansion length: %d bits (must be a positive multiple of 8)", nBits)
+	}
+
+	key := fmt.Sprintf("%s/%d", name, nBits)
+
+	expansionMu.Lock()
+	defer expansionMu.Unlock()
+
+	if cached, ok := expansionCache[key]; ok {
+		return cached, nil
+	}
+
+	// Compute floor(x * 2^k) with k chosen so that the result has at least nBits bits.
+	k := uint(nBits) //nolint:gosec // nBits > 0
+	var x *big.Int
+	switch name {
+	case DatasetPi:
+		x = piFixed(k)
+	case DatasetE:
+		x = eFixed(k)
+	case DatasetSqrt2:
+		x = sqrtFixed(2, k)
+	case DatasetSqrt3:
+		x = sqrtFixed(3, k)
+	default:
+		return nil, fmt.Errorf("unknown dataset: %q", name)
+	}
+
+	// Keep the leading nBits bits (integer part first).
+	x.Rsh(x, uint(x.BitLen()-nBits)) //nolint:gosec // BitLen() > nBits by construction
+	out := make([]byte, nBits/8)
+	x.FillBytes(out)
+
+	expansionCache[key] = out
+	return out, nil
+}
+
+// sqrtFixed returns floor(sqrt(v) * 2^k).
+func sqrtFixed(v int64, k uint) *big.Int {
+	x := new(big.Int).Lsh(big.NewInt(v), 2*k)
+	return x.Sqrt(x)
+}
+
+// eFixed returns floor(e * 2^k) using binary splitting of sum_{j>=0} 1/j!.
+func eFixed(k uint) *big.Int {
+	// Choose the number of terms N such that N! > 2^(k+guardBits).
+	terms := int64(1)
+	for lg := 0.0; lg < float64(k+guardBits); lg += math.Log2(float64(terms)) {
+		terms++
+	}
+
+	p, q := eSplit(0, terms)
+
+	// sum_{j=0}^{terms} 1/j! = 1 + p/q
+	num := new(big.Int).Add(p, q)
+	num.Lsh(num, k)
+	return num.Quo(num, q)
+}
+
+// eSplit computes p/q = sum_{j=a+1}^{b} a!/j! for the binary splitting of e.
+func eSplit(a, b int64) (p, q *big.Int) {
+	if b-a == 1 {
+		return big.NewInt(1), big.NewInt(b)
+	}
+
+	m := (a + b) / 2
+	p1, q1 := eSplit(a, m)
+	p2, q2 := eSplit(m, b)
+
+	p = new(big.Int).Mul(p1, q2)
+	p.Add(p, p2)
+	q = new(big.Int).Mul(q1, q2)
+	return p, q
+}
+
+// Chudnovsky series constants.
+const (
+	chudA      = 13591409
+	chudB      = 545140134
+	chudC3Over = 10939058860032000 // 640320^3 / 24
+	chudScale  = 426880            // 640320^(3/2) / (12 * sqrt(10005))
+	chudRoot   = 10005
+	// chudBitsPerTerm is log2(640320^3 / 1728), the precision gained per series term.
+	chudBitsPerTerm = 47.11
+)
+
+// piFixed returns floor(pi * 2^k) using the Chudnovsky series with binary splitting.
+func piFixed(k uint) *big.Int {
+	terms := int64(float64(k)/chudBitsPerTerm) + 2
+	_, q, t := piSplit(0, terms)
+
+	// pi = chudScale * sqrt(chudRoot) * Q / T
+	root := sqrtFixed(chudRoot, k+guardBits)
+	num := new(big.Int).Mul(root, q)
+	num.Mul(num, big.NewInt(chudScale))
+	num.Quo(num, t)
+	return num.Rsh(num, guardBits)
+}
+
+// piSplit computes the binary splitting terms P(a,b), Q(a,b), T(a,b) of the Chudnovsky series.
+func piSplit(a, b int64) (p, q, t *big.Int) {
+	if b-a == 1 {
+		if a == 0 {
+			p = big.NewInt(1)
+			q = big.NewInt(1)
+		} else {
+			p = big.NewInt(6*a - 5)
+			p.Mul(p, big.NewInt(2*a-1))
+			p.Mul(p, big.NewInt(6*a-1))
+			q = big.NewInt(a)
+			q.Mul(q, q)
+			q.Mul(q, big.NewInt(a))
+			q.Mul(q, big.NewInt(chudC3Over))
+		}
+
+		t = big.NewInt(chudB)
+		t.Mul(t, big.NewInt(a))
+		t.Add(t, big.NewInt(chudA))
+		t.Mul(t, p)
+		if a%2 == 1 {
+			t.Neg(t)
+		}
+		return p, q, t
+	}
+
+	m := (a + b) / 2
+	p1, q1, t1 := piSplit(a, m)
+	p2, q2, t2 := piSplit(m, b)
+
+	p = new(big.Int).Mul(p1, p2)
+	q = new(big.Int).Mul(q1, q2)
+	t = new(big.Int).Mul(t1, q2)
+	t.Add(t, new(big.Int).Mul(p1, t2))
+	return p, q, t
+}
@@ -0,0 +1,57 @@
+package selftest
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestExpansionPrefixes(t *testing.T) {
+	// Leading bytes of the NIST data.* files (integer part included).
+	tests := []struct {
+		name string
+		want string
+	}{
+		{DatasetPi, "c90fdaa22168c234c4c6628b80dc1cd1"},
+		{DatasetE, "adf85458a2bb4a9aafdc5620273d3cf1"},
+		{DatasetSqrt2, "b504f333f9de6484597d89b3754abe9f"},
+		{DatasetSqrt3, "ddb3d742c265539d92ba16b83c5c1dc4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expansion(tt.name, 128)
+			if err != nil {
+				t.Fatalf("Expansion(%q) failed: %v", tt.name, err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Fatalf("unexpected prefix: got %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpansionIsPrefixStable(t *testing.T) {
+	short, err := Expansion(DatasetE, 256)
+	if err != nil {
+		t.Fatalf("Expansion failed: %v", err)
+	}
+	long, err := Expansion(DatasetE, 4096)
+	if err != nil {
+		t.Fatalf("Expansion failed: %v", err)
+	}
+	if hex.EncodeToString(long[:len(short)]) != hex.EncodeToString(short) {
+		t.Fatalf("longer expansion does not extend shorter one")
+	}
+}
+
+func TestExpansionErrors(t *testing.T) {
+	if _, err := Expansion("golden_ratio", 128); err == nil {
+		t.Error("expected error for unknown dataset")
+	}
+	if _, err := Expansion(DatasetPi, 0); err == nil {
+		t.Error("expected error for zero length")
+	}
+	if _, err := Expansion(DatasetPi, 12); err == nil {
+		t.Error("expected error for length not divisible by 8")
+	}
+}
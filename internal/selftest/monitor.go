@@ -0,0 +1,126 @@
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status is the state of the known-answer self-test gate.
+type Status int
+
+const (
+	// StatusPending means the self-test has not completed yet.
+	StatusPending Status = iota
+	// StatusPassed means the last self-test matched every reference value.
+	StatusPassed
+	// StatusFailed means the last self-test produced a mismatch or could not run.
+	StatusFailed
+)
+
+// String returns the lower-case name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusPassed:
+		return "passed"
+	case StatusFailed:
+		return "failed"
+	default:
+		return "pending"
+	}
+}
+
+// Listener is notified after every completed self-test run. report is nil if the
+// run failed with err.
+type Listener func(status Status, report *Report, err error)
+
+// Monitor runs the self-test once at startup and then periodically, keeping
+// track of the latest outcome.
+type Monitor struct {
+	datasets []string
+	interval time.Duration
+	run      func(ctx context.Context, datasets ...string) (*Report, error)
+
+	mu        sync.RWMutex
+	status    Status
+	listeners []Listener
+}
+
+// NewMonitor creates a Monitor checking the given data sets (all if empty).
+// An interval of zero runs the self-test only once.
+func NewMonitor(datasets []string, interval time.Duration) (*Monitor, error) {
+	for _, name := range datasets {
+		if datasetColumn(name) < 0 {
+			return nil, fmt.Errorf("unknown self-test dataset: %q", name)
+		}
+	}
+	if interval < 0 {
+		return nil, fmt.Errorf("self-test interval must not be negative: %s", interval)
+	}
+
+	return &Monitor{
+		datasets: datasets,
+		interval: interval,
+		run:      Run,
+	}, nil
+}
+
+// OnResult registers a listener. It must be called before Start.
+func (m *Monitor) OnResult(l Listener) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, l)
+}
+
+// Status returns the outcome of the latest completed run.
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// RunOnce executes a single self-test and notifies listeners. A run aborted by
+// context cancellation leaves the status unchanged and notifies nobody.
+func (m *Monitor) RunOnce(ctx context.Context) Status {
+	report, err := m.run(ctx, m.datasets...)
+	if err != nil && ctx.Err() != nil {
+		return m.Status()
+	}
+
+	status := StatusFailed
+	if err == nil && report.Passed {
+		status = StatusPassed
+	}
+
+	m.mu.Lock()
+	m.status = status
+	listeners := append([]Listener(nil), m.listeners...)
+	m.mu.Unlock()
+
+	for _, l := range listeners {
+		l(status, report, err)
+	}
+	return status
+}
+
+// Start runs the self-test immediately and then every interval until ctx is done.
+// It blocks and is intended to be run in its own goroutine.
+func (m *Monitor) Start(ctx context.Context) {
+	m.RunOnce(ctx)
+	if m.interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.RunOnce(ctx)
+		}
+	}
+}
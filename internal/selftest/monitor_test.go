@@ -0,0 +1,147 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewMonitorValidation(t *testing.T) {
+	if _, err := NewMonitor([]string{"unknown"}, time.Minute); err == nil {
+		t.Error("expected error for unknown dataset")
+	}
+	if _, err := NewMonitor(nil, -time.Second); err == nil {
+		t.Error("expected error for negative interval")
+	}
+
+	m, err := NewMonitor([]string{DatasetE}, 0)
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	if m.Status() != StatusPending {
+		t.Fatalf("expected pending status, got %s", m.Status())
+	}
+}
+
+func TestMonitorRunOnce(t *testing.T) {
+	m, err := NewMonitor(nil, 0)
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+
+	var got []Status
+	m.OnResult(func(status Status, report *Report, err error) {
+		got = append(got, status)
+	})
+
+	m.run = func(ctx context.Context, datasets ...string) (*Report, error) {
+		return &Report{Passed: true}, nil
+	}
+	if s := m.RunOnce(context.Background()); s != StatusPassed {
+		t.Fatalf("expected passed, got %s", s)
+	}
+
+	m.run = func(ctx context.Context, datasets ...string) (*Report, error) {
+		return &Report{Passed: false}, nil
+	}
+	if s := m.RunOnce(context.Background()); s != StatusFailed {
+		t.Fatalf("expected failed on mismatch, got %s", s)
+	}
+
+	m.run = func(ctx context.Context, datasets ...string) (*Report, error) {
+		return nil, errors.New("boom")
+	}
+	if s := m.RunOnce(context.Background()); s != StatusFailed {
+		t.Fatalf("expected failed on error, got %s", s)
+	}
+
+	if len(got) != 3 || got[0] != StatusPassed || got[1] != StatusFailed || got[2] != StatusFailed {
+		t.Fatalf("unexpected listener notifications: %v", got)
+	}
+}
+
+func TestMonitorCancelledRunKeepsStatus(t *testing.T) {
+	m, err := NewMonitor(nil, 0)
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	m.OnResult(func(Status, *Report, error) {
+		t.Error("listener must not be called for cancelled runs")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.run = func(ctx context.Context, datasets ...string) (*Report, error) {
+		return nil, ctx.Err()
+	}
+	if s := m.RunOnce(ctx); s != StatusPending {
+		t.Fatalf("expected pending after cancelled run, got %s", s)
+	}
+}
+
+func TestMonitorStartPeriodic(t *testing.T) {
+	m, err := NewMonitor(nil, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	runs := 0
+	m.run = func(ctx context.Context, datasets ...string) (*Report, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		return &Report{Passed: true}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Start(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := runs
+		mu.Unlock()
+		if n >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at least 3 runs, got %d", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return after cancellation")
+	}
+}
+
+func TestMonitorStartOnce(t *testing.T) {
+	m, err := NewMonitor(nil, 0)
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	m.run = func(ctx context.Context, datasets ...string) (*Report, error) {
+		return &Report{Passed: true}, nil
+	}
+
+	m.Start(context.Background()) // must return after a single run
+	if m.Status() != StatusPassed {
+		t.Fatalf("expected passed, got %s", m.Status())
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	if StatusPending.String() != "pending" || StatusPassed.String() != "passed" || StatusFailed.String() != "failed" {
+		t.Fatal("unexpected status names")
+	}
+}
@@ -0,0 +1,70 @@
+package selftest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunMatchesAppendixB(t *testing.T) {
+	report, err := Run(context.Background(), DatasetSqrt2)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if want := 19; len(report.Checks) != want {
+		t.Fatalf("expected %d checks, got %d", want, len(report.Checks))
+	}
+	for _, c := range report.Failed() {
+		t.Errorf("%s/%s: got %.6f, want %.6f (diff %.2e)", c.Dataset, c.Test, c.PValue, c.Expected, c.AbsDiff)
+	}
+	if !report.Passed {
+		t.Fatal("expected self-test to pass")
+	}
+	if report.Duration <= 0 {
+		t.Fatalf("invalid duration: %v", report.Duration)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if _, err := Run(context.Background(), "unknown"); err == nil {
+		t.Error("expected error for unknown dataset")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Run(ctx, DatasetE); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
+
+func TestReportFailed(t *testing.T) {
+	r := &Report{Checks: []Check{
+		{Test: "a", Passed: true},
+		{Test: "b", Passed: false},
+	}}
+	failed := r.Failed()
+	if len(failed) != 1 || failed[0].Test != "b" {
+		t.Fatalf("unexpected failed checks: %+v", failed)
+	}
+}
+
+func TestKnownAnswersTableShape(t *testing.T) {
+	for _, ref := range knownAnswers() {
+		if len(ref.tests) != len(ref.want) {
+			t.Errorf("%v: %d sub-tests but %d reference rows", ref.tests, len(ref.tests), len(ref.want))
+		}
+	}
+}
+
+func TestAppendixB(t *testing.T) {
+	values := AppendixB(DatasetSqrt3)
+	if len(values) != 17 || values["serial_2"] != 0.171100 || values["cumulative_sums_reverse"] != 0.689519 {
+		t.Errorf("unexpected Appendix B values: %v", values)
+	}
+	if _, ok := values["linear_complexity_exact"]; ok {
+		t.Error("frozen checks must not be reported as Appendix B values")
+	}
+	if AppendixB("unknown") != nil {
+		t.Error("expected nil for unknown dataset")
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/audit"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/drift"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/metrics"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/middleware"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+)
+
+// WithDriftMonitor evaluates the results of each labeled source over the rolling window
+// of m and enables the GetSourceStatus RPC
+func WithDriftMonitor(m *drift.Monitor) Option {
+	return func(s *Server) {
+		s.drift = m
+	}
+}
+
+// GetSourceStatus implements the GetSourceStatus RPC
+func (s *Server) GetSourceStatus(ctx context.Context, req *pb.GetSourceStatusRequest) (*pb.GetSourceStatusResponse, error) {
+	ctx = requestContext(ctx)
+	logger := middleware.Logger(ctx)
+
+	logger.Debug().
+		Str("source", req.SourceLabel).
+		Msg("GetSourceStatus request received")
+
+	var err error
+	switch {
+	case s.drift == nil:
+		err = status.Error(codes.FailedPrecondition, "drift detection is disabled")
+	case req.SourceLabel == "":
+		err = status.Error(codes.InvalidArgument, "source_label is required")
+	}
+	if err != nil {
+		metrics.RequestsTotal.WithLabelValues("GetSourceStatus", "error").Inc()
+		return nil, err
+	}
+
+	st, ok := s.drift.Status(req.SourceLabel)
+	if !ok {
+		metrics.RequestsTotal.WithLabelValues("GetSourceStatus", "error").Inc()
+		return nil, status.Errorf(codes.NotFound, "no results for source %q", req.SourceLabel)
+	}
+
+	metrics.RequestsTotal.WithLabelValues("GetSourceStatus", "success").Inc()
+	return sourceStatusResponse(st), nil
+}
+
+// Prefixes of the drift keys of the sources that the service samples itself. A
+// source_label of RunTestSuite cannot start with them, so a client label never shares
+// a window with a generator or host source of the same name
+const (
+	generatorSourcePrefix = "generator:"
+	hostSourcePrefix      = "source:"
+)
+
+// driftSource returns the drift key of a submission: the source_label of RunTestSuite,
+// the generator of GenerateAndTest as "generator:<name>" and the host source of
+// TestSource as "source:<name>"
+func driftSource(rec audit.Record) string {
+	if rec.Source == "" {
+		return ""
+	}
+	switch rec.Method {
+	case "GenerateAndTest":
+		return generatorSourcePrefix + rec.Source
+	case "TestSource":
+		return hostSourcePrefix + rec.Source
+	}
+	return rec.Source
+}
+
+// observeDrift adds the results of a submission of source to its rolling window and
+// logs and notifies changes of its state. Unlabeled submissions are not tracked.
+func (s *Server) observeDrift(ctx context.Context, source string, results []nist.TestResult) {
+	if s.drift == nil || source == "" {
+		return
+	}
+
+	previous, _ := s.drift.Status(source)
+	st, ok := s.drift.Observe(source, time.Now().UTC(), driftResults(results))
+	if !ok {
+		middleware.Logger(ctx).Warn().
+			Str("source", source).
+			Msg("Source not tracked for drift detection, too many sources")
+		return
+	}
+	metrics.RecordSourceStatus(source, int(st.State), st.Samples)
+
+	if st.State != previous.State {
+		event := middleware.Logger(ctx).Info()
+		if st.State == drift.StateWarn || st.State == drift.StateFail {
+			event = middleware.Logger(ctx).Warn()
+		}
+		event.
+			Str("source", source).
+			Str("previous_state", previous.State.String()).
+			Str("state", st.State.String()).
+			Int("window_samples", st.Samples).
+			Msg("Source drift state changed")
+		s.notifySourceState(ctx, previous.State, st)
+	}
+}
+
+// driftResults returns the p-values of the tests that ran, with one p-value per sub-test
+// for tests that report several
+func driftResults(results []nist.TestResult) []drift.Result {
+	out := make([]drift.Result, 0, len(results))
+	for _, r := range results {
+		if pValues := r.SubTestPValues(); pValues != nil {
+			out = append(out, drift.Result{Test: r.Name, PValues: pValues})
+		}
+	}
+	return out
+}
+
+func sourceStatusResponse(st drift.Status) *pb.GetSourceStatusResponse {
+	resp := &pb.GetSourceStatusResponse{
+		SourceLabel: st.Source,
+		State:       sourceState(st.State),
+		Samples:     int32(st.Samples),    //nolint:gosec // bounded by the window size
+		WindowSize:  int32(st.WindowSize), //nolint:gosec // validated configuration value
+		LastUpdated: st.Updated.Format(time.RFC3339),
+		Tests:       make([]*pb.SourceTestStatus, len(st.Tests)),
+	}
+	for i, t := range st.Tests {
+		resp.Tests[i] = &pb.SourceTestStatus{
+			Name:             t.Name,
+			Samples:          int32(t.Samples), //nolint:gosec // window size times sub-tests
+			Proportion:       t.Proportion,
+			ProportionMin:    t.ProportionMin,
+			UniformityPValue: t.Uniformity,
+			State:            sourceState(t.State),
+		}
+	}
+	return resp
+}
+
+func sourceState(s drift.State) pb.SourceState {
+	switch s {
+	case drift.StateOK:
+		return pb.SourceState_SOURCE_STATE_OK
+	case drift.StateWarn:
+		return pb.SourceState_SOURCE_STATE_WARN
+	case drift.StateFail:
+		return pb.SourceState_SOURCE_STATE_FAIL
+	default:
+		return pb.SourceState_SOURCE_STATE_UNSPECIFIED
+	}
+}
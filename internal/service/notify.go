@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/audit"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/drift"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/middleware"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/notify"
+)
+
+// WithNotifications sends failed analyses and drift state changes to d, which selects
+// the events to deliver by its rules
+func WithNotifications(d *notify.Dispatcher) Option {
+	return func(s *Server) {
+		s.notifier = d
+	}
+}
+
+// notifyRun reports a completed analysis in which tests failed. A test fails by the
+// verdict of its passed field (see nist.SubTestsFailed), not by its smallest p-value,
+// which is below Alpha for most random sequences when there are many sub-tests.
+func (s *Server) notifyRun(ctx context.Context, rec audit.Record, results []nist.TestResult) {
+	if s.notifier == nil {
+		return
+	}
+
+	var failed []string
+	for _, r := range results {
+		if r.Failed() {
+			failed = append(failed, r.Name)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	s.notifier.Dispatch(notify.Event{
+		Kind:        notify.KindRunFailed,
+		RequestID:   middleware.GetRequestID(ctx),
+		Method:      rec.Method,
+		Source:      rec.Source,
+		PassRate:    rec.PassRate,
+		FailedTests: failed,
+	})
+}
+
+// notifySourceState reports a change of the drift state of a source
+func (s *Server) notifySourceState(ctx context.Context, previous drift.State, st drift.Status) {
+	if s.notifier == nil {
+		return
+	}
+
+	var tests []string
+	for _, t := range st.Tests {
+		if t.State == drift.StateWarn || t.State == drift.StateFail {
+			tests = append(tests, t.Name)
+		}
+	}
+	s.notifier.Dispatch(notify.Event{
+		Kind:          notify.KindSourceState,
+		RequestID:     middleware.GetRequestID(ctx),
+		Source:        st.Source,
+		State:         st.State.String(),
+		PreviousState: previous.String(),
+		Tests:         tests,
+	})
+}
@@ -3,22 +3,49 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 
 	pb "github.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb"
 
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/audit"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/drift"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/generators"
 	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/metrics"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/middleware"
 	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
-
-	"gonum.org/v1/gonum/mathext"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/notify"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/selftest"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/sources"
 )
 
-// runAllTests is a variable to allow mocking in tests
-var runAllTests = nist.RunAllTests
+// runTests is a variable to allow mocking in tests
+var runTests = nist.DefaultRegistry.Run
+
+// runSelfTest is a variable to allow mocking in tests
+var runSelfTest = selftest.Run
+
+// generate is a variable to allow mocking in tests
+var generate = generators.Generate
+
+// readSource is a variable to allow mocking in tests
+var readSource = sources.Read
 
 const (
 	// Version of the service (2.0.0 for breaking API change)
@@ -26,34 +53,148 @@ const (
 
 	// Alpha significance level from NIST (p-value threshold)
 	Alpha = 0.01
+
+	// DefaultGeneratedBits is the GenerateAndTest and TestSource length if none is requested
+	DefaultGeneratedBits = 1000000
+
+	// PreciseBits is the admission weight added to a request for precise p-values. The
+	// arbitrary-precision tails of the full battery take about as long as the float64
+	// path on 500,000 bits; the weight doubles that to cover inputs with many sub-tests
+	PreciseBits = 1000000
 )
 
 // Server implements the Sp80022TestService
 type Server struct {
 	pb.UnimplementedSp80022TestServiceServer
+
+	// mu guards the settings that can be changed while the server runs: the TestSource
+	// settings, precise p-values and the DFT memory limit
+	mu sync.RWMutex
+
+	// TestSource settings; the RPC is rejected unless sourcesEnabled is set
+	sourcesEnabled      bool
+	sourcesAllowedPaths []string
+
+	// Requests for precise p-values are rejected unless preciseEnabled is set
+	preciseEnabled bool
+
+	// Working memory accepted for the Spectral (DFT) test, in bytes; 0 means unlimited
+	dftMemoryLimit int64
+
+	// Audit trail of completed analyses; nil disables auditing
+	auditLog *audit.Log
+
+	// Sub-test p-values of the last submissions per test and source for the uniformity gauge
+	uniformity *uniformityWindow
+
+	// Rolling-window evaluation per source label; nil disables drift detection
+	drift *drift.Monitor
+
+	// Outbound notifications of failures; nil disables them
+	notifier *notify.Dispatcher
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithSourceTesting enables the TestSource RPC. The file source may only read the given
+// paths; the scopes of the caller are checked by the authorization interceptor.
+func WithSourceTesting(allowedPaths []string) Option {
+	return func(s *Server) {
+		s.sourcesEnabled = true
+		s.sourcesAllowedPaths = allowedPaths
+	}
+}
+
+// WithPrecisePValues accepts requests that set precise_p_values. Their arbitrary-precision
+// tails cost far more CPU than the float64 path, see PreciseBits
+func WithPrecisePValues() Option {
+	return func(s *Server) {
+		s.preciseEnabled = true
+	}
+}
+
+// WithDFTMemoryLimit rejects requests whose Spectral (DFT) test would need more than
+// limit bytes of working memory (see nist.DFTMemoryBytes)
+func WithDFTMemoryLimit(limit int64) Option {
+	return func(s *Server) {
+		s.dftMemoryLimit = limit
+	}
+}
+
+// WithAuditLog appends a record of every analysis to l
+func WithAuditLog(l *audit.Log) Option {
+	return func(s *Server) {
+		s.auditLog = l
+	}
+}
+
+// WithUniformityWindow reports the uniformity of the sub-test p-values of the last size
+// submissions of each test and source in the nist_p_value_uniformity gauge. Sizes below
+// 1 keep DefaultUniformityWindow.
+func WithUniformityWindow(size int) Option {
+	return func(s *Server) {
+		if size > 0 {
+			s.uniformity = newUniformityWindow(size)
+		}
+	}
+}
+
+// SetSourceTesting enables or disables the TestSource RPC of a running server, with the
+// settings of WithSourceTesting
+func (s *Server) SetSourceTesting(enabled bool, allowedPaths []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sourcesEnabled = enabled
+	s.sourcesAllowedPaths = allowedPaths
+}
+
+// SetPrecisePValues enables or disables precise p-values on a running server, see
+// WithPrecisePValues
+func (s *Server) SetPrecisePValues(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.preciseEnabled = enabled
+}
+
+// SetDFTMemoryLimit changes the limit of WithDFTMemoryLimit of a running server
+func (s *Server) SetDFTMemoryLimit(limit int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dftMemoryLimit = limit
 }
 
 // NewServer creates a new Sp80022TestService server
-func NewServer() *Server {
-	return &Server{}
+func NewServer(opts ...Option) *Server {
+	s := &Server{uniformity: newUniformityWindow(DefaultUniformityWindow)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // RunTestSuite implements the RunTestSuite RPC
 func (s *Server) RunTestSuite(ctx context.Context, req *pb.Sp80022TestRequest) (*pb.Sp80022TestResponse, error) {
 	startTime := time.Now()
 
-	// Generate unique request ID for log correlation
-	requestID := uuid.New().String()
+	ctx = requestContext(ctx)
+	logger := middleware.Logger(ctx)
 
-	log.Info().
-		Str("request_id", requestID).
+	logger.Info().
 		Int("bitstream_bytes", len(req.Bitstream)).
 		Msg("RunTestSuite request received")
 
+	if err := s.authorizePrecise(req.Config); err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Precise p-values denied")
+		metrics.RequestsTotal.WithLabelValues("RunTestSuite", "denied").Inc()
+		return nil, err
+	}
+
 	// Validate request
 	if err := s.validateRequest(req); err != nil {
-		log.Error().
-			Str("request_id", requestID).
+		logger.Error().
 			Err(err).
 			Msg("Request validation failed")
 		metrics.RequestsTotal.WithLabelValues("RunTestSuite", "error").Inc()
@@ -62,22 +203,40 @@ func (s *Server) RunTestSuite(ctx context.Context, req *pb.Sp80022TestRequest) (
 
 	metrics.RequestsTotal.WithLabelValues("RunTestSuite", "success").Inc()
 
+	opts, _ := runOptions(req.Config) // validated above
+	rec := audit.Record{Method: "RunTestSuite", Source: req.SourceLabel, Parameters: auditParameters(req.Config)}
+	return s.runSuite(ctx, startTime, req.Bitstream, opts, rec)
+}
+
+// runSuite executes the selected tests on a validated bitstream and builds the response.
+// rec describes the request for the audit log.
+func (s *Server) runSuite(ctx context.Context, startTime time.Time, bitstream []byte, opts nist.RunOptions, rec audit.Record) (*pb.Sp80022TestResponse, error) {
+	// Correlate the RPC span, if traced, with the logs and the audit log
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("request_id", middleware.GetRequestID(ctx)),
+		attribute.Int("nist.input_bits", len(bitstream)*8),
+	)
+
 	// Run NIST tests in pure Go
 	testStart := time.Now()
-	results, err := runAllTests(req.Bitstream)
+	results, err := runTests(ctx, bitstream, opts)
 	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
+		middleware.Logger(ctx).Error().
 			Err(err).
 			Msg("NIST test execution failed")
+		rec.Verdict = "error"
+		rec.Error = err.Error()
+		s.writeAudit(ctx, bitstream, rec)
 		return nil, fmt.Errorf("test execution failed: %w", err)
 	}
 
 	// Record overall duration
 	duration := time.Since(testStart)
 	metrics.OverallDuration.Observe(duration.Seconds())
+	metrics.RecordInputBits(rec.Method, len(bitstream)*8)
+	source := metrics.SourceLabel(rec.Source)
 
-	sampleBits := int32(len(req.Bitstream) * 8) //nolint:gosec // safe: MaxBits < 2^31
+	sampleBits := int32(len(bitstream) * 8) //nolint:gosec // safe: MaxBits < 2^31
 
 	// Build response
 	response := &pb.Sp80022TestResponse{
@@ -85,6 +244,7 @@ func (s *Server) RunTestSuite(ctx context.Context, req *pb.Sp80022TestRequest) (
 		SampleSizeBits:  sampleBits,
 		Results:         make([]*pb.Sp80022TestResult, len(results)),
 		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
+		RequestId:       middleware.GetRequestID(ctx),
 	}
 
 	// Convert results and compute overall metrics
@@ -121,6 +281,7 @@ func (s *Server) RunTestSuite(ctx context.Context, req *pb.Sp80022TestRequest) (
 		}
 		metrics.TestsTotal.WithLabelValues(result.Name, status).Inc()
 		metrics.PValue.WithLabelValues(result.Name).Set(result.PValue)
+		s.recordPValueMetrics(result, source)
 
 		// Convert to protobuf message
 		pbResult := &pb.Sp80022TestResult{
@@ -137,10 +298,20 @@ func (s *Server) RunTestSuite(ctx context.Context, req *pb.Sp80022TestRequest) (
 			pbResult.Warning = &result.Warning
 		}
 
+		for _, count := range result.Histogram {
+			pbResult.Histogram = append(pbResult.Histogram, int64(count))
+		}
+
+		if result.Precise != nil {
+			pbResult.PValuePrecise = &result.Precise.PValue
+			pbResult.PValueDiscrepancy = &result.Precise.Discrepancy
+			pbResult.PValuesPrecise = result.Precise.SubTests
+		}
+
 		response.Results[i] = pbResult
 
-		// Only add real p-values to uniformity check
-		pValues = append(pValues, result.PValue)
+		// Only add real p-values to uniformity check, one per sub-test
+		pValues = append(pValues, result.SubTestPValues()...)
 	}
 
 	// Calculate overall pass rate ONLY for implemented tests
@@ -154,18 +325,27 @@ func (s *Server) RunTestSuite(ctx context.Context, req *pb.Sp80022TestRequest) (
 	// Transparency fields
 	response.TestsRun = int32(testsRun)                    //nolint:gosec // testsRun <= len(results) <= 15
 	response.TestsSkipped = int32(len(results) - testsRun) //nolint:gosec // bounded by len(results)
-	response.TestsTotal = int32(len(results))              //nolint:gosec // Always 15 and fits int32
-	response.NistCompliant = (testsRun == len(results))
+	response.TestsTotal = int32(len(results))              //nolint:gosec // bounded by the registry size
+	response.NistCompliant = len(opts.Tests) == 0 && testsRun == len(results)
 
 	// Calculate p-value uniformity ONLY for real tests
-	if len(pValues) >= 5 { // Need at least 5 tests for meaningful chi²
-		response.PValueUniformityChi2 = calculatePValueUniformity(pValues)
+	if len(pValues) >= 5 { // Need at least 5 p-values for meaningful chi²
+		response.PValueUniformityChi2 = drift.Uniformity(pValues)
 	} else {
 		response.PValueUniformityChi2 = -1.0 // Not enough data
 	}
 
-	log.Info().
-		Str("request_id", requestID).
+	rec.Verdict = "fail"
+	if nist.SuitePassed(results) {
+		rec.Verdict = "pass"
+	}
+	rec.PassRate = response.OverallPassRate
+	rec.Results = auditResults(results)
+	s.writeAudit(ctx, bitstream, rec)
+	s.notifyRun(ctx, rec, results)
+	s.observeDrift(ctx, driftSource(rec), results)
+
+	middleware.Logger(ctx).Info().
 		Float64("overall_pass_rate", response.OverallPassRate).
 		Float64("p_value_uniformity", response.PValueUniformityChi2).
 		Int64("execution_time_ms", response.ExecutionTimeMs).
@@ -174,12 +354,340 @@ func (s *Server) RunTestSuite(ctx context.Context, req *pb.Sp80022TestRequest) (
 	return response, nil
 }
 
+// SelfTest implements the SelfTest RPC
+func (s *Server) SelfTest(ctx context.Context, req *pb.SelfTestRequest) (*pb.SelfTestResponse, error) {
+	startTime := time.Now()
+	ctx = requestContext(ctx)
+	logger := middleware.Logger(ctx)
+
+	logger.Info().
+		Strs("datasets", req.Datasets).
+		Msg("SelfTest request received")
+
+	for _, name := range req.Datasets {
+		if !slices.Contains(selftest.Datasets, name) {
+			logger.Error().
+				Str("dataset", name).
+				Msg("Request validation failed")
+			metrics.RequestsTotal.WithLabelValues("SelfTest", "error").Inc()
+			return nil, status.Errorf(codes.InvalidArgument, "unknown dataset: %q (valid: %v)", name, selftest.Datasets)
+		}
+	}
+
+	report, err := runSelfTest(ctx, req.Datasets...)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Self-test execution failed")
+		metrics.RequestsTotal.WithLabelValues("SelfTest", "error").Inc()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, status.FromContextError(ctxErr).Err()
+		}
+		return nil, status.Errorf(codes.Internal, "self-test failed: %v", err)
+	}
+
+	metrics.RequestsTotal.WithLabelValues("SelfTest", "success").Inc()
+
+	response := &pb.SelfTestResponse{
+		Timestamp: time.Now().Format(time.RFC3339),
+		Passed:    report.Passed,
+		Tolerance: selftest.Tolerance,
+		Checks:    make([]*pb.SelfTestCheck, len(report.Checks)),
+	}
+
+	for i, c := range report.Checks {
+		response.Checks[i] = &pb.SelfTestCheck{
+			Dataset:        c.Dataset,
+			Test:           c.Test,
+			ExpectedPValue: c.Expected,
+			PValue:         c.PValue,
+			AbsDiff:        c.AbsDiff,
+			Passed:         c.Passed,
+		}
+	}
+	response.ExecutionTimeMs = time.Since(startTime).Milliseconds()
+
+	if report.Passed {
+		logger.Info().
+			Int("checks", len(report.Checks)).
+			Int64("execution_time_ms", response.ExecutionTimeMs).
+			Msg("Self-test passed")
+	} else {
+		for _, c := range report.Failed() {
+			logger.Error().
+				Str("dataset", c.Dataset).
+				Str("test", c.Test).
+				Float64("expected_p_value", c.Expected).
+				Float64("p_value", c.PValue).
+				Float64("abs_diff", c.AbsDiff).
+				Msg("Self-test check failed")
+		}
+	}
+
+	return response, nil
+}
+
+// GenerateAndTest implements the GenerateAndTest RPC
+func (s *Server) GenerateAndTest(ctx context.Context, req *pb.GenerateAndTestRequest) (*pb.GenerateAndTestResponse, error) {
+	startTime := time.Now()
+	ctx = requestContext(ctx)
+	logger := middleware.Logger(ctx)
+
+	bits := int(req.Bits)
+	if bits == 0 {
+		bits = DefaultGeneratedBits
+	}
+
+	logger.Info().
+		Str("generator", req.Generator).
+		Int("bits", bits).
+		Msg("GenerateAndTest request received")
+
+	if err := s.validateBitCount(bits); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Request validation failed")
+		metrics.RequestsTotal.WithLabelValues("GenerateAndTest", "error").Inc()
+		return nil, err
+	}
+
+	seed := req.Seed
+	if len(seed) == 0 {
+		seed = generators.DefaultSeed(req.Generator)
+	}
+
+	bitstream, err := generate(req.Generator, seed, bits)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Sequence generation failed")
+		metrics.RequestsTotal.WithLabelValues("GenerateAndTest", "error").Inc()
+		return nil, fmt.Errorf("generation failed: %w", err)
+	}
+
+	metrics.RequestsTotal.WithLabelValues("GenerateAndTest", "success").Inc()
+
+	rec := audit.Record{Method: "GenerateAndTest", Source: req.Generator, Parameters: auditParameters(req)}
+	result, err := s.runSuite(ctx, startTime, bitstream, nist.RunOptions{}, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GenerateAndTestResponse{
+		Generator: req.Generator,
+		Seed:      seed,
+		Result:    result,
+	}, nil
+}
+
+// TestSource implements the TestSource RPC
+func (s *Server) TestSource(ctx context.Context, req *pb.TestSourceRequest) (*pb.TestSourceResponse, error) {
+	startTime := time.Now()
+	ctx = requestContext(ctx)
+	logger := middleware.Logger(ctx)
+
+	bits := int(req.Bits)
+	if bits == 0 {
+		bits = DefaultGeneratedBits
+	}
+
+	logger.Info().
+		Str("source", req.Source).
+		Str("path", req.Path).
+		Int("bits", bits).
+		Msg("TestSource request received")
+
+	if err := s.authorizeSource(req); err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("TestSource request denied")
+		metrics.RequestsTotal.WithLabelValues("TestSource", "denied").Inc()
+		return nil, err
+	}
+
+	if err := s.validateBitCount(bits); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Request validation failed")
+		metrics.RequestsTotal.WithLabelValues("TestSource", "error").Inc()
+		return nil, err
+	}
+
+	bitstream, err := readSource(ctx, sources.Spec{Name: req.Source, Seed: req.Seed, Path: req.Path}, bits)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Source read failed")
+		metrics.RequestsTotal.WithLabelValues("TestSource", "error").Inc()
+		return nil, fmt.Errorf("source read failed: %w", err)
+	}
+
+	metrics.RequestsTotal.WithLabelValues("TestSource", "success").Inc()
+
+	rec := audit.Record{Method: "TestSource", Source: req.Source, Parameters: auditParameters(req)}
+	result, err := s.runSuite(ctx, startTime, bitstream, nist.RunOptions{}, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.TestSourceResponse{
+		Source: req.Source,
+		Result: result,
+	}, nil
+}
+
+// recordPValueMetrics adds the sub-test p-values of a test result to the p-value
+// distribution and uniformity window of its source and counts its failed sub-tests.
+// The smallest p-value of several sub-tests is not uniformly distributed, so it is not
+// recorded.
+func (s *Server) recordPValueMetrics(result nist.TestResult, source string) {
+	pValues := result.SubTestPValues()
+	if uniformity, ok := s.uniformity.observe(result.Name, source, pValues); ok {
+		metrics.PValueUniformity.WithLabelValues(result.Name, source).Set(uniformity)
+	}
+	for i, p := range pValues {
+		metrics.RecordPValueDistribution(result.Name, source, p)
+		if len(pValues) > 1 && p < Alpha {
+			metrics.RecordSubTestFailure(result.Name, i)
+		}
+	}
+}
+
+// requestContext returns ctx with a request ID and request logger. The request ID interceptor
+// normally assigns them; calls that bypass it get a new UUID.
+func requestContext(ctx context.Context) context.Context {
+	if middleware.GetRequestID(ctx) != "" {
+		return ctx
+	}
+	return middleware.WithRequestID(ctx, uuid.New().String())
+}
+
+// writeAudit completes rec with the request ID, caller and input digest and appends it
+// to the audit log. A failed write is logged but does not fail the request.
+func (s *Server) writeAudit(ctx context.Context, bitstream []byte, rec audit.Record) {
+	if s.auditLog == nil {
+		return
+	}
+
+	digest := sha256.Sum256(bitstream)
+	rec.Time = time.Now().UTC()
+	rec.RequestID = middleware.GetRequestID(ctx)
+	rec.Identity = middleware.Identity(ctx)
+	rec.InputSHA256 = hex.EncodeToString(digest[:])
+	rec.InputBits = len(bitstream) * 8
+
+	if err := s.auditLog.Write(rec); err != nil {
+		middleware.Logger(ctx).Error().
+			Err(err).
+			Msg("Audit log write failed")
+	}
+}
+
+// auditParameters returns the request parameters as protobuf JSON, or nil if none were given
+func auditParameters(m proto.Message) json.RawMessage {
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return nil
+	}
+	raw, err := protojson.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// auditResults converts the test results for the audit log, with the sub-test p-values of
+// tests that have several
+func auditResults(results []nist.TestResult) []audit.Result {
+	out := make([]audit.Result, len(results))
+	for i, r := range results {
+		out[i] = audit.Result{Test: r.Name, PValue: r.PValue, Passed: r.Passed, Skipped: r.PValue < 0}
+		if len(r.Tails) > 1 {
+			out[i].PValues = make([]float64, len(r.Tails))
+			for j, tail := range r.Tails {
+				out[i].PValues[j] = tail.PValue()
+			}
+		}
+	}
+	return out
+}
+
+// authorizeSource checks that source testing is enabled and that a file source reads an
+// allowed path. The scopes of the caller are checked by the authorization interceptor
+// (TestSource in AUTH_METHOD_SCOPES)
+func (s *Server) authorizeSource(req *pb.TestSourceRequest) error {
+	s.mu.RLock()
+	enabled, allowedPaths := s.sourcesEnabled, s.sourcesAllowedPaths
+	s.mu.RUnlock()
+
+	if !enabled {
+		return status.Error(codes.PermissionDenied, "source testing is disabled")
+	}
+
+	if req.Source == sources.File && !slices.Contains(allowedPaths, filepath.Clean(req.Path)) {
+		return status.Errorf(codes.PermissionDenied, "path not allowed: %q", req.Path)
+	}
+
+	return nil
+}
+
+// authorizePrecise rejects requests for precise p-values unless the server enables them
+func (s *Server) authorizePrecise(cfg *pb.Sp80022TestConfig) error {
+	if !cfg.GetPrecisePValues() {
+		return nil
+	}
+
+	s.mu.RLock()
+	enabled := s.preciseEnabled
+	s.mu.RUnlock()
+
+	if !enabled {
+		return status.Error(codes.PermissionDenied, "precise p-values are disabled")
+	}
+	return nil
+}
+
+// validateBitCount checks the requested length of a generated or sampled sequence
+func (s *Server) validateBitCount(bits int) error {
+	if bits%8 != 0 {
+		return fmt.Errorf("bits must be a multiple of 8: got %d", bits)
+	}
+	if bits < nist.MinBits {
+		return fmt.Errorf("insufficient bits: got %d, need at least %d", bits, nist.MinBits)
+	}
+	if bits > nist.MaxBits {
+		return fmt.Errorf("too many bits: got %d, maximum %d", bits, nist.MaxBits)
+	}
+	return s.checkMemory(bits, nil)
+}
+
+// checkMemory enforces the DFT memory ceiling before the selected tests are run
+func (s *Server) checkMemory(bits int, tests []string) error {
+	s.mu.RLock()
+	limit := s.dftMemoryLimit
+	s.mu.RUnlock()
+
+	if limit <= 0 || (len(tests) > 0 && !slices.Contains(tests, "discrete_fourier_transform")) {
+		return nil
+	}
+	if need := nist.DFTMemoryBytes(bits); need > limit {
+		return status.Errorf(codes.ResourceExhausted, "discrete_fourier_transform needs %d bytes for %d bits, limit is %d bytes",
+			need, bits, limit)
+	}
+	return nil
+}
+
 // validateRequest validates the test request
 func (s *Server) validateRequest(req *pb.Sp80022TestRequest) error {
 	if len(req.Bitstream) == 0 {
 		return fmt.Errorf("bitstream cannot be empty")
 	}
 
+	for _, prefix := range []string{generatorSourcePrefix, hostSourcePrefix} {
+		if strings.HasPrefix(req.SourceLabel, prefix) {
+			return fmt.Errorf("source_label must not start with %q, which is reserved for the sources of the service", prefix)
+		}
+	}
+
 	numBits := len(req.Bitstream) * 8
 
 	// Check minimum bits (Universal Test requires 387,840)
@@ -194,46 +702,90 @@ func (s *Server) validateRequest(req *pb.Sp80022TestRequest) error {
 			numBits, nist.MaxBits, nist.MaxBits/8)
 	}
 
+	// Check test selection and parameters
+	opts, err := runOptions(req.Config)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := nist.DefaultRegistry.Validate(opts); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := checkUniversalBlockLength(opts); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	return s.checkMemory(numBits, opts.Tests)
+}
+
+// checkUniversalBlockLength rejects a Universal block length that no request can
+// satisfy. nist.UniversalStatistical supports L up to 16, but within MaxBits the
+// service admits sequences for L up to 9 only
+func checkUniversalBlockLength(opts nist.RunOptions) error {
+	if len(opts.Tests) > 0 && !slices.Contains(opts.Tests, "universal_statistical") {
+		return nil
+	}
+	params := opts.Params["universal_statistical"]
+	L := params["block_length"]
+	if L == 0 {
+		return nil
+	}
+	if need := nist.UniversalMinBits(L, params["init_blocks"]); need > nist.MaxBits {
+		return fmt.Errorf("universal_statistical block length %d needs %d bits, more than the maximum of %d (largest block length %d)",
+			L, need, nist.MaxBits, nist.UniversalBlockLength(nist.MaxBits))
+	}
 	return nil
 }
 
-// calculatePValueUniformity performs a chi-squared test on p-value distribution
-// NIST expects p-values to be uniformly distributed in [0, 1]
-func calculatePValueUniformity(pValues []float64) float64 {
-	if len(pValues) == 0 {
-		return 0.0
+// runOptions maps the request configuration to registry run options; unset (zero)
+// parameters keep the test defaults
+func runOptions(cfg *pb.Sp80022TestConfig) (nist.RunOptions, error) {
+	opts := nist.RunOptions{Params: map[string]nist.Params{}}
+	if cfg == nil {
+		return opts, nil
 	}
 
-	// Use 10 bins as per NIST specification
-	const numBins = 10
-	bins := make([]int, numBins)
+	opts.Tests = cfg.Tests
 
-	// Distribute p-values into bins
-	for _, pval := range pValues {
-		if pval < 0 || pval > 1 {
-			continue // Skip invalid p-values
+	set := func(test, param string, value int32) {
+		if value == 0 {
+			return
 		}
-
-		binIndex := int(pval * float64(numBins))
-		if binIndex == numBins {
-			binIndex = numBins - 1 // Handle p-value = 1.0
+		if opts.Params[test] == nil {
+			opts.Params[test] = nist.Params{}
 		}
-		bins[binIndex]++
+		opts.Params[test][param] = int(value)
 	}
-
-	// Calculate chi-squared statistic
-	expectedCount := float64(len(pValues)) / float64(numBins)
-	chi2 := 0.0
-
-	for _, observed := range bins {
-		diff := float64(observed) - expectedCount
-		chi2 += (diff * diff) / expectedCount
+	set("block_frequency", "block_length", cfg.BlockFrequencyBlockLength)
+	set("non_overlapping_template", "template_length", cfg.NonOverlappingTemplateBlockLength)
+	set("overlapping_template", "template_length", cfg.OverlappingTemplateBlockLength)
+	set("approximate_entropy", "block_length", cfg.ApproximateEntropyBlockLength)
+	set("serial", "block_length", cfg.SerialBlockLength)
+	set("linear_complexity", "block_length", cfg.LinearComplexitySequenceLength)
+	set("binary_matrix_rank", "rows", cfg.BinaryMatrixRankRows)
+	set("binary_matrix_rank", "columns", cfg.BinaryMatrixRankColumns)
+	set("universal_statistical", "block_length", cfg.UniversalBlockLength)
+	set("universal_statistical", "init_blocks", cfg.UniversalInitBlocks)
+	set("overlapping_template", "block_length", cfg.OverlappingTemplateSubstringLength)
+	set("overlapping_template", "degrees_of_freedom", cfg.OverlappingTemplateDegreesOfFreedom)
+
+	if pattern := cfg.OverlappingTemplatePattern; pattern != "" {
+		if len(pattern) > nist.OverlappingTemplateMaxLength {
+			return opts, fmt.Errorf("overlapping template pattern too long: %d bits (maximum %d)", len(pattern), nist.OverlappingTemplateMaxLength)
+		}
+		value, err := strconv.ParseUint(pattern, 2, 64)
+		if err != nil {
+			return opts, fmt.Errorf("overlapping template pattern must consist of 0 and 1: %q", pattern)
+		}
+		if m := cfg.OverlappingTemplateBlockLength; m != 0 && int(m) != len(pattern) {
+			return opts, fmt.Errorf("overlapping template pattern has %d bits but template length is %d", len(pattern), m)
+		}
+		set("overlapping_template", "template_length", int32(len(pattern))) //nolint:gosec // bounded above
+		opts.Params["overlapping_template"]["template"] = int(value)
 	}
+	if cfg.LinearComplexityReportHistogram {
+		set("linear_complexity", "report_histogram", 1)
+	}
+	opts.Precise = cfg.PrecisePValues
 
-	// Convert chi2 to p-value using incomplete gamma function
-	// For now, return the raw chi2 statistic
-	df := float64(numBins - 1)
-	pValue := mathext.GammaIncRegComp(df/2.0, chi2/2.0)
-
-	return pValue
+	return opts, nil
 }
@@ -1,12 +1,34 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/AmmannChristian/go-authx/grpcserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/audit"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/drift"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/generators"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/metrics"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/middleware"
 	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/notify"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/selftest"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/sources"
 	pb "github.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb"
 )
 
@@ -24,18 +46,6 @@ func TestValidateRequest(t *testing.T) {
 	}
 }
 
-func TestCalculatePValueUniformity(t *testing.T) {
-	values := []float64{0.1, 0.3, 0.5, 0.7, 0.9}
-	chi2 := calculatePValueUniformity(values)
-	if chi2 <= 0 {
-		t.Fatalf("expected positive chi2, got %f", chi2)
-	}
-
-	if got := calculatePValueUniformity(nil); got != 0 {
-		t.Fatalf("expected zero for empty input, got %f", got)
-	}
-}
-
 func TestRunTestSuiteSuccessAndFailure(t *testing.T) {
 	s := NewServer()
 
@@ -81,24 +91,40 @@ func TestValidateRequestEdgeCases(t *testing.T) {
 	}
 }
 
-func TestCalculatePValueUniformityEdgeCases(t *testing.T) {
-	// Test boundary conditions
-	pValues := []float64{
-		-0.1, // Should be ignored
-		1.1,  // Should be ignored
-		1.0,  // Should go to last bin
-		0.0,  // Should go to first bin
-		0.5,
-	}
+func TestValidateRequestUniversalBlockLength(t *testing.T) {
+	s := NewServer()
+	bitstream := make([]byte, nist.MinBits/8)
 
-	// We have 3 valid values: 1.0, 0.0, 0.5
-	// Bins: [1, 0, 0, 0, 0, 1, 0, 0, 0, 1] (roughly)
-	// Expected count per bin: 3/10 = 0.3
-	// Chi2 should be calculated.
+	tests := []struct {
+		name    string
+		cfg     *pb.Sp80022TestConfig
+		wantErr bool
+	}{
+		{"largest block length", &pb.Sp80022TestConfig{UniversalBlockLength: 9}, false},
+		{"block length beyond the maximum request", &pb.Sp80022TestConfig{UniversalBlockLength: 10}, true},
+		{"initialization blocks beyond the maximum request", &pb.Sp80022TestConfig{UniversalBlockLength: 9, UniversalInitBlocks: 600000}, true},
+		{"universal test not selected", &pb.Sp80022TestConfig{Tests: []string{"runs"}, UniversalBlockLength: 16}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateRequest(&pb.Sp80022TestRequest{Bitstream: bitstream, Config: tt.cfg})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateRequest error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "largest block length 9") {
+				t.Errorf("error does not name the largest block length: %v", err)
+			}
+		})
+	}
 
-	chi2 := calculatePValueUniformity(pValues)
-	if chi2 <= 0 {
-		t.Errorf("expected positive chi2, got %f", chi2)
+	// An admitted block length the input is too short for is skipped with its requirement
+	req := &pb.Sp80022TestRequest{Bitstream: bitstream, Config: &pb.Sp80022TestConfig{Tests: []string{"universal_statistical"}, UniversalBlockLength: 9}}
+	resp, err := s.RunTestSuite(context.Background(), req)
+	if err != nil {
+		t.Fatalf("RunTestSuite failed: %v", err)
+	}
+	if r := resp.Results[0]; r.PValue != -1 || !strings.Contains(r.GetWarning(), "4654080") {
+		t.Errorf("expected skipped result with the bits needed for L = 9: %+v", r)
 	}
 }
 
@@ -130,12 +156,12 @@ func TestRunTestSuiteCoverage(t *testing.T) {
 }
 
 func TestRunTestSuiteMocked(t *testing.T) {
-	orig := runAllTests
-	defer func() { runAllTests = orig }()
+	orig := runTests
+	defer func() { runTests = orig }()
 
 	s := NewServer()
 
-	runAllTests = func(bitstream []byte) ([]nist.TestResult, error) {
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
 		return nil, fmt.Errorf("mock error")
 	}
 	validBits := make([]byte, nist.MinBits/8)
@@ -144,7 +170,7 @@ func TestRunTestSuiteMocked(t *testing.T) {
 		t.Error("expected error from mocked RunAllTests")
 	}
 
-	runAllTests = func(bitstream []byte) ([]nist.TestResult, error) {
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
 		return []nist.TestResult{
 			{Name: "SkippedTest", PValue: -1.0, Passed: false},
 			{Name: "ValidTest", PValue: 0.5, Passed: true, Proportion: 1.0},
@@ -165,3 +191,800 @@ func TestRunTestSuiteMocked(t *testing.T) {
 		t.Errorf("expected -1.0 for uniformity chi2, got %f", resp.PValueUniformityChi2)
 	}
 }
+
+func TestRequestID(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(audit.Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	s := NewServer(WithAuditLog(auditLog))
+
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{{Name: "frequency_monobit", PValue: 0.5, Passed: true}}, nil
+	}
+	req := &pb.Sp80022TestRequest{Bitstream: make([]byte, nist.MinBits/8)}
+
+	t.Run("from context", func(t *testing.T) {
+		resp, err := s.RunTestSuite(middleware.WithRequestID(context.Background(), "req-42"), req)
+		if err != nil {
+			t.Fatalf("RunTestSuite failed: %v", err)
+		}
+		if resp.RequestId != "req-42" {
+			t.Errorf("expected request ID req-42, got %q", resp.RequestId)
+		}
+	})
+
+	t.Run("without interceptor", func(t *testing.T) {
+		resp, err := s.GenerateAndTest(context.Background(), &pb.GenerateAndTestRequest{Generator: generators.LCG, Bits: nist.MinBits})
+		if err != nil {
+			t.Fatalf("GenerateAndTest failed: %v", err)
+		}
+		if len(resp.Result.RequestId) != 36 {
+			t.Errorf("expected a generated UUID, got %q", resp.Result.RequestId)
+		}
+	})
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"request_id":"req-42"`) {
+		t.Errorf("audit records do not carry the request IDs: %s", raw)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	s := NewServer()
+
+	resp, err := s.SelfTest(context.Background(), &pb.SelfTestRequest{Datasets: []string{selftest.DatasetSqrt2}})
+	if err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+	if !resp.Passed {
+		t.Fatalf("expected self-test to pass: %+v", resp.Checks)
+	}
+	if len(resp.Checks) == 0 {
+		t.Fatal("expected checks in response")
+	}
+	if resp.Tolerance != selftest.Tolerance {
+		t.Fatalf("unexpected tolerance: %g", resp.Tolerance)
+	}
+
+	if _, err := s.SelfTest(context.Background(), &pb.SelfTestRequest{Datasets: []string{"unknown"}}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for unknown dataset, got %v", err)
+	}
+}
+
+func TestSelfTestExecutionError(t *testing.T) {
+	orig := runSelfTest
+	defer func() { runSelfTest = orig }()
+
+	runSelfTest = func(ctx context.Context, datasets ...string) (*selftest.Report, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("expansion failed")
+	}
+
+	s := NewServer()
+	if _, err := s.SelfTest(context.Background(), &pb.SelfTestRequest{}); status.Code(err) != codes.Internal {
+		t.Errorf("expected Internal for an execution error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.SelfTest(ctx, &pb.SelfTestRequest{}); status.Code(err) != codes.Canceled {
+		t.Errorf("expected Canceled for a cancelled request, got %v", err)
+	}
+}
+
+func TestSelfTestMockedFailure(t *testing.T) {
+	orig := runSelfTest
+	defer func() { runSelfTest = orig }()
+
+	runSelfTest = func(ctx context.Context, datasets ...string) (*selftest.Report, error) {
+		return &selftest.Report{
+			Passed: false,
+			Checks: []selftest.Check{
+				{Dataset: "pi", Test: "frequency_monobit", Expected: 0.578211, PValue: 0.5, AbsDiff: 0.078211, Passed: false},
+			},
+		}, nil
+	}
+
+	resp, err := NewServer().SelfTest(context.Background(), &pb.SelfTestRequest{})
+	if err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+	if resp.Passed || len(resp.Checks) != 1 || resp.Checks[0].Passed {
+		t.Fatalf("expected failing self-test response, got %+v", resp)
+	}
+}
+
+func TestGenerateAndTest(t *testing.T) {
+	s := NewServer()
+
+	resp, err := s.GenerateAndTest(context.Background(), &pb.GenerateAndTestRequest{
+		Generator: generators.XOR,
+		Bits:      nist.MinBits,
+	})
+	if err != nil {
+		t.Fatalf("GenerateAndTest failed: %v", err)
+	}
+	if resp.Generator != generators.XOR || len(resp.Seed) == 0 {
+		t.Fatalf("unexpected generator or seed in response: %+v", resp)
+	}
+	if resp.Result.SampleSizeBits != nist.MinBits {
+		t.Fatalf("expected %d bits, got %d", nist.MinBits, resp.Result.SampleSizeBits)
+	}
+
+	// The 127-bit XOR recurrence has a linear complexity far below that of a random sequence.
+	for _, r := range resp.Result.Results {
+		if r.Name == "linear_complexity" && r.Passed {
+			t.Fatalf("expected linear_complexity to fail for the XOR generator, p=%f", r.PValue)
+		}
+	}
+}
+
+func TestGenerateAndTestErrors(t *testing.T) {
+	s := NewServer()
+
+	tests := []struct {
+		name string
+		req  *pb.GenerateAndTestRequest
+	}{
+		{"unknown generator", &pb.GenerateAndTestRequest{Generator: "unknown", Bits: nist.MinBits}},
+		{"invalid seed", &pb.GenerateAndTestRequest{Generator: generators.XOR, Seed: []byte{0}, Bits: nist.MinBits}},
+		{"not a multiple of 8", &pb.GenerateAndTestRequest{Generator: generators.LCG, Bits: nist.MinBits + 1}},
+		{"too short", &pb.GenerateAndTestRequest{Generator: generators.LCG, Bits: 1024}},
+		{"too long", &pb.GenerateAndTestRequest{Generator: generators.LCG, Bits: nist.MaxBits + 8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.GenerateAndTest(context.Background(), tt.req); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestGenerateAndTestMocked(t *testing.T) {
+	origGenerate, origRun := generate, runTests
+	defer func() { generate, runTests = origGenerate, origRun }()
+
+	var gotBits int
+	generate = func(name string, seed []byte, nBits int) ([]byte, error) {
+		gotBits = nBits
+		return make([]byte, nBits/8), nil
+	}
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return nil, fmt.Errorf("mock error")
+	}
+
+	s := NewServer()
+	if _, err := s.GenerateAndTest(context.Background(), &pb.GenerateAndTestRequest{Generator: generators.LCG}); err == nil {
+		t.Fatal("expected error from mocked RunAllTests")
+	}
+	if gotBits != DefaultGeneratedBits {
+		t.Fatalf("expected default length %d, got %d", DefaultGeneratedBits, gotBits)
+	}
+}
+
+func TestTestSourceAuthorization(t *testing.T) {
+	admin := grpcserver.WithTokenClaims(context.Background(), &grpcserver.TokenClaims{Subject: "ops", Scopes: []string{"nist:admin"}})
+	enabled := NewServer(WithSourceTesting([]string{"/dev/urandom"}))
+	disabledLater := NewServer(WithSourceTesting([]string{"/dev/urandom"}))
+	disabledLater.SetSourceTesting(false, nil)
+
+	tests := []struct {
+		name   string
+		server *Server
+		ctx    context.Context
+		req    *pb.TestSourceRequest
+	}{
+		{"disabled", NewServer(), admin, &pb.TestSourceRequest{Source: sources.CryptoRand}},
+		{"path not allowed", enabled, admin, &pb.TestSourceRequest{Source: sources.File, Path: "/etc/shadow"}},
+		{"disabled while running", disabledLater, admin, &pb.TestSourceRequest{Source: sources.CryptoRand}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.server.TestSource(tt.ctx, tt.req)
+			if status.Code(err) != codes.PermissionDenied {
+				t.Fatalf("expected PermissionDenied, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTestSource(t *testing.T) {
+	origRun := runTests
+	defer func() { runTests = origRun }()
+
+	var gotBits int
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		gotBits = len(bitstream) * 8
+		return []nist.TestResult{{Name: "frequency_monobit", PValue: 0.5, Passed: true}}, nil
+	}
+
+	s := NewServer(WithSourceTesting([]string{"/dev/urandom"}))
+	ctx := grpcserver.WithTokenClaims(context.Background(), &grpcserver.TokenClaims{Subject: "ops", Scopes: []string{"nist:admin"}})
+
+	resp, err := s.TestSource(ctx, &pb.TestSourceRequest{Source: sources.PCG, Seed: []byte{1}})
+	if err != nil {
+		t.Fatalf("TestSource failed: %v", err)
+	}
+	if resp.Source != sources.PCG || gotBits != DefaultGeneratedBits {
+		t.Fatalf("unexpected response for %d bits: %+v", gotBits, resp)
+	}
+
+	if _, err := s.TestSource(ctx, &pb.TestSourceRequest{Source: sources.File, Path: "/dev/urandom", Bits: nist.MinBits}); err != nil {
+		t.Fatalf("TestSource on allowed path failed: %v", err)
+	}
+
+	if _, err := s.TestSource(ctx, &pb.TestSourceRequest{Source: sources.PCG, Bits: 1024}); err == nil {
+		t.Fatal("expected error for too few bits")
+	}
+	if _, err := s.TestSource(ctx, &pb.TestSourceRequest{Source: sources.CryptoRand, Seed: []byte{1}}); err == nil {
+		t.Fatal("expected error for seeded crypto_rand")
+	}
+
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return nil, fmt.Errorf("mock error")
+	}
+	if _, err := s.TestSource(ctx, &pb.TestSourceRequest{Source: sources.ChaCha8}); err == nil {
+		t.Fatal("expected error from mocked RunAllTests")
+	}
+}
+
+func TestRunTestSuiteConfig(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	var got nist.RunOptions
+	runTests = func(_ context.Context, bitstream []byte, opts nist.RunOptions) ([]nist.TestResult, error) {
+		got = opts
+		return []nist.TestResult{
+			{Name: "runs", PValue: 0.4, Passed: true},
+			{Name: "serial", PValue: 0.6, Passed: true},
+		}, nil
+	}
+
+	s := NewServer(WithPrecisePValues())
+	validBits := make([]byte, nist.MinBits/8)
+	resp, err := s.RunTestSuite(context.Background(), &pb.Sp80022TestRequest{
+		Bitstream: validBits,
+		Config: &pb.Sp80022TestConfig{
+			SerialBlockLength:         12,
+			BlockFrequencyBlockLength: 256,
+			Tests:                     []string{"serial", "runs"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("RunTestSuite failed: %v", err)
+	}
+	if len(got.Tests) != 2 || got.Params["serial"]["block_length"] != 12 || got.Params["block_frequency"]["block_length"] != 256 {
+		t.Fatalf("unexpected run options: %+v", got)
+	}
+	if _, ok := got.Params["linear_complexity"]; ok {
+		t.Fatal("unset parameters must keep their defaults")
+	}
+	if resp.NistCompliant || resp.TestsTotal != 2 {
+		t.Fatalf("a partial selection must not be NIST compliant: %+v", resp)
+	}
+
+	resp, err = s.RunTestSuite(context.Background(), &pb.Sp80022TestRequest{
+		Bitstream: validBits,
+		Config: &pb.Sp80022TestConfig{
+			LinearComplexityReportHistogram:     true,
+			BinaryMatrixRankRows:                16,
+			BinaryMatrixRankColumns:             48,
+			UniversalBlockLength:                7,
+			UniversalInitBlocks:                 2000,
+			OverlappingTemplatePattern:          "0110",
+			OverlappingTemplateDegreesOfFreedom: 3,
+			PrecisePValues:                      true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("RunTestSuite failed: %v", err)
+	}
+	if got.Params["linear_complexity"]["report_histogram"] != 1 {
+		t.Fatalf("histogram option not mapped: %+v", got)
+	}
+	if rank := got.Params["binary_matrix_rank"]; rank["rows"] != 16 || rank["columns"] != 48 {
+		t.Fatalf("matrix dimensions not mapped: %+v", got)
+	}
+	if u := got.Params["universal_statistical"]; u["block_length"] != 7 || u["init_blocks"] != 2000 {
+		t.Fatalf("universal parameters not mapped: %+v", got)
+	}
+	if o := got.Params["overlapping_template"]; o["template_length"] != 4 || o["template"] != 0b0110 || o["degrees_of_freedom"] != 3 {
+		t.Fatalf("overlapping template parameters not mapped: %+v", got)
+	}
+	if !got.Precise {
+		t.Fatalf("precise option not mapped: %+v", got)
+	}
+
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{
+			{Name: "linear_complexity", PValue: 0.3, Passed: true, Histogram: []int{1, 2, 3}},
+			{Name: "runs", PValue: 0.25, Passed: true, Precise: &nist.PrecisePValue{PValue: 0.25000000001, Discrepancy: 1e-11, SubTests: []float64{0.25000000001}}},
+		}, nil
+	}
+	resp, err = s.RunTestSuite(context.Background(), &pb.Sp80022TestRequest{Bitstream: validBits})
+	if err != nil {
+		t.Fatalf("RunTestSuite failed: %v", err)
+	}
+	if h := resp.Results[0].Histogram; len(h) != 3 || h[2] != 3 {
+		t.Fatalf("histogram not returned: %v", h)
+	}
+	if r := resp.Results[0]; r.PValuePrecise != nil || r.PValueDiscrepancy != nil {
+		t.Fatalf("precise p-value reported without precise mode: %v", r)
+	}
+	if r := resp.Results[1]; r.GetPValuePrecise() != 0.25000000001 || r.GetPValueDiscrepancy() != 1e-11 || len(r.PValuesPrecise) != 1 {
+		t.Fatalf("precise p-value not returned: %v", r)
+	}
+
+	invalid := []*pb.Sp80022TestConfig{
+		{SerialBlockLength: 40},
+		{LinearComplexitySequenceLength: 400},
+		{NonOverlappingTemplateBlockLength: 10},
+		{BinaryMatrixRankColumns: 65},
+		{UniversalBlockLength: 5},
+		{UniversalInitBlocks: 640},
+		{UniversalBlockLength: 7, UniversalInitBlocks: 1000},
+		{OverlappingTemplatePattern: "01a"},
+		{OverlappingTemplatePattern: "11111111111111111"},
+		{OverlappingTemplatePattern: "0110", OverlappingTemplateBlockLength: 9},
+		{OverlappingTemplateSubstringLength: 8},
+		{Tests: []string{"bogus"}},
+	}
+	for _, cfg := range invalid {
+		if _, err := s.RunTestSuite(context.Background(), &pb.Sp80022TestRequest{Bitstream: validBits, Config: cfg}); err == nil {
+			t.Errorf("expected error for config %+v", cfg)
+		}
+	}
+}
+
+func TestPrecisePValuesDisabled(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{{Name: "runs", PValue: 0.5, Passed: true}}, nil
+	}
+
+	s := NewServer()
+	req := &pb.Sp80022TestRequest{
+		Bitstream: make([]byte, nist.MinBits/8),
+		Config:    &pb.Sp80022TestConfig{PrecisePValues: true},
+	}
+	if _, err := s.RunTestSuite(context.Background(), req); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied without WithPrecisePValues, got %v", err)
+	}
+	if _, err := s.RunTestSuite(context.Background(), &pb.Sp80022TestRequest{Bitstream: req.Bitstream}); err != nil {
+		t.Fatalf("request without precise p-values failed: %v", err)
+	}
+
+	s.SetPrecisePValues(true)
+	if _, err := s.RunTestSuite(context.Background(), req); err != nil {
+		t.Fatalf("RunTestSuite failed after enabling precise p-values: %v", err)
+	}
+	s.SetPrecisePValues(false)
+	if _, err := s.RunTestSuite(context.Background(), req); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied after disabling precise p-values, got %v", err)
+	}
+}
+
+func TestDFTMemoryLimit(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{{Name: "runs", PValue: 0.5, Passed: true}}, nil
+	}
+
+	validBits := make([]byte, nist.MinBits/8)
+	limit := nist.DFTMemoryBytes(nist.MinBits) - 1
+	s := NewServer(WithDFTMemoryLimit(limit))
+
+	_, err := s.RunTestSuite(context.Background(), &pb.Sp80022TestRequest{Bitstream: validBits})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+
+	// Selections without the DFT test are not limited.
+	_, err = s.RunTestSuite(context.Background(), &pb.Sp80022TestRequest{
+		Bitstream: validBits,
+		Config:    &pb.Sp80022TestConfig{Tests: []string{"runs"}},
+	})
+	if err != nil {
+		t.Fatalf("expected selection without DFT to pass, got %v", err)
+	}
+
+	_, err = s.GenerateAndTest(context.Background(), &pb.GenerateAndTestRequest{Generator: "lcg", Bits: nist.MinBits})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted for GenerateAndTest, got %v", err)
+	}
+
+	if _, err := NewServer(WithDFTMemoryLimit(limit+1)).RunTestSuite(context.Background(), &pb.Sp80022TestRequest{Bitstream: validBits}); err != nil {
+		t.Fatalf("expected request within the limit to pass, got %v", err)
+	}
+
+	// The limit of a running server can be raised.
+	s.SetDFTMemoryLimit(limit + 1)
+	if _, err := s.RunTestSuite(context.Background(), &pb.Sp80022TestRequest{Bitstream: validBits}); err != nil {
+		t.Fatalf("expected request within the raised limit to pass, got %v", err)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(audit.Config{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	s := NewServer(WithAuditLog(auditLog), WithSourceTesting(nil))
+
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{
+			{Name: "frequency_monobit", PValue: 0.5, Passed: true, Tails: []nist.Tail{{Kind: nist.ErfcTail, X: 0.5}}},
+			{Name: "cumulative_sums", PValue: 0.004, Passed: false, Tails: []nist.Tail{{Kind: nist.ErfcTail, X: 0.1}, {Kind: nist.ErfcTail, X: 2}}},
+			{Name: "random_excursions", PValue: -1},
+		}, nil
+	}
+	bitstream := make([]byte, nist.MinBits/8)
+	ctx := grpcserver.WithTokenClaims(context.Background(), &grpcserver.TokenClaims{Subject: "alice", Scopes: []string{"nist:admin"}})
+	req := &pb.Sp80022TestRequest{Bitstream: bitstream, SourceLabel: "trng-7", Config: &pb.Sp80022TestConfig{Tests: []string{"frequency_monobit"}}}
+	if _, err := s.RunTestSuite(ctx, req); err != nil {
+		t.Fatalf("RunTestSuite failed: %v", err)
+	}
+	if _, err := s.GenerateAndTest(ctx, &pb.GenerateAndTestRequest{Generator: generators.LCG, Bits: nist.MinBits}); err != nil {
+		t.Fatalf("GenerateAndTest failed: %v", err)
+	}
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return nil, fmt.Errorf("mock error")
+	}
+	if _, err := s.TestSource(ctx, &pb.TestSourceRequest{Source: sources.PCG, Seed: []byte{1}}); err == nil {
+		t.Fatal("expected error from mocked RunAllTests")
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _, err := audit.Verify(bytes.NewReader(raw), ""); err != nil || n != 3 {
+		t.Fatalf("Verify = %d, %v; want 3 chained records", n, err)
+	}
+
+	var records []audit.Record
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var rec audit.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+
+	digest := sha256.Sum256(bitstream)
+	suite := records[0]
+	if suite.Method != "RunTestSuite" || suite.Source != "trng-7" || suite.Identity != "alice" || suite.RequestID == "" {
+		t.Errorf("unexpected record: %+v", suite)
+	}
+	if suite.InputSHA256 != hex.EncodeToString(digest[:]) || suite.InputBits != nist.MinBits {
+		t.Errorf("unexpected input digest %s of %d bits", suite.InputSHA256, suite.InputBits)
+	}
+	if string(suite.Parameters) != `{"tests":["frequency_monobit"]}` {
+		t.Errorf("unexpected parameters: %s", suite.Parameters)
+	}
+	if suite.Verdict != "fail" || suite.PassRate != 0.5 || len(suite.Results) != 3 {
+		t.Errorf("unexpected verdict %s, pass rate %v, results %+v", suite.Verdict, suite.PassRate, suite.Results)
+	}
+	if r := suite.Results[1]; len(r.PValues) != 2 || r.PValues[0] != math.Erfc(0.1) {
+		t.Errorf("unexpected sub-test p-values: %+v", r)
+	}
+	if r := suite.Results[2]; !r.Skipped {
+		t.Errorf("expected skipped result: %+v", r)
+	}
+
+	if gen := records[1]; gen.Method != "GenerateAndTest" || gen.Source != generators.LCG || !strings.Contains(string(gen.Parameters), `"generator":"lcg"`) {
+		t.Errorf("unexpected record: %+v", gen)
+	}
+	if src := records[2]; src.Method != "TestSource" || src.Verdict != "error" || src.Error != "mock error" {
+		t.Errorf("unexpected record: %+v", src)
+	}
+}
+
+func TestAuditVerdictSubTests(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(audit.Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	s := NewServer(WithAuditLog(auditLog))
+
+	// One of ten sub-tests below Alpha: the smallest p-value fails, the test does not
+	tails := []nist.Tail{{Kind: nist.ErfcTail, X: 1.9}}
+	for i := 0; i < 9; i++ {
+		tails = append(tails, nist.Tail{Kind: nist.ErfcTail, X: 0.1})
+	}
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{{Name: "audit_subtests", PValue: tails[0].PValue(), Passed: true, Tails: tails}}, nil
+	}
+	req := &pb.Sp80022TestRequest{Bitstream: make([]byte, nist.MinBits/8)}
+	resp, err := s.RunTestSuite(context.Background(), req)
+	if err != nil {
+		t.Fatalf("RunTestSuite failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec audit.Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Results[0].Passed || rec.Verdict != "pass" {
+		t.Errorf("passed %v, audit verdict %s; want both to pass", resp.Results[0].Passed, rec.Verdict)
+	}
+}
+
+func TestPValueMetrics(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{
+			{Name: "metrics_single", PValue: 0.35, Passed: true, Tails: []nist.Tail{{Kind: nist.ErfcTail, X: 0.65}}},
+			{Name: "metrics_multi", PValue: math.Erfc(3), Passed: false, Tails: []nist.Tail{{Kind: nist.ErfcTail, X: 0.1}, {Kind: nist.ErfcTail, X: 3}}},
+			{Name: "metrics_skipped", PValue: -1},
+		}, nil
+	}
+	s := NewServer(WithUniformityWindow(10))
+	req := &pb.Sp80022TestRequest{Bitstream: make([]byte, nist.MinBits/8), SourceLabel: "metrics-trng"}
+	for i := 0; i < 10; i++ {
+		if _, err := s.RunTestSuite(context.Background(), req); err != nil {
+			t.Fatalf("RunTestSuite failed: %v", err)
+		}
+	}
+
+	if got := testutil.CollectAndCount(metrics.PValueDistribution, "nist_p_value_distribution"); got < 2 {
+		t.Errorf("expected p-value distributions of both tests, got %d series", got)
+	}
+	if got := testutil.ToFloat64(metrics.SubTestFailuresTotal.WithLabelValues("metrics_multi", "1")); got != 10 {
+		t.Errorf("expected 10 failures of sub-test 1, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.SubTestFailuresTotal.WithLabelValues("metrics_multi", "0")); got != 0 {
+		t.Errorf("expected no failures of sub-test 0, got %v", got)
+	}
+	// Ten identical p-values in the same bin are far from uniform.
+	if got := testutil.ToFloat64(metrics.PValueUniformity.WithLabelValues("metrics_single", "metrics-trng")); got > 1e-6 {
+		t.Errorf("expected a low uniformity for a constant p-value, got %v", got)
+	}
+}
+
+func TestPValueMetricsSubTests(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	// Ten sub-tests with one p-value per bin: their smallest p-value is always in the
+	// lowest bin, but the sub-test p-values are uniform
+	tails := make([]nist.Tail, 10)
+	for i := range tails {
+		tails[i] = nist.Tail{Kind: nist.ExpTail, X: -math.Log(0.05 + float64(i)/10)}
+	}
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{{Name: "metrics_subtests", PValue: tails[0].PValue(), Passed: true, Tails: tails}}, nil
+	}
+	s := NewServer(WithUniformityWindow(10))
+	req := &pb.Sp80022TestRequest{Bitstream: make([]byte, nist.MinBits/8), SourceLabel: "metrics-subtests"}
+	var resp *pb.Sp80022TestResponse
+	for i := 0; i < 10; i++ {
+		var err error
+		if resp, err = s.RunTestSuite(context.Background(), req); err != nil {
+			t.Fatalf("RunTestSuite failed: %v", err)
+		}
+	}
+
+	if got := testutil.ToFloat64(metrics.PValueUniformity.WithLabelValues("metrics_subtests", "metrics-subtests")); math.Abs(got-1) > 1e-9 {
+		t.Errorf("expected uniformity 1 for one sub-test p-value per bin, got %v", got)
+	}
+	if math.Abs(resp.PValueUniformityChi2-1) > 1e-9 {
+		t.Errorf("expected response uniformity 1 over the sub-test p-values, got %v", resp.PValueUniformityChi2)
+	}
+}
+
+func TestGetSourceStatus(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{
+			{Name: "drift_single", PValue: 0.001, Passed: false},
+			{Name: "drift_multi", PValue: 0.5, Passed: true, Tails: []nist.Tail{{Kind: nist.ErfcTail, X: 0.5}, {Kind: nist.ErfcTail, X: 0.6}}},
+			{Name: "drift_skipped", PValue: -1},
+		}, nil
+	}
+
+	ctx := context.Background()
+	if _, err := NewServer().GetSourceStatus(ctx, &pb.GetSourceStatusRequest{SourceLabel: "drift-trng"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition with drift detection disabled, got %v", err)
+	}
+
+	s := NewServer(WithDriftMonitor(drift.NewMonitor(20, 10)))
+	if _, err := s.GetSourceStatus(ctx, &pb.GetSourceStatusRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an empty source label, got %v", err)
+	}
+	if _, err := s.GetSourceStatus(ctx, &pb.GetSourceStatusRequest{SourceLabel: "drift-trng"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound before any submission, got %v", err)
+	}
+
+	// Unlabeled submissions are not tracked.
+	req := &pb.Sp80022TestRequest{Bitstream: make([]byte, nist.MinBits/8)}
+	if _, err := s.RunTestSuite(ctx, req); err != nil {
+		t.Fatalf("RunTestSuite failed: %v", err)
+	}
+	req.SourceLabel = "drift-trng"
+	for i := 0; i < 10; i++ {
+		if _, err := s.RunTestSuite(ctx, req); err != nil {
+			t.Fatalf("RunTestSuite failed: %v", err)
+		}
+	}
+
+	resp, err := s.GetSourceStatus(ctx, &pb.GetSourceStatusRequest{SourceLabel: "drift-trng"})
+	if err != nil {
+		t.Fatalf("GetSourceStatus failed: %v", err)
+	}
+	if resp.State != pb.SourceState_SOURCE_STATE_FAIL || resp.Samples != 10 || resp.WindowSize != 20 || resp.LastUpdated == "" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	if len(resp.Tests) != 2 {
+		t.Fatalf("expected the two tests that ran, got %v", resp.Tests)
+	}
+	multi, single := resp.Tests[0], resp.Tests[1]
+	if multi.Name != "drift_multi" || multi.Samples != 20 || multi.State != pb.SourceState_SOURCE_STATE_OK {
+		t.Errorf("expected drift_multi with both sub-tests OK, got %v", multi)
+	}
+	if single.Name != "drift_single" || single.Proportion != 0 || single.State != pb.SourceState_SOURCE_STATE_FAIL {
+		t.Errorf("expected drift_single failing, got %v", single)
+	}
+
+	if got := testutil.ToFloat64(metrics.SourceState.WithLabelValues("drift-trng")); got != float64(drift.StateFail) {
+		t.Errorf("expected source state gauge %v, got %v", drift.StateFail, got)
+	}
+	if got := testutil.ToFloat64(metrics.SourceWindowSamples.WithLabelValues("drift-trng")); got != 10 {
+		t.Errorf("expected 10 window samples, got %v", got)
+	}
+}
+
+func TestDriftSourceNamespaces(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{{Name: "drift_ns", PValue: 0.5, Passed: true}}, nil
+	}
+	ctx := context.Background()
+	s := NewServer(WithDriftMonitor(drift.NewMonitor(20, 10)))
+
+	if _, err := s.GenerateAndTest(ctx, &pb.GenerateAndTestRequest{Generator: generators.LCG, Bits: nist.MinBits}); err != nil {
+		t.Fatalf("GenerateAndTest failed: %v", err)
+	}
+	req := &pb.Sp80022TestRequest{Bitstream: make([]byte, nist.MinBits/8), SourceLabel: generators.LCG}
+	for i := 0; i < 2; i++ {
+		if _, err := s.RunTestSuite(ctx, req); err != nil {
+			t.Fatalf("RunTestSuite failed: %v", err)
+		}
+	}
+
+	for label, want := range map[string]int32{"generator:" + generators.LCG: 1, generators.LCG: 2} {
+		resp, err := s.GetSourceStatus(ctx, &pb.GetSourceStatusRequest{SourceLabel: label})
+		if err != nil {
+			t.Fatalf("GetSourceStatus(%q) failed: %v", label, err)
+		}
+		if resp.Samples != want {
+			t.Errorf("%q: got %d samples, want %d", label, resp.Samples, want)
+		}
+	}
+
+	for _, label := range []string{"generator:" + generators.LCG, "source:pcg"} {
+		req.SourceLabel = label
+		if _, err := s.RunTestSuite(ctx, req); err == nil {
+			t.Errorf("expected error for reserved source label %q", label)
+		}
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	orig := runTests
+	defer func() { runTests = orig }()
+
+	runTests = func(_ context.Context, bitstream []byte, _ nist.RunOptions) ([]nist.TestResult, error) {
+		return []nist.TestResult{
+			{Name: "notify_passed", PValue: 0.5, Passed: true},
+			{Name: "notify_failed", PValue: 0.001, Passed: false},
+			{Name: "notify_skipped", PValue: -1},
+		}, nil
+	}
+
+	var buf bytes.Buffer
+	rules := notify.Rules{RunMinFailures: 1, SourceFail: true}
+	dispatcher := notify.NewDispatcher(rules, time.Second, notify.NewWriter(&buf))
+	s := NewServer(WithDriftMonitor(drift.NewMonitor(10, 10)), WithNotifications(dispatcher))
+
+	req := &pb.Sp80022TestRequest{Bitstream: make([]byte, nist.MinBits/8), SourceLabel: "notify-trng"}
+	for i := 0; i < 10; i++ {
+		if _, err := s.RunTestSuite(context.Background(), req); err != nil {
+			t.Fatalf("RunTestSuite failed: %v", err)
+		}
+	}
+	if err := dispatcher.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var runs, states int
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e notify.Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		switch e.Kind {
+		case notify.KindRunFailed:
+			runs++
+			if e.Source != "notify-trng" || e.Method != "RunTestSuite" || e.RequestID == "" || len(e.FailedTests) != 1 || e.FailedTests[0] != "notify_failed" {
+				t.Errorf("unexpected run event: %+v", e)
+			}
+		case notify.KindSourceState:
+			states++
+			if e.State != notify.StateFail || e.PreviousState != "UNKNOWN" || len(e.Tests) != 1 || e.Tests[0] != "notify_failed" {
+				t.Errorf("unexpected source event: %+v", e)
+			}
+		}
+	}
+	// Every run failed; the window is evaluated from the tenth run on and fails then.
+	if runs != 10 || states != 1 {
+		t.Errorf("expected 10 run and 1 source events, got %d and %d", runs, states)
+	}
+}
+
+func TestNotificationsGoodGenerator(t *testing.T) {
+	var buf bytes.Buffer
+	dispatcher := notify.NewDispatcher(notify.Rules{RunMinFailures: 1}, time.Second, notify.NewWriter(&buf))
+	s := NewServer(WithNotifications(dispatcher))
+
+	resp, err := s.GenerateAndTest(context.Background(), &pb.GenerateAndTestRequest{Generator: generators.GSHA1})
+	if err != nil {
+		t.Fatalf("GenerateAndTest failed: %v", err)
+	}
+	if err := dispatcher.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The smallest of the 148 template p-values of this sequence is below Alpha, as for
+	// most random sequences, but not more templates fail than expected by chance; the
+	// response reports the same verdict as the notifications
+	for _, r := range resp.Result.Results {
+		if r.Name == "non_overlapping_template" && (r.PValue >= nist.Alpha || !r.Passed) {
+			t.Fatalf("expected a passing test with a template p-value below Alpha, got %+v", r)
+		}
+		if r.PValue >= 0 && !r.Passed {
+			t.Errorf("%s: expected pass, got %+v", r.Name, r)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no notification for a good generator, got %s", buf.String())
+	}
+}
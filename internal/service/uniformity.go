@@ -0,0 +1,68 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/drift"
+)
+
+// DefaultUniformityWindow is the number of submissions per test and source whose
+// sub-test p-values are evaluated for the nist_p_value_uniformity gauge.
+const DefaultUniformityWindow = 100
+
+// uniformityWindow keeps the sub-test p-values of the last submissions of each test and
+// source and evaluates their uniformity, so that a drifting p-value distribution shows
+// up across many requests. The window of a test holds size submissions whatever its
+// number of sub-tests, so the 148 templates of one request do not replace the p-values
+// of the previous ones.
+type uniformityWindow struct {
+	size int
+
+	mu      sync.Mutex
+	samples map[uniformityKey]*pValueRing
+}
+
+type uniformityKey struct {
+	test, source string
+}
+
+// pValueRing holds up to len(values) p-values, overwriting the oldest ones when full.
+type pValueRing struct {
+	subTests int
+	values   []float64
+	next     int
+	full     bool
+}
+
+func newUniformityWindow(size int) *uniformityWindow {
+	return &uniformityWindow{size: size, samples: make(map[uniformityKey]*pValueRing)}
+}
+
+// observe adds the sub-test p-values of one submission of test from source and returns
+// the uniformity p-value of the window, or false while the window does not yet hold
+// size submissions. A change in the number of sub-tests, e.g. another template length,
+// starts a new window.
+func (w *uniformityWindow) observe(test, source string, pValues []float64) (float64, bool) {
+	if len(pValues) == 0 {
+		return 0, false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := uniformityKey{test, source}
+	ring, ok := w.samples[key]
+	if !ok || ring.subTests != len(pValues) {
+		ring = &pValueRing{subTests: len(pValues), values: make([]float64, w.size*len(pValues))}
+		w.samples[key] = ring
+	}
+	ring.next += copy(ring.values[ring.next:], pValues)
+	if ring.next == len(ring.values) {
+		ring.next = 0
+		ring.full = true
+	}
+	if !ring.full {
+		return 0, false
+	}
+	return drift.Uniformity(ring.values), true
+}
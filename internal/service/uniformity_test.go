@@ -0,0 +1,65 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUniformityWindow(t *testing.T) {
+	w := newUniformityWindow(10)
+
+	// One p-value per bin is perfectly uniform once the window is full.
+	for i := 0; i < 9; i++ {
+		if _, ok := w.observe("runs", "trng-1", []float64{float64(i)/10 + 0.05}); ok {
+			t.Fatalf("uniformity reported after %d of 10 p-values", i+1)
+		}
+	}
+	u, ok := w.observe("runs", "trng-1", []float64{0.95})
+	if !ok || math.Abs(u-1) > 1e-12 {
+		t.Fatalf("observe = %v, %v; want uniformity 1 for one p-value per bin", u, ok)
+	}
+
+	// Other tests and sources have their own windows.
+	if _, ok := w.observe("runs", "trng-2", []float64{0.5}); ok {
+		t.Error("window of another source must start empty")
+	}
+	if _, ok := w.observe("serial", "trng-1", []float64{0.5}); ok {
+		t.Error("window of another test must start empty")
+	}
+	if _, ok := w.observe("runs", "trng-1", nil); ok {
+		t.Error("a skipped result must not be evaluated")
+	}
+
+	// The oldest p-values are replaced: after ten p-values near 0 the window is far
+	// from uniform.
+	for i := 0; i < 10; i++ {
+		u, ok = w.observe("runs", "trng-1", []float64{0.001})
+	}
+	if !ok || u > 1e-6 {
+		t.Errorf("observe = %v, %v; want a uniformity near 0 for clustered p-values", u, ok)
+	}
+}
+
+func TestUniformityWindowSubTests(t *testing.T) {
+	w := newUniformityWindow(10)
+
+	// The window holds ten submissions of 148 sub-tests, not ten p-values.
+	templates := make([]float64, 148)
+	for i := range templates {
+		templates[i] = (float64(i) + 0.5) / 148
+	}
+	for i := 0; i < 9; i++ {
+		if _, ok := w.observe("non_overlapping_template", "trng-1", templates); ok {
+			t.Fatalf("uniformity reported after %d of 10 submissions", i+1)
+		}
+	}
+	u, ok := w.observe("non_overlapping_template", "trng-1", templates)
+	if !ok || u < 0.99 {
+		t.Fatalf("observe = %v, %v; want a uniformity near 1 for evenly spread p-values", u, ok)
+	}
+
+	// Another number of sub-tests, e.g. another template length, starts a new window.
+	if _, ok := w.observe("non_overlapping_template", "trng-1", templates[:18]); ok {
+		t.Error("window with another number of sub-tests must start empty")
+	}
+}
@@ -0,0 +1,142 @@
+// Package sources reads bits from random sources available on the host, such as the
+// operating system CSPRNG, the math/rand/v2 generators or a file or character device.
+package sources
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+)
+
+// Names of the available sources.
+const (
+	CryptoRand = "crypto_rand"
+	PCG        = "pcg"
+	ChaCha8    = "chacha8"
+	File       = "file"
+)
+
+// Names lists the available sources.
+var Names = []string{CryptoRand, PCG, ChaCha8, File}
+
+// chunkSize is the number of bytes read between context checks.
+const chunkSize = 64 * 1024
+
+// Spec selects a source and its parameters.
+type Spec struct {
+	// Name is one of Names.
+	Name string
+	// Seed is a big-endian unsigned integer seeding PCG (up to 16 bytes) or
+	// ChaCha8 (up to 32 bytes). It must be empty for the other sources.
+	Seed []byte
+	// Path is the file or character device read by File.
+	Path string
+}
+
+// Read returns nBits bits from the source described by spec. nBits must be a positive
+// multiple of 8. A file that ends before nBits bits have been read is an error.
+func Read(ctx context.Context, spec Spec, nBits int) ([]byte, error) {
+	if nBits <= 0 || nBits%8 != 0 {
+		return nil, fmt.Errorf("invalid length: %d bits (must be a positive multiple of 8)", nBits)
+	}
+
+	r, closer, err := open(spec)
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil {
+		defer closer.Close() //nolint:errcheck // read-only
+	}
+
+	out := make([]byte, nBits/8)
+	for off := 0; off < len(out); off += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(off+chunkSize, len(out))
+		if _, err := io.ReadFull(r, out[off:end]); err != nil {
+			return nil, fmt.Errorf("read %s: %w", spec.Name, err)
+		}
+	}
+	return out, nil
+}
+
+// open returns a reader for spec and, for files, the handle to close afterwards.
+func open(spec Spec) (io.Reader, io.Closer, error) {
+	if spec.Name != File && spec.Path != "" {
+		return nil, nil, fmt.Errorf("source %s does not take a path", spec.Name)
+	}
+
+	switch spec.Name {
+	case CryptoRand:
+		if len(spec.Seed) > 0 {
+			return nil, nil, fmt.Errorf("source %s does not take a seed", spec.Name)
+		}
+		return cryptorand.Reader, nil, nil
+
+	case PCG:
+		var seed [16]byte
+		if err := padSeed(seed[:], spec.Seed); err != nil {
+			return nil, nil, err
+		}
+		pcg := rand.NewPCG(binary.BigEndian.Uint64(seed[:8]), binary.BigEndian.Uint64(seed[8:]))
+		return &uint64Reader{src: pcg}, nil, nil
+
+	case ChaCha8:
+		var seed [32]byte
+		if err := padSeed(seed[:], spec.Seed); err != nil {
+			return nil, nil, err
+		}
+		return rand.NewChaCha8(seed), nil, nil
+
+	case File:
+		if len(spec.Seed) > 0 {
+			return nil, nil, fmt.Errorf("source %s does not take a seed", spec.Name)
+		}
+		if spec.Path == "" {
+			return nil, nil, fmt.Errorf("source %s requires a path", spec.Name)
+		}
+		f, err := os.Open(spec.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open source: %w", err)
+		}
+		return f, f, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown source: %q", spec.Name)
+	}
+}
+
+// padSeed copies seed right-aligned into dst, i.e. as a big-endian integer.
+func padSeed(dst, seed []byte) error {
+	if len(seed) > len(dst) {
+		return fmt.Errorf("seed too long: %d bytes (maximum %d)", len(seed), len(dst))
+	}
+	copy(dst[len(dst)-len(seed):], seed)
+	return nil
+}
+
+// uint64Reader adapts a rand.Source to io.Reader, emitting each value big-endian.
+type uint64Reader struct {
+	src rand.Source
+	buf [8]byte
+	n   int // unread bytes at the end of buf
+}
+
+func (r *uint64Reader) Read(p []byte) (int, error) {
+	read := 0
+	for read < len(p) {
+		if r.n == 0 {
+			binary.BigEndian.PutUint64(r.buf[:], r.src.Uint64())
+			r.n = len(r.buf)
+		}
+		c := copy(p[read:], r.buf[len(r.buf)-r.n:])
+		r.n -= c
+		read += c
+	}
+	return read, nil
+}
@@ -0,0 +1,116 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSeededSources(t *testing.T) {
+	for _, name := range []string{PCG, ChaCha8} {
+		t.Run(name, func(t *testing.T) {
+			a, err := Read(context.Background(), Spec{Name: name, Seed: []byte{0x2a}}, 1024)
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if len(a) != 128 {
+				t.Fatalf("expected 128 bytes, got %d", len(a))
+			}
+
+			b, _ := Read(context.Background(), Spec{Name: name, Seed: []byte{0x2a}}, 1024)
+			if !bytes.Equal(a, b) {
+				t.Fatal("same seed produced different output")
+			}
+
+			c, _ := Read(context.Background(), Spec{Name: name, Seed: []byte{0x2b}}, 1024)
+			if bytes.Equal(a, c) {
+				t.Fatal("different seeds produced identical output")
+			}
+		})
+	}
+}
+
+func TestReadPCGMatchesMathRand(t *testing.T) {
+	got, err := Read(context.Background(), Spec{Name: PCG, Seed: []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}}, 8*20)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	pcg := rand.NewPCG(1, 2)
+	want := make([]byte, 24)
+	for i := 0; i < 3; i++ {
+		binary.BigEndian.PutUint64(want[8*i:], pcg.Uint64())
+	}
+	if !bytes.Equal(got, want[:20]) {
+		t.Fatalf("got %x, want %x", got, want[:20])
+	}
+}
+
+func TestReadCryptoRand(t *testing.T) {
+	got, err := Read(context.Background(), Spec{Name: CryptoRand}, 8*chunkSize+64)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if bytes.Equal(got[:32], make([]byte, 32)) {
+		t.Fatal("crypto/rand returned zeros")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bits.bin")
+	data := bytes.Repeat([]byte{0xa5, 0x5a}, 100)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	got, err := Read(context.Background(), Spec{Name: File, Path: path}, 8*150)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(got, data[:150]) {
+		t.Fatal("unexpected file content")
+	}
+
+	if _, err := Read(context.Background(), Spec{Name: File, Path: path}, 8*201); err == nil {
+		t.Fatal("expected error for short file")
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  Spec
+		nBits int
+	}{
+		{"unknown source", Spec{Name: "rdrand"}, 64},
+		{"invalid length", Spec{Name: CryptoRand}, 12},
+		{"zero length", Spec{Name: CryptoRand}, 0},
+		{"seed for crypto_rand", Spec{Name: CryptoRand, Seed: []byte{1}}, 64},
+		{"path for pcg", Spec{Name: PCG, Path: "/dev/urandom"}, 64},
+		{"pcg seed too long", Spec{Name: PCG, Seed: make([]byte, 17)}, 64},
+		{"chacha8 seed too long", Spec{Name: ChaCha8, Seed: make([]byte, 33)}, 64},
+		{"file without path", Spec{Name: File}, 64},
+		{"file with seed", Spec{Name: File, Path: "/dev/null", Seed: []byte{1}}, 64},
+		{"missing file", Spec{Name: File, Path: "/nonexistent/bits.bin"}, 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(context.Background(), tt.spec, tt.nBits); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestReadCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Read(ctx, Spec{Name: CryptoRand}, 64); err == nil {
+		t.Fatal("expected error for cancelled context")
+	}
+}
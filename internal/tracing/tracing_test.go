@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/service"
+	pb "github.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb"
+)
+
+func TestSetup(t *testing.T) {
+	for _, exporter := range []string{"", ExporterNone, ExporterStdout} {
+		t.Run("exporter "+exporter, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), Config{Exporter: exporter, SampleRatio: 1})
+			if err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("shutdown failed: %v", err)
+			}
+		})
+	}
+
+	if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Error("expected error for unknown exporter")
+	}
+}
+
+func TestServerSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(exporter, 0)
+	origProvider, origPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(origProvider)
+		otel.SetTextMapPropagator(origPropagator)
+	}()
+
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(ServerOption())
+	pb.RegisterSp80022TestServiceServer(srv, service.NewServer())
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// A sampled W3C trace context from the client is continued even with a sample ratio of 0.
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-"+traceID.String()+"-"+spanID.String()+"-01")
+	req := &pb.Sp80022TestRequest{
+		Bitstream: make([]byte, nist.MinBits/8),
+		Config:    &pb.Sp80022TestConfig{Tests: []string{"frequency_monobit", "runs"}},
+	}
+	if _, err := pb.NewSp80022TestServiceClient(conn).RunTestSuite(ctx, req); err != nil {
+		t.Fatalf("RunTestSuite failed: %v", err)
+	}
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	var server *tracetest.SpanStub
+	tests := make(map[string]tracetest.SpanStub)
+	for i, s := range spans {
+		if s.SpanKind == trace.SpanKindServer {
+			server = &spans[i]
+		} else {
+			tests[s.Name] = s
+		}
+	}
+	if server == nil || server.Name != "nist.sp800_22.v1.Sp80022TestService/RunTestSuite" {
+		t.Fatalf("missing server span in %d spans", len(spans))
+	}
+	if server.SpanContext.TraceID() != traceID || server.Parent.SpanID() != spanID {
+		t.Errorf("server span does not continue the incoming trace context: %v", server.SpanContext)
+	}
+	for _, name := range []string{"nist.frequency_monobit", "nist.runs"} {
+		s, ok := tests[name]
+		if !ok {
+			t.Errorf("missing span %s", name)
+			continue
+		}
+		if s.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("span %s is not a child of the server span", name)
+		}
+	}
+}
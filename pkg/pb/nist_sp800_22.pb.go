@@ -21,13 +21,73 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// SourceState is the alert state of a source derived from its rolling window
+type SourceState int32
+
+const (
+	SourceState_SOURCE_STATE_UNSPECIFIED SourceState = 0
+	// Every proportion and uniformity is within the NIST acceptance range
+	SourceState_SOURCE_STATE_OK SourceState = 1
+	// A proportion or uniformity is close to the NIST limit
+	SourceState_SOURCE_STATE_WARN SourceState = 2
+	// A proportion or uniformity is outside the NIST acceptance range
+	SourceState_SOURCE_STATE_FAIL SourceState = 3
+)
+
+// Enum value maps for SourceState.
+var (
+	SourceState_name = map[int32]string{
+		0: "SOURCE_STATE_UNSPECIFIED",
+		1: "SOURCE_STATE_OK",
+		2: "SOURCE_STATE_WARN",
+		3: "SOURCE_STATE_FAIL",
+	}
+	SourceState_value = map[string]int32{
+		"SOURCE_STATE_UNSPECIFIED": 0,
+		"SOURCE_STATE_OK":          1,
+		"SOURCE_STATE_WARN":        2,
+		"SOURCE_STATE_FAIL":        3,
+	}
+)
+
+func (x SourceState) Enum() *SourceState {
+	p := new(SourceState)
+	*p = x
+	return p
+}
+
+func (x SourceState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SourceState) Descriptor() protoreflect.EnumDescriptor {
+	return file_nist_sp800_22_proto_enumTypes[0].Descriptor()
+}
+
+func (SourceState) Type() protoreflect.EnumType {
+	return &file_nist_sp800_22_proto_enumTypes[0]
+}
+
+func (x SourceState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SourceState.Descriptor instead.
+func (SourceState) EnumDescriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{0}
+}
+
 // Sp80022TestRequest contains the bitstream and optional configuration
 type Sp80022TestRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Raw bitstream as bytes (minimum 387,840 bits)
 	Bitstream []byte `protobuf:"bytes,1,opt,name=bitstream,proto3" json:"bitstream,omitempty"`
 	// Optional test configuration parameters
-	Config        *Sp80022TestConfig `protobuf:"bytes,2,opt,name=config,proto3,oneof" json:"config,omitempty"`
+	Config *Sp80022TestConfig `protobuf:"bytes,2,opt,name=config,proto3,oneof" json:"config,omitempty"`
+	// Optional label of the random source the bitstream was taken from, recorded in the
+	// audit log and tracked by drift detection. Labels starting with "generator:" or
+	// "source:" are reserved for the sources of the service
+	SourceLabel   string `protobuf:"bytes,3,opt,name=source_label,json=sourceLabel,proto3" json:"source_label,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -76,6 +136,13 @@ func (x *Sp80022TestRequest) GetConfig() *Sp80022TestConfig {
 	return nil
 }
 
+func (x *Sp80022TestRequest) GetSourceLabel() string {
+	if x != nil {
+		return x.SourceLabel
+	}
+	return ""
+}
+
 // Sp80022TestConfig allows customization of test parameters
 type Sp80022TestConfig struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -91,8 +158,36 @@ type Sp80022TestConfig struct {
 	SerialBlockLength int32 `protobuf:"varint,5,opt,name=serial_block_length,json=serialBlockLength,proto3" json:"serial_block_length,omitempty"`
 	// Linear Complexity Test - sequence length M (default: 500)
 	LinearComplexitySequenceLength int32 `protobuf:"varint,6,opt,name=linear_complexity_sequence_length,json=linearComplexitySequenceLength,proto3" json:"linear_complexity_sequence_length,omitempty"`
-	unknownFields                  protoimpl.UnknownFields
-	sizeCache                      protoimpl.SizeCache
+	// Tests to run (e.g., "runs", "serial"); all registered tests if empty
+	Tests []string `protobuf:"bytes,7,rep,name=tests,proto3" json:"tests,omitempty"`
+	// Linear Complexity Test - report the class counts nu_0..nu_6 in the result histogram
+	LinearComplexityReportHistogram bool `protobuf:"varint,8,opt,name=linear_complexity_report_histogram,json=linearComplexityReportHistogram,proto3" json:"linear_complexity_report_histogram,omitempty"`
+	// Binary Matrix Rank Test - matrix rows M, 2-64 (default: 32)
+	BinaryMatrixRankRows int32 `protobuf:"varint,9,opt,name=binary_matrix_rank_rows,json=binaryMatrixRankRows,proto3" json:"binary_matrix_rank_rows,omitempty"`
+	// Binary Matrix Rank Test - matrix columns Q, 2-64 (default: 32)
+	BinaryMatrixRankColumns int32 `protobuf:"varint,10,opt,name=binary_matrix_rank_columns,json=binaryMatrixRankColumns,proto3" json:"binary_matrix_rank_columns,omitempty"`
+	// Universal Statistical Test - block length L, 6-9 (default: chosen from the
+	// sequence length). Inputs too short for L are reported as skipped. L = 10 needs
+	// 10,342,400 bits, more than the maximum request size, so L = 10-16 is only
+	// available through the Go package
+	UniversalBlockLength int32 `protobuf:"varint,11,opt,name=universal_block_length,json=universalBlockLength,proto3" json:"universal_block_length,omitempty"`
+	// Universal Statistical Test - initialization blocks Q, at least 10 * 2^L; requires
+	// universal_block_length (default: 10 * 2^L)
+	UniversalInitBlocks int32 `protobuf:"varint,12,opt,name=universal_init_blocks,json=universalInitBlocks,proto3" json:"universal_init_blocks,omitempty"`
+	// Overlapping Template Test - template bits, most significant first (e.g. "011011");
+	// its length sets m and must agree with overlapping_template_block_length if both
+	// are given (default: all ones of length m)
+	OverlappingTemplatePattern string `protobuf:"bytes,13,opt,name=overlapping_template_pattern,json=overlappingTemplatePattern,proto3" json:"overlapping_template_pattern,omitempty"`
+	// Overlapping Template Test - block length M (default: 1032)
+	OverlappingTemplateSubstringLength int32 `protobuf:"varint,14,opt,name=overlapping_template_substring_length,json=overlappingTemplateSubstringLength,proto3" json:"overlapping_template_substring_length,omitempty"`
+	// Overlapping Template Test - degrees of freedom K, 1-32 (default: 5)
+	OverlappingTemplateDegreesOfFreedom int32 `protobuf:"varint,15,opt,name=overlapping_template_degrees_of_freedom,json=overlappingTemplateDegreesOfFreedom,proto3" json:"overlapping_template_degrees_of_freedom,omitempty"`
+	// Additionally evaluate the p-values with arbitrary-precision tail functions and
+	// report them with their discrepancy (validation use; considerably slower). Rejected
+	// with PERMISSION_DENIED unless the server enables it (PRECISE_PVALUES_ENABLED)
+	PrecisePValues bool `protobuf:"varint,16,opt,name=precise_p_values,json=precisePValues,proto3" json:"precise_p_values,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *Sp80022TestConfig) Reset() {
@@ -167,6 +262,76 @@ func (x *Sp80022TestConfig) GetLinearComplexitySequenceLength() int32 {
 	return 0
 }
 
+func (x *Sp80022TestConfig) GetTests() []string {
+	if x != nil {
+		return x.Tests
+	}
+	return nil
+}
+
+func (x *Sp80022TestConfig) GetLinearComplexityReportHistogram() bool {
+	if x != nil {
+		return x.LinearComplexityReportHistogram
+	}
+	return false
+}
+
+func (x *Sp80022TestConfig) GetBinaryMatrixRankRows() int32 {
+	if x != nil {
+		return x.BinaryMatrixRankRows
+	}
+	return 0
+}
+
+func (x *Sp80022TestConfig) GetBinaryMatrixRankColumns() int32 {
+	if x != nil {
+		return x.BinaryMatrixRankColumns
+	}
+	return 0
+}
+
+func (x *Sp80022TestConfig) GetUniversalBlockLength() int32 {
+	if x != nil {
+		return x.UniversalBlockLength
+	}
+	return 0
+}
+
+func (x *Sp80022TestConfig) GetUniversalInitBlocks() int32 {
+	if x != nil {
+		return x.UniversalInitBlocks
+	}
+	return 0
+}
+
+func (x *Sp80022TestConfig) GetOverlappingTemplatePattern() string {
+	if x != nil {
+		return x.OverlappingTemplatePattern
+	}
+	return ""
+}
+
+func (x *Sp80022TestConfig) GetOverlappingTemplateSubstringLength() int32 {
+	if x != nil {
+		return x.OverlappingTemplateSubstringLength
+	}
+	return 0
+}
+
+func (x *Sp80022TestConfig) GetOverlappingTemplateDegreesOfFreedom() int32 {
+	if x != nil {
+		return x.OverlappingTemplateDegreesOfFreedom
+	}
+	return 0
+}
+
+func (x *Sp80022TestConfig) GetPrecisePValues() bool {
+	if x != nil {
+		return x.PrecisePValues
+	}
+	return false
+}
+
 // Sp80022TestResponse contains results from all 15 NIST SP 800-22 tests
 type Sp80022TestResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -174,9 +339,11 @@ type Sp80022TestResponse struct {
 	Timestamp string `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	// Number of bits in the input sample
 	SampleSizeBits int32 `protobuf:"varint,2,opt,name=sample_size_bits,json=sampleSizeBits,proto3" json:"sample_size_bits,omitempty"`
-	// Overall pass rate (0.0 - 1.0) - ONLY for implemented tests
+	// Overall pass rate (0.0 - 1.0) - ONLY for implemented tests, the fraction of
+	// results whose passed field is set
 	OverallPassRate float64 `protobuf:"fixed64,3,opt,name=overall_pass_rate,json=overallPassRate,proto3" json:"overall_pass_rate,omitempty"`
-	// P-value uniformity chi-squared test result
+	// P-value uniformity chi-squared test result over the sub-test p-values of the
+	// tests that ran (one per template, excursion state, ... for tests that report several)
 	PValueUniformityChi2 float64 `protobuf:"fixed64,4,opt,name=p_value_uniformity_chi2,json=pValueUniformityChi2,proto3" json:"p_value_uniformity_chi2,omitempty"`
 	// Individual test results (15 tests)
 	Results []*Sp80022TestResult `protobuf:"bytes,5,rep,name=results,proto3" json:"results,omitempty"`
@@ -186,10 +353,12 @@ type Sp80022TestResponse struct {
 	TestsRun int32 `protobuf:"varint,7,opt,name=tests_run,json=testsRun,proto3" json:"tests_run,omitempty"`
 	// Number of tests not yet implemented
 	TestsSkipped int32 `protobuf:"varint,8,opt,name=tests_skipped,json=testsSkipped,proto3" json:"tests_skipped,omitempty"`
-	// Always 15 for NIST SP 800-22
+	// Number of tests requested (15 for the full NIST SP 800-22 battery)
 	TestsTotal int32 `protobuf:"varint,9,opt,name=tests_total,json=testsTotal,proto3" json:"tests_total,omitempty"`
-	// true only if tests_run == tests_total (full NIST SP 800-22 compliance)
+	// true only if the full battery was requested and tests_run == tests_total
 	NistCompliant bool `protobuf:"varint,10,opt,name=nist_compliant,json=nistCompliant,proto3" json:"nist_compliant,omitempty"`
+	// Request ID of the call, also sent in the x-request-id header and trailer
+	RequestId     string `protobuf:"bytes,11,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -294,6 +463,13 @@ func (x *Sp80022TestResponse) GetNistCompliant() bool {
 	return false
 }
 
+func (x *Sp80022TestResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
 // Sp80022TestResult represents the outcome of a single NIST SP 800-22 statistical test
 type Sp80022TestResult struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -301,14 +477,29 @@ type Sp80022TestResult struct {
 	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	// P-value from the test (0.0 - 1.0)
 	PValue float64 `protobuf:"fixed64,2,opt,name=p_value,json=pValue,proto3" json:"p_value,omitempty"`
-	// Whether the test passed (p_value > 0.01)
+	// Whether the test passed. Deviating from SP 800-22, which judges each p-value on
+	// its own, a test with n sub-tests passes if the fraction of its sub-test p-values
+	// >= 0.01 is at least 0.99 - 3*sqrt(0.99*0.01/n), the proportion range of section
+	// 4.2.1. Up to 9 sub-tests this equals p_value >= 0.01. The audit log, notifications
+	// and drift detection use the same verdict
 	Passed bool `protobuf:"varint,3,opt,name=passed,proto3" json:"passed,omitempty"`
-	// Proportion metric (for multi-run tests, optional)
+	// Fraction of the sub-test p-values >= 0.01 (for tests that report p-values)
 	Proportion *float64 `protobuf:"fixed64,4,opt,name=proportion,proto3,oneof" json:"proportion,omitempty"`
 	// Warning message if test couldn't complete normally
-	Warning       *string `protobuf:"bytes,5,opt,name=warning,proto3,oneof" json:"warning,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Warning *string `protobuf:"bytes,5,opt,name=warning,proto3,oneof" json:"warning,omitempty"`
+	// Observed class counts, when requested (e.g., Linear Complexity nu_0..nu_6)
+	Histogram []int64 `protobuf:"varint,6,rep,packed,name=histogram,proto3" json:"histogram,omitempty"`
+	// P-value from arbitrary-precision tail functions, when precise_p_values is set; the
+	// smallest of p_values_precise, like p_value
+	PValuePrecise *float64 `protobuf:"fixed64,7,opt,name=p_value_precise,json=pValuePrecise,proto3,oneof" json:"p_value_precise,omitempty"`
+	// Largest absolute difference between a float64 p-value and its arbitrary-precision
+	// counterpart, over p_value and every sub-test
+	PValueDiscrepancy *float64 `protobuf:"fixed64,8,opt,name=p_value_discrepancy,json=pValueDiscrepancy,proto3,oneof" json:"p_value_discrepancy,omitempty"`
+	// Arbitrary-precision p-value of every sub-test (one per template, excursion state,
+	// ... in the order of the audit log), when precise_p_values is set
+	PValuesPrecise []float64 `protobuf:"fixed64,9,rep,packed,name=p_values_precise,json=pValuesPrecise,proto3" json:"p_values_precise,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *Sp80022TestResult) Reset() {
@@ -376,48 +567,864 @@ func (x *Sp80022TestResult) GetWarning() string {
 	return ""
 }
 
-var File_nist_sp800_22_proto protoreflect.FileDescriptor
+func (x *Sp80022TestResult) GetHistogram() []int64 {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
 
-const file_nist_sp800_22_proto_rawDesc = "" +
-	"\n" +
-	"\x13nist_sp800_22.proto\x12\x10nist.sp800_22.v1\"\x7f\n" +
-	"\x12Sp80022TestRequest\x12\x1c\n" +
-	"\tbitstream\x18\x01 \x01(\fR\tbitstream\x12@\n" +
-	"\x06config\x18\x02 \x01(\v2#.nist.sp800_22.v1.Sp80022TestConfigH\x00R\x06config\x88\x01\x01B\t\n" +
-	"\a_config\"\xb5\x03\n" +
-	"\x11Sp80022TestConfig\x12?\n" +
-	"\x1cblock_frequency_block_length\x18\x01 \x01(\x05R\x19blockFrequencyBlockLength\x12P\n" +
-	"%non_overlapping_template_block_length\x18\x02 \x01(\x05R!nonOverlappingTemplateBlockLength\x12I\n" +
-	"!overlapping_template_block_length\x18\x03 \x01(\x05R\x1eoverlappingTemplateBlockLength\x12G\n" +
-	" approximate_entropy_block_length\x18\x04 \x01(\x05R\x1dapproximateEntropyBlockLength\x12.\n" +
-	"\x13serial_block_length\x18\x05 \x01(\x05R\x11serialBlockLength\x12I\n" +
-	"!linear_complexity_sequence_length\x18\x06 \x01(\x05R\x1elinearComplexitySequenceLength\"\xb5\x03\n" +
-	"\x13Sp80022TestResponse\x12\x1c\n" +
-	"\ttimestamp\x18\x01 \x01(\tR\ttimestamp\x12(\n" +
-	"\x10sample_size_bits\x18\x02 \x01(\x05R\x0esampleSizeBits\x12*\n" +
-	"\x11overall_pass_rate\x18\x03 \x01(\x01R\x0foverallPassRate\x125\n" +
-	"\x17p_value_uniformity_chi2\x18\x04 \x01(\x01R\x14pValueUniformityChi2\x12=\n" +
-	"\aresults\x18\x05 \x03(\v2#.nist.sp800_22.v1.Sp80022TestResultR\aresults\x12*\n" +
-	"\x11execution_time_ms\x18\x06 \x01(\x03R\x0fexecutionTimeMs\x12\x1b\n" +
-	"\ttests_run\x18\a \x01(\x05R\btestsRun\x12#\n" +
-	"\rtests_skipped\x18\b \x01(\x05R\ftestsSkipped\x12\x1f\n" +
-	"\vtests_total\x18\t \x01(\x05R\n" +
-	"testsTotal\x12%\n" +
-	"\x0enist_compliant\x18\n" +
-	" \x01(\bR\rnistCompliant\"\xb7\x01\n" +
-	"\x11Sp80022TestResult\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x17\n" +
-	"\ap_value\x18\x02 \x01(\x01R\x06pValue\x12\x16\n" +
-	"\x06passed\x18\x03 \x01(\bR\x06passed\x12#\n" +
-	"\n" +
-	"proportion\x18\x04 \x01(\x01H\x00R\n" +
-	"proportion\x88\x01\x01\x12\x1d\n" +
-	"\awarning\x18\x05 \x01(\tH\x01R\awarning\x88\x01\x01B\r\n" +
-	"\v_proportionB\n" +
-	"\n" +
-	"\b_warning2q\n" +
-	"\x12Sp80022TestService\x12[\n" +
-	"\fRunTestSuite\x12$.nist.sp800_22.v1.Sp80022TestRequest\x1a%.nist.sp800_22.v1.Sp80022TestResponseBEZCgithub.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb;nistsp80022v1b\x06proto3"
+func (x *Sp80022TestResult) GetPValuePrecise() float64 {
+	if x != nil && x.PValuePrecise != nil {
+		return *x.PValuePrecise
+	}
+	return 0
+}
+
+func (x *Sp80022TestResult) GetPValueDiscrepancy() float64 {
+	if x != nil && x.PValueDiscrepancy != nil {
+		return *x.PValueDiscrepancy
+	}
+	return 0
+}
+
+func (x *Sp80022TestResult) GetPValuesPrecise() []float64 {
+	if x != nil {
+		return x.PValuesPrecise
+	}
+	return nil
+}
+
+// SelfTestRequest selects the NIST sample data sets to check
+type SelfTestRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Data sets to check (pi, e, sqrt2, sqrt3); all of them if empty
+	Datasets      []string `protobuf:"bytes,1,rep,name=datasets,proto3" json:"datasets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SelfTestRequest) Reset() {
+	*x = SelfTestRequest{}
+	mi := &file_nist_sp800_22_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelfTestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfTestRequest) ProtoMessage() {}
+
+func (x *SelfTestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfTestRequest.ProtoReflect.Descriptor instead.
+func (*SelfTestRequest) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SelfTestRequest) GetDatasets() []string {
+	if x != nil {
+		return x.Datasets
+	}
+	return nil
+}
+
+// SelfTestResponse contains the outcome of the known-answer self-test
+type SelfTestResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ISO 8601 timestamp when the self-test was executed
+	Timestamp string `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// true only if every check matched its reference value within tolerance
+	Passed bool `protobuf:"varint,2,opt,name=passed,proto3" json:"passed,omitempty"`
+	// Maximum accepted absolute difference between computed and reference p-values
+	Tolerance float64 `protobuf:"fixed64,3,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+	// Individual checks (one per data set and sub-test)
+	Checks []*SelfTestCheck `protobuf:"bytes,4,rep,name=checks,proto3" json:"checks,omitempty"`
+	// Total execution time in milliseconds
+	ExecutionTimeMs int64 `protobuf:"varint,5,opt,name=execution_time_ms,json=executionTimeMs,proto3" json:"execution_time_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SelfTestResponse) Reset() {
+	*x = SelfTestResponse{}
+	mi := &file_nist_sp800_22_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelfTestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfTestResponse) ProtoMessage() {}
+
+func (x *SelfTestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfTestResponse.ProtoReflect.Descriptor instead.
+func (*SelfTestResponse) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SelfTestResponse) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *SelfTestResponse) GetPassed() bool {
+	if x != nil {
+		return x.Passed
+	}
+	return false
+}
+
+func (x *SelfTestResponse) GetTolerance() float64 {
+	if x != nil {
+		return x.Tolerance
+	}
+	return 0
+}
+
+func (x *SelfTestResponse) GetChecks() []*SelfTestCheck {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+func (x *SelfTestResponse) GetExecutionTimeMs() int64 {
+	if x != nil {
+		return x.ExecutionTimeMs
+	}
+	return 0
+}
+
+// SelfTestCheck compares one computed p-value with its Appendix B reference value
+type SelfTestCheck struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Data set name (e.g., "pi")
+	Dataset string `protobuf:"bytes,1,opt,name=dataset,proto3" json:"dataset,omitempty"`
+	// Test or sub-test name (e.g., "cumulative_sums_forward")
+	Test string `protobuf:"bytes,2,opt,name=test,proto3" json:"test,omitempty"`
+	// Reference p-value from SP 800-22 Rev 1a Appendix B
+	ExpectedPValue float64 `protobuf:"fixed64,3,opt,name=expected_p_value,json=expectedPValue,proto3" json:"expected_p_value,omitempty"`
+	// P-value computed by this implementation
+	PValue float64 `protobuf:"fixed64,4,opt,name=p_value,json=pValue,proto3" json:"p_value,omitempty"`
+	// Absolute difference between p_value and expected_p_value
+	AbsDiff float64 `protobuf:"fixed64,5,opt,name=abs_diff,json=absDiff,proto3" json:"abs_diff,omitempty"`
+	// Whether abs_diff is within tolerance
+	Passed        bool `protobuf:"varint,6,opt,name=passed,proto3" json:"passed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SelfTestCheck) Reset() {
+	*x = SelfTestCheck{}
+	mi := &file_nist_sp800_22_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelfTestCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfTestCheck) ProtoMessage() {}
+
+func (x *SelfTestCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfTestCheck.ProtoReflect.Descriptor instead.
+func (*SelfTestCheck) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SelfTestCheck) GetDataset() string {
+	if x != nil {
+		return x.Dataset
+	}
+	return ""
+}
+
+func (x *SelfTestCheck) GetTest() string {
+	if x != nil {
+		return x.Test
+	}
+	return ""
+}
+
+func (x *SelfTestCheck) GetExpectedPValue() float64 {
+	if x != nil {
+		return x.ExpectedPValue
+	}
+	return 0
+}
+
+func (x *SelfTestCheck) GetPValue() float64 {
+	if x != nil {
+		return x.PValue
+	}
+	return 0
+}
+
+func (x *SelfTestCheck) GetAbsDiff() float64 {
+	if x != nil {
+		return x.AbsDiff
+	}
+	return 0
+}
+
+func (x *SelfTestCheck) GetPassed() bool {
+	if x != nil {
+		return x.Passed
+	}
+	return false
+}
+
+// GenerateAndTestRequest selects a reference generator and its output length
+type GenerateAndTestRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Generator name (lcg, qcg1, qcg2, ccg, xor, modexp, bbs, micali_schnorr, g_sha1)
+	Generator string `protobuf:"bytes,1,opt,name=generator,proto3" json:"generator,omitempty"`
+	// Seed as a big-endian unsigned integer; the generator's default seed if empty
+	Seed []byte `protobuf:"bytes,2,opt,name=seed,proto3" json:"seed,omitempty"`
+	// Number of bits to generate, a multiple of 8 (default: 1,000,000)
+	Bits          int32 `protobuf:"varint,3,opt,name=bits,proto3" json:"bits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateAndTestRequest) Reset() {
+	*x = GenerateAndTestRequest{}
+	mi := &file_nist_sp800_22_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateAndTestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateAndTestRequest) ProtoMessage() {}
+
+func (x *GenerateAndTestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateAndTestRequest.ProtoReflect.Descriptor instead.
+func (*GenerateAndTestRequest) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GenerateAndTestRequest) GetGenerator() string {
+	if x != nil {
+		return x.Generator
+	}
+	return ""
+}
+
+func (x *GenerateAndTestRequest) GetSeed() []byte {
+	if x != nil {
+		return x.Seed
+	}
+	return nil
+}
+
+func (x *GenerateAndTestRequest) GetBits() int32 {
+	if x != nil {
+		return x.Bits
+	}
+	return 0
+}
+
+// GenerateAndTestResponse contains the test suite results for the generated sequence
+type GenerateAndTestResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Generator name
+	Generator string `protobuf:"bytes,1,opt,name=generator,proto3" json:"generator,omitempty"`
+	// Seed that was used
+	Seed []byte `protobuf:"bytes,2,opt,name=seed,proto3" json:"seed,omitempty"`
+	// Test suite results for the generated sequence
+	Result        *Sp80022TestResponse `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateAndTestResponse) Reset() {
+	*x = GenerateAndTestResponse{}
+	mi := &file_nist_sp800_22_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateAndTestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateAndTestResponse) ProtoMessage() {}
+
+func (x *GenerateAndTestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateAndTestResponse.ProtoReflect.Descriptor instead.
+func (*GenerateAndTestResponse) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GenerateAndTestResponse) GetGenerator() string {
+	if x != nil {
+		return x.Generator
+	}
+	return ""
+}
+
+func (x *GenerateAndTestResponse) GetSeed() []byte {
+	if x != nil {
+		return x.Seed
+	}
+	return nil
+}
+
+func (x *GenerateAndTestResponse) GetResult() *Sp80022TestResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+// TestSourceRequest selects a random source on the server host
+type TestSourceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Source name (crypto_rand, pcg, chacha8, file)
+	Source string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// Seed for pcg (up to 16 bytes) or chacha8 (up to 32 bytes) as a big-endian unsigned integer
+	Seed []byte `protobuf:"bytes,2,opt,name=seed,proto3" json:"seed,omitempty"`
+	// File or character device read by the file source; must be allowed by the server
+	Path string `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	// Number of bits to read, a multiple of 8 (default: 1,000,000)
+	Bits          int32 `protobuf:"varint,4,opt,name=bits,proto3" json:"bits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestSourceRequest) Reset() {
+	*x = TestSourceRequest{}
+	mi := &file_nist_sp800_22_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestSourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestSourceRequest) ProtoMessage() {}
+
+func (x *TestSourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestSourceRequest.ProtoReflect.Descriptor instead.
+func (*TestSourceRequest) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TestSourceRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *TestSourceRequest) GetSeed() []byte {
+	if x != nil {
+		return x.Seed
+	}
+	return nil
+}
+
+func (x *TestSourceRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *TestSourceRequest) GetBits() int32 {
+	if x != nil {
+		return x.Bits
+	}
+	return 0
+}
+
+// TestSourceResponse contains the test suite results for the bits read from the source
+type TestSourceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Source name
+	Source string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// Test suite results for the bits read from the source
+	Result        *Sp80022TestResponse `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestSourceResponse) Reset() {
+	*x = TestSourceResponse{}
+	mi := &file_nist_sp800_22_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestSourceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestSourceResponse) ProtoMessage() {}
+
+func (x *TestSourceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestSourceResponse.ProtoReflect.Descriptor instead.
+func (*TestSourceResponse) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TestSourceResponse) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *TestSourceResponse) GetResult() *Sp80022TestResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+// GetSourceStatusRequest names the source to report
+type GetSourceStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Source label: source_label of RunTestSuite, "generator:<name>" for the generator of
+	// GenerateAndTest or "source:<name>" for the source of TestSource
+	SourceLabel   string `protobuf:"bytes,1,opt,name=source_label,json=sourceLabel,proto3" json:"source_label,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSourceStatusRequest) Reset() {
+	*x = GetSourceStatusRequest{}
+	mi := &file_nist_sp800_22_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSourceStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSourceStatusRequest) ProtoMessage() {}
+
+func (x *GetSourceStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSourceStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetSourceStatusRequest) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetSourceStatusRequest) GetSourceLabel() string {
+	if x != nil {
+		return x.SourceLabel
+	}
+	return ""
+}
+
+// GetSourceStatusResponse contains the rolling-window evaluation of a source
+type GetSourceStatusResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Source label
+	SourceLabel string `protobuf:"bytes,1,opt,name=source_label,json=sourceLabel,proto3" json:"source_label,omitempty"`
+	// Worst state of all statistics
+	State SourceState `protobuf:"varint,2,opt,name=state,proto3,enum=nist.sp800_22.v1.SourceState" json:"state,omitempty"`
+	// Number of submissions in the window
+	Samples int32 `protobuf:"varint,3,opt,name=samples,proto3" json:"samples,omitempty"`
+	// Maximum number of submissions kept in the window
+	WindowSize int32 `protobuf:"varint,4,opt,name=window_size,json=windowSize,proto3" json:"window_size,omitempty"`
+	// ISO 8601 timestamp of the latest submission
+	LastUpdated string `protobuf:"bytes,5,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	// Evaluation per test, ordered by name
+	Tests         []*SourceTestStatus `protobuf:"bytes,6,rep,name=tests,proto3" json:"tests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSourceStatusResponse) Reset() {
+	*x = GetSourceStatusResponse{}
+	mi := &file_nist_sp800_22_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSourceStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSourceStatusResponse) ProtoMessage() {}
+
+func (x *GetSourceStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSourceStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetSourceStatusResponse) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetSourceStatusResponse) GetSourceLabel() string {
+	if x != nil {
+		return x.SourceLabel
+	}
+	return ""
+}
+
+func (x *GetSourceStatusResponse) GetState() SourceState {
+	if x != nil {
+		return x.State
+	}
+	return SourceState_SOURCE_STATE_UNSPECIFIED
+}
+
+func (x *GetSourceStatusResponse) GetSamples() int32 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *GetSourceStatusResponse) GetWindowSize() int32 {
+	if x != nil {
+		return x.WindowSize
+	}
+	return 0
+}
+
+func (x *GetSourceStatusResponse) GetLastUpdated() string {
+	if x != nil {
+		return x.LastUpdated
+	}
+	return ""
+}
+
+func (x *GetSourceStatusResponse) GetTests() []*SourceTestStatus {
+	if x != nil {
+		return x.Tests
+	}
+	return nil
+}
+
+// SourceTestStatus is the rolling-window evaluation of one test. The p-values of the
+// sub-tests of a test (e.g. the templates of non_overlapping_template) are pooled for
+// the proportion and evaluated separately for the uniformity
+type SourceTestStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Test name
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Number of p-values of all sub-tests in the window (tests skipped for a sequence
+	// contribute none)
+	Samples int32 `protobuf:"varint,2,opt,name=samples,proto3" json:"samples,omitempty"`
+	// Proportion of passing p-values (p >= 0.01)
+	Proportion float64 `protobuf:"fixed64,3,opt,name=proportion,proto3" json:"proportion,omitempty"`
+	// Lower limit of the NIST acceptable proportion range for this number of samples
+	ProportionMin float64 `protobuf:"fixed64,4,opt,name=proportion_min,json=proportionMin,proto3" json:"proportion_min,omitempty"`
+	// Smallest chi-squared uniformity p-value of the sub-tests, multiplied by their
+	// number (Bonferroni), or -1 until a sub-test has 55 samples
+	UniformityPValue float64 `protobuf:"fixed64,5,opt,name=uniformity_p_value,json=uniformityPValue,proto3" json:"uniformity_p_value,omitempty"`
+	// State of this statistic
+	State         SourceState `protobuf:"varint,6,opt,name=state,proto3,enum=nist.sp800_22.v1.SourceState" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourceTestStatus) Reset() {
+	*x = SourceTestStatus{}
+	mi := &file_nist_sp800_22_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourceTestStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceTestStatus) ProtoMessage() {}
+
+func (x *SourceTestStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_nist_sp800_22_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceTestStatus.ProtoReflect.Descriptor instead.
+func (*SourceTestStatus) Descriptor() ([]byte, []int) {
+	return file_nist_sp800_22_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SourceTestStatus) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SourceTestStatus) GetSamples() int32 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *SourceTestStatus) GetProportion() float64 {
+	if x != nil {
+		return x.Proportion
+	}
+	return 0
+}
+
+func (x *SourceTestStatus) GetProportionMin() float64 {
+	if x != nil {
+		return x.ProportionMin
+	}
+	return 0
+}
+
+func (x *SourceTestStatus) GetUniformityPValue() float64 {
+	if x != nil {
+		return x.UniformityPValue
+	}
+	return 0
+}
+
+func (x *SourceTestStatus) GetState() SourceState {
+	if x != nil {
+		return x.State
+	}
+	return SourceState_SOURCE_STATE_UNSPECIFIED
+}
+
+var File_nist_sp800_22_proto protoreflect.FileDescriptor
+
+const file_nist_sp800_22_proto_rawDesc = "" +
+	"\n" +
+	"\x13nist_sp800_22.proto\x12\x10nist.sp800_22.v1\"\xa2\x01\n" +
+	"\x12Sp80022TestRequest\x12\x1c\n" +
+	"\tbitstream\x18\x01 \x01(\fR\tbitstream\x12@\n" +
+	"\x06config\x18\x02 \x01(\v2#.nist.sp800_22.v1.Sp80022TestConfigH\x00R\x06config\x88\x01\x01\x12!\n" +
+	"\fsource_label\x18\x03 \x01(\tR\vsourceLabelB\t\n" +
+	"\a_config\"\x8b\b\n" +
+	"\x11Sp80022TestConfig\x12?\n" +
+	"\x1cblock_frequency_block_length\x18\x01 \x01(\x05R\x19blockFrequencyBlockLength\x12P\n" +
+	"%non_overlapping_template_block_length\x18\x02 \x01(\x05R!nonOverlappingTemplateBlockLength\x12I\n" +
+	"!overlapping_template_block_length\x18\x03 \x01(\x05R\x1eoverlappingTemplateBlockLength\x12G\n" +
+	" approximate_entropy_block_length\x18\x04 \x01(\x05R\x1dapproximateEntropyBlockLength\x12.\n" +
+	"\x13serial_block_length\x18\x05 \x01(\x05R\x11serialBlockLength\x12I\n" +
+	"!linear_complexity_sequence_length\x18\x06 \x01(\x05R\x1elinearComplexitySequenceLength\x12\x14\n" +
+	"\x05tests\x18\a \x03(\tR\x05tests\x12K\n" +
+	"\"linear_complexity_report_histogram\x18\b \x01(\bR\x1flinearComplexityReportHistogram\x125\n" +
+	"\x17binary_matrix_rank_rows\x18\t \x01(\x05R\x14binaryMatrixRankRows\x12;\n" +
+	"\x1abinary_matrix_rank_columns\x18\n" +
+	" \x01(\x05R\x17binaryMatrixRankColumns\x124\n" +
+	"\x16universal_block_length\x18\v \x01(\x05R\x14universalBlockLength\x122\n" +
+	"\x15universal_init_blocks\x18\f \x01(\x05R\x13universalInitBlocks\x12@\n" +
+	"\x1coverlapping_template_pattern\x18\r \x01(\tR\x1aoverlappingTemplatePattern\x12Q\n" +
+	"%overlapping_template_substring_length\x18\x0e \x01(\x05R\"overlappingTemplateSubstringLength\x12T\n" +
+	"'overlapping_template_degrees_of_freedom\x18\x0f \x01(\x05R#overlappingTemplateDegreesOfFreedom\x12(\n" +
+	"\x10precise_p_values\x18\x10 \x01(\bR\x0eprecisePValues\"\xd4\x03\n" +
+	"\x13Sp80022TestResponse\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\tR\ttimestamp\x12(\n" +
+	"\x10sample_size_bits\x18\x02 \x01(\x05R\x0esampleSizeBits\x12*\n" +
+	"\x11overall_pass_rate\x18\x03 \x01(\x01R\x0foverallPassRate\x125\n" +
+	"\x17p_value_uniformity_chi2\x18\x04 \x01(\x01R\x14pValueUniformityChi2\x12=\n" +
+	"\aresults\x18\x05 \x03(\v2#.nist.sp800_22.v1.Sp80022TestResultR\aresults\x12*\n" +
+	"\x11execution_time_ms\x18\x06 \x01(\x03R\x0fexecutionTimeMs\x12\x1b\n" +
+	"\ttests_run\x18\a \x01(\x05R\btestsRun\x12#\n" +
+	"\rtests_skipped\x18\b \x01(\x05R\ftestsSkipped\x12\x1f\n" +
+	"\vtests_total\x18\t \x01(\x05R\n" +
+	"testsTotal\x12%\n" +
+	"\x0enist_compliant\x18\n" +
+	" \x01(\bR\rnistCompliant\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\v \x01(\tR\trequestId\"\x8d\x03\n" +
+	"\x11Sp80022TestResult\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x17\n" +
+	"\ap_value\x18\x02 \x01(\x01R\x06pValue\x12\x16\n" +
+	"\x06passed\x18\x03 \x01(\bR\x06passed\x12#\n" +
+	"\n" +
+	"proportion\x18\x04 \x01(\x01H\x00R\n" +
+	"proportion\x88\x01\x01\x12\x1d\n" +
+	"\awarning\x18\x05 \x01(\tH\x01R\awarning\x88\x01\x01\x12\x1c\n" +
+	"\thistogram\x18\x06 \x03(\x03R\thistogram\x12+\n" +
+	"\x0fp_value_precise\x18\a \x01(\x01H\x02R\rpValuePrecise\x88\x01\x01\x123\n" +
+	"\x13p_value_discrepancy\x18\b \x01(\x01H\x03R\x11pValueDiscrepancy\x88\x01\x01\x12(\n" +
+	"\x10p_values_precise\x18\t \x03(\x01R\x0epValuesPreciseB\r\n" +
+	"\v_proportionB\n" +
+	"\n" +
+	"\b_warningB\x12\n" +
+	"\x10_p_value_preciseB\x16\n" +
+	"\x14_p_value_discrepancy\"-\n" +
+	"\x0fSelfTestRequest\x12\x1a\n" +
+	"\bdatasets\x18\x01 \x03(\tR\bdatasets\"\xcb\x01\n" +
+	"\x10SelfTestResponse\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\tR\ttimestamp\x12\x16\n" +
+	"\x06passed\x18\x02 \x01(\bR\x06passed\x12\x1c\n" +
+	"\ttolerance\x18\x03 \x01(\x01R\ttolerance\x127\n" +
+	"\x06checks\x18\x04 \x03(\v2\x1f.nist.sp800_22.v1.SelfTestCheckR\x06checks\x12*\n" +
+	"\x11execution_time_ms\x18\x05 \x01(\x03R\x0fexecutionTimeMs\"\xb3\x01\n" +
+	"\rSelfTestCheck\x12\x18\n" +
+	"\adataset\x18\x01 \x01(\tR\adataset\x12\x12\n" +
+	"\x04test\x18\x02 \x01(\tR\x04test\x12(\n" +
+	"\x10expected_p_value\x18\x03 \x01(\x01R\x0eexpectedPValue\x12\x17\n" +
+	"\ap_value\x18\x04 \x01(\x01R\x06pValue\x12\x19\n" +
+	"\babs_diff\x18\x05 \x01(\x01R\aabsDiff\x12\x16\n" +
+	"\x06passed\x18\x06 \x01(\bR\x06passed\"^\n" +
+	"\x16GenerateAndTestRequest\x12\x1c\n" +
+	"\tgenerator\x18\x01 \x01(\tR\tgenerator\x12\x12\n" +
+	"\x04seed\x18\x02 \x01(\fR\x04seed\x12\x12\n" +
+	"\x04bits\x18\x03 \x01(\x05R\x04bits\"\x8a\x01\n" +
+	"\x17GenerateAndTestResponse\x12\x1c\n" +
+	"\tgenerator\x18\x01 \x01(\tR\tgenerator\x12\x12\n" +
+	"\x04seed\x18\x02 \x01(\fR\x04seed\x12=\n" +
+	"\x06result\x18\x03 \x01(\v2%.nist.sp800_22.v1.Sp80022TestResponseR\x06result\"g\n" +
+	"\x11TestSourceRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x12\n" +
+	"\x04seed\x18\x02 \x01(\fR\x04seed\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12\x12\n" +
+	"\x04bits\x18\x04 \x01(\x05R\x04bits\"k\n" +
+	"\x12TestSourceResponse\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12=\n" +
+	"\x06result\x18\x02 \x01(\v2%.nist.sp800_22.v1.Sp80022TestResponseR\x06result\";\n" +
+	"\x16GetSourceStatusRequest\x12!\n" +
+	"\fsource_label\x18\x01 \x01(\tR\vsourceLabel\"\x89\x02\n" +
+	"\x17GetSourceStatusResponse\x12!\n" +
+	"\fsource_label\x18\x01 \x01(\tR\vsourceLabel\x123\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x1d.nist.sp800_22.v1.SourceStateR\x05state\x12\x18\n" +
+	"\asamples\x18\x03 \x01(\x05R\asamples\x12\x1f\n" +
+	"\vwindow_size\x18\x04 \x01(\x05R\n" +
+	"windowSize\x12!\n" +
+	"\flast_updated\x18\x05 \x01(\tR\vlastUpdated\x128\n" +
+	"\x05tests\x18\x06 \x03(\v2\".nist.sp800_22.v1.SourceTestStatusR\x05tests\"\xea\x01\n" +
+	"\x10SourceTestStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\asamples\x18\x02 \x01(\x05R\asamples\x12\x1e\n" +
+	"\n" +
+	"proportion\x18\x03 \x01(\x01R\n" +
+	"proportion\x12%\n" +
+	"\x0eproportion_min\x18\x04 \x01(\x01R\rproportionMin\x12,\n" +
+	"\x12uniformity_p_value\x18\x05 \x01(\x01R\x10uniformityPValue\x123\n" +
+	"\x05state\x18\x06 \x01(\x0e2\x1d.nist.sp800_22.v1.SourceStateR\x05state*n\n" +
+	"\vSourceState\x12\x1c\n" +
+	"\x18SOURCE_STATE_UNSPECIFIED\x10\x00\x12\x13\n" +
+	"\x0fSOURCE_STATE_OK\x10\x01\x12\x15\n" +
+	"\x11SOURCE_STATE_WARN\x10\x02\x12\x15\n" +
+	"\x11SOURCE_STATE_FAIL\x10\x032\xed\x03\n" +
+	"\x12Sp80022TestService\x12[\n" +
+	"\fRunTestSuite\x12$.nist.sp800_22.v1.Sp80022TestRequest\x1a%.nist.sp800_22.v1.Sp80022TestResponse\x12Q\n" +
+	"\bSelfTest\x12!.nist.sp800_22.v1.SelfTestRequest\x1a\".nist.sp800_22.v1.SelfTestResponse\x12f\n" +
+	"\x0fGenerateAndTest\x12(.nist.sp800_22.v1.GenerateAndTestRequest\x1a).nist.sp800_22.v1.GenerateAndTestResponse\x12W\n" +
+	"\n" +
+	"TestSource\x12#.nist.sp800_22.v1.TestSourceRequest\x1a$.nist.sp800_22.v1.TestSourceResponse\x12f\n" +
+	"\x0fGetSourceStatus\x12(.nist.sp800_22.v1.GetSourceStatusRequest\x1a).nist.sp800_22.v1.GetSourceStatusResponseBEZCgithub.com/AmmannChristian/nist-sp800-22-rev1a/pkg/pb;nistsp80022v1b\x06proto3"
 
 var (
 	file_nist_sp800_22_proto_rawDescOnce sync.Once
@@ -431,23 +1438,49 @@ func file_nist_sp800_22_proto_rawDescGZIP() []byte {
 	return file_nist_sp800_22_proto_rawDescData
 }
 
-var file_nist_sp800_22_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_nist_sp800_22_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_nist_sp800_22_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_nist_sp800_22_proto_goTypes = []any{
-	(*Sp80022TestRequest)(nil),  // 0: nist.sp800_22.v1.Sp80022TestRequest
-	(*Sp80022TestConfig)(nil),   // 1: nist.sp800_22.v1.Sp80022TestConfig
-	(*Sp80022TestResponse)(nil), // 2: nist.sp800_22.v1.Sp80022TestResponse
-	(*Sp80022TestResult)(nil),   // 3: nist.sp800_22.v1.Sp80022TestResult
+	(SourceState)(0),                // 0: nist.sp800_22.v1.SourceState
+	(*Sp80022TestRequest)(nil),      // 1: nist.sp800_22.v1.Sp80022TestRequest
+	(*Sp80022TestConfig)(nil),       // 2: nist.sp800_22.v1.Sp80022TestConfig
+	(*Sp80022TestResponse)(nil),     // 3: nist.sp800_22.v1.Sp80022TestResponse
+	(*Sp80022TestResult)(nil),       // 4: nist.sp800_22.v1.Sp80022TestResult
+	(*SelfTestRequest)(nil),         // 5: nist.sp800_22.v1.SelfTestRequest
+	(*SelfTestResponse)(nil),        // 6: nist.sp800_22.v1.SelfTestResponse
+	(*SelfTestCheck)(nil),           // 7: nist.sp800_22.v1.SelfTestCheck
+	(*GenerateAndTestRequest)(nil),  // 8: nist.sp800_22.v1.GenerateAndTestRequest
+	(*GenerateAndTestResponse)(nil), // 9: nist.sp800_22.v1.GenerateAndTestResponse
+	(*TestSourceRequest)(nil),       // 10: nist.sp800_22.v1.TestSourceRequest
+	(*TestSourceResponse)(nil),      // 11: nist.sp800_22.v1.TestSourceResponse
+	(*GetSourceStatusRequest)(nil),  // 12: nist.sp800_22.v1.GetSourceStatusRequest
+	(*GetSourceStatusResponse)(nil), // 13: nist.sp800_22.v1.GetSourceStatusResponse
+	(*SourceTestStatus)(nil),        // 14: nist.sp800_22.v1.SourceTestStatus
 }
 var file_nist_sp800_22_proto_depIdxs = []int32{
-	1, // 0: nist.sp800_22.v1.Sp80022TestRequest.config:type_name -> nist.sp800_22.v1.Sp80022TestConfig
-	3, // 1: nist.sp800_22.v1.Sp80022TestResponse.results:type_name -> nist.sp800_22.v1.Sp80022TestResult
-	0, // 2: nist.sp800_22.v1.Sp80022TestService.RunTestSuite:input_type -> nist.sp800_22.v1.Sp80022TestRequest
-	2, // 3: nist.sp800_22.v1.Sp80022TestService.RunTestSuite:output_type -> nist.sp800_22.v1.Sp80022TestResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2,  // 0: nist.sp800_22.v1.Sp80022TestRequest.config:type_name -> nist.sp800_22.v1.Sp80022TestConfig
+	4,  // 1: nist.sp800_22.v1.Sp80022TestResponse.results:type_name -> nist.sp800_22.v1.Sp80022TestResult
+	7,  // 2: nist.sp800_22.v1.SelfTestResponse.checks:type_name -> nist.sp800_22.v1.SelfTestCheck
+	3,  // 3: nist.sp800_22.v1.GenerateAndTestResponse.result:type_name -> nist.sp800_22.v1.Sp80022TestResponse
+	3,  // 4: nist.sp800_22.v1.TestSourceResponse.result:type_name -> nist.sp800_22.v1.Sp80022TestResponse
+	0,  // 5: nist.sp800_22.v1.GetSourceStatusResponse.state:type_name -> nist.sp800_22.v1.SourceState
+	14, // 6: nist.sp800_22.v1.GetSourceStatusResponse.tests:type_name -> nist.sp800_22.v1.SourceTestStatus
+	0,  // 7: nist.sp800_22.v1.SourceTestStatus.state:type_name -> nist.sp800_22.v1.SourceState
+	1,  // 8: nist.sp800_22.v1.Sp80022TestService.RunTestSuite:input_type -> nist.sp800_22.v1.Sp80022TestRequest
+	5,  // 9: nist.sp800_22.v1.Sp80022TestService.SelfTest:input_type -> nist.sp800_22.v1.SelfTestRequest
+	8,  // 10: nist.sp800_22.v1.Sp80022TestService.GenerateAndTest:input_type -> nist.sp800_22.v1.GenerateAndTestRequest
+	10, // 11: nist.sp800_22.v1.Sp80022TestService.TestSource:input_type -> nist.sp800_22.v1.TestSourceRequest
+	12, // 12: nist.sp800_22.v1.Sp80022TestService.GetSourceStatus:input_type -> nist.sp800_22.v1.GetSourceStatusRequest
+	3,  // 13: nist.sp800_22.v1.Sp80022TestService.RunTestSuite:output_type -> nist.sp800_22.v1.Sp80022TestResponse
+	6,  // 14: nist.sp800_22.v1.Sp80022TestService.SelfTest:output_type -> nist.sp800_22.v1.SelfTestResponse
+	9,  // 15: nist.sp800_22.v1.Sp80022TestService.GenerateAndTest:output_type -> nist.sp800_22.v1.GenerateAndTestResponse
+	11, // 16: nist.sp800_22.v1.Sp80022TestService.TestSource:output_type -> nist.sp800_22.v1.TestSourceResponse
+	13, // 17: nist.sp800_22.v1.Sp80022TestService.GetSourceStatus:output_type -> nist.sp800_22.v1.GetSourceStatusResponse
+	13, // [13:18] is the sub-list for method output_type
+	8,  // [8:13] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_nist_sp800_22_proto_init() }
@@ -462,13 +1495,14 @@ func file_nist_sp800_22_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_nist_sp800_22_proto_rawDesc), len(file_nist_sp800_22_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      1,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_nist_sp800_22_proto_goTypes,
 		DependencyIndexes: file_nist_sp800_22_proto_depIdxs,
+		EnumInfos:         file_nist_sp800_22_proto_enumTypes,
 		MessageInfos:      file_nist_sp800_22_proto_msgTypes,
 	}.Build()
 	File_nist_sp800_22_proto = out.File
@@ -19,7 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Sp80022TestService_RunTestSuite_FullMethodName = "/nist.sp800_22.v1.Sp80022TestService/RunTestSuite"
+	Sp80022TestService_RunTestSuite_FullMethodName    = "/nist.sp800_22.v1.Sp80022TestService/RunTestSuite"
+	Sp80022TestService_SelfTest_FullMethodName        = "/nist.sp800_22.v1.Sp80022TestService/SelfTest"
+	Sp80022TestService_GenerateAndTest_FullMethodName = "/nist.sp800_22.v1.Sp80022TestService/GenerateAndTest"
+	Sp80022TestService_TestSource_FullMethodName      = "/nist.sp800_22.v1.Sp80022TestService/TestSource"
+	Sp80022TestService_GetSourceStatus_FullMethodName = "/nist.sp800_22.v1.Sp80022TestService/GetSourceStatus"
 )
 
 // Sp80022TestServiceClient is the client API for Sp80022TestService service.
@@ -30,6 +34,18 @@ const (
 type Sp80022TestServiceClient interface {
 	// RunTestSuite executes all 15 NIST SP 800-22 statistical tests on the provided bitstream
 	RunTestSuite(ctx context.Context, in *Sp80022TestRequest, opts ...grpc.CallOption) (*Sp80022TestResponse, error)
+	// SelfTest runs the known-answer tests against the NIST sample data sets and compares
+	// the p-values with the reference values published in SP 800-22 Rev 1a Appendix B
+	SelfTest(ctx context.Context, in *SelfTestRequest, opts ...grpc.CallOption) (*SelfTestResponse, error)
+	// GenerateAndTest runs the test suite on the output of one of the reference generators
+	// described in SP 800-22 Rev 1a Appendix D
+	GenerateAndTest(ctx context.Context, in *GenerateAndTestRequest, opts ...grpc.CallOption) (*GenerateAndTestResponse, error)
+	// TestSource runs the test suite on bits read from a random source on the server host.
+	// It is disabled by default and restricted to callers with the configured admin scope
+	TestSource(ctx context.Context, in *TestSourceRequest, opts ...grpc.CallOption) (*TestSourceResponse, error)
+	// GetSourceStatus reports the proportion and uniformity of the recent results of a
+	// source label, evaluated over a rolling window of successive submissions
+	GetSourceStatus(ctx context.Context, in *GetSourceStatusRequest, opts ...grpc.CallOption) (*GetSourceStatusResponse, error)
 }
 
 type sp80022TestServiceClient struct {
@@ -50,6 +66,46 @@ func (c *sp80022TestServiceClient) RunTestSuite(ctx context.Context, in *Sp80022
 	return out, nil
 }
 
+func (c *sp80022TestServiceClient) SelfTest(ctx context.Context, in *SelfTestRequest, opts ...grpc.CallOption) (*SelfTestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SelfTestResponse)
+	err := c.cc.Invoke(ctx, Sp80022TestService_SelfTest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sp80022TestServiceClient) GenerateAndTest(ctx context.Context, in *GenerateAndTestRequest, opts ...grpc.CallOption) (*GenerateAndTestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GenerateAndTestResponse)
+	err := c.cc.Invoke(ctx, Sp80022TestService_GenerateAndTest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sp80022TestServiceClient) TestSource(ctx context.Context, in *TestSourceRequest, opts ...grpc.CallOption) (*TestSourceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TestSourceResponse)
+	err := c.cc.Invoke(ctx, Sp80022TestService_TestSource_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sp80022TestServiceClient) GetSourceStatus(ctx context.Context, in *GetSourceStatusRequest, opts ...grpc.CallOption) (*GetSourceStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSourceStatusResponse)
+	err := c.cc.Invoke(ctx, Sp80022TestService_GetSourceStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Sp80022TestServiceServer is the server API for Sp80022TestService service.
 // All implementations must embed UnimplementedSp80022TestServiceServer
 // for forward compatibility.
@@ -58,6 +114,18 @@ func (c *sp80022TestServiceClient) RunTestSuite(ctx context.Context, in *Sp80022
 type Sp80022TestServiceServer interface {
 	// RunTestSuite executes all 15 NIST SP 800-22 statistical tests on the provided bitstream
 	RunTestSuite(context.Context, *Sp80022TestRequest) (*Sp80022TestResponse, error)
+	// SelfTest runs the known-answer tests against the NIST sample data sets and compares
+	// the p-values with the reference values published in SP 800-22 Rev 1a Appendix B
+	SelfTest(context.Context, *SelfTestRequest) (*SelfTestResponse, error)
+	// GenerateAndTest runs the test suite on the output of one of the reference generators
+	// described in SP 800-22 Rev 1a Appendix D
+	GenerateAndTest(context.Context, *GenerateAndTestRequest) (*GenerateAndTestResponse, error)
+	// TestSource runs the test suite on bits read from a random source on the server host.
+	// It is disabled by default and restricted to callers with the configured admin scope
+	TestSource(context.Context, *TestSourceRequest) (*TestSourceResponse, error)
+	// GetSourceStatus reports the proportion and uniformity of the recent results of a
+	// source label, evaluated over a rolling window of successive submissions
+	GetSourceStatus(context.Context, *GetSourceStatusRequest) (*GetSourceStatusResponse, error)
 	mustEmbedUnimplementedSp80022TestServiceServer()
 }
 
@@ -71,6 +139,18 @@ type UnimplementedSp80022TestServiceServer struct{}
 func (UnimplementedSp80022TestServiceServer) RunTestSuite(context.Context, *Sp80022TestRequest) (*Sp80022TestResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method RunTestSuite not implemented")
 }
+func (UnimplementedSp80022TestServiceServer) SelfTest(context.Context, *SelfTestRequest) (*SelfTestResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SelfTest not implemented")
+}
+func (UnimplementedSp80022TestServiceServer) GenerateAndTest(context.Context, *GenerateAndTestRequest) (*GenerateAndTestResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GenerateAndTest not implemented")
+}
+func (UnimplementedSp80022TestServiceServer) TestSource(context.Context, *TestSourceRequest) (*TestSourceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TestSource not implemented")
+}
+func (UnimplementedSp80022TestServiceServer) GetSourceStatus(context.Context, *GetSourceStatusRequest) (*GetSourceStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSourceStatus not implemented")
+}
 func (UnimplementedSp80022TestServiceServer) mustEmbedUnimplementedSp80022TestServiceServer() {}
 func (UnimplementedSp80022TestServiceServer) testEmbeddedByValue()                            {}
 
@@ -110,6 +190,78 @@ func _Sp80022TestService_RunTestSuite_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Sp80022TestService_SelfTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SelfTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Sp80022TestServiceServer).SelfTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sp80022TestService_SelfTest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Sp80022TestServiceServer).SelfTest(ctx, req.(*SelfTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sp80022TestService_GenerateAndTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateAndTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Sp80022TestServiceServer).GenerateAndTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sp80022TestService_GenerateAndTest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Sp80022TestServiceServer).GenerateAndTest(ctx, req.(*GenerateAndTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sp80022TestService_TestSource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TestSourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Sp80022TestServiceServer).TestSource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sp80022TestService_TestSource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Sp80022TestServiceServer).TestSource(ctx, req.(*TestSourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sp80022TestService_GetSourceStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSourceStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Sp80022TestServiceServer).GetSourceStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sp80022TestService_GetSourceStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Sp80022TestServiceServer).GetSourceStatus(ctx, req.(*GetSourceStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Sp80022TestService_ServiceDesc is the grpc.ServiceDesc for Sp80022TestService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -121,6 +273,22 @@ var Sp80022TestService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RunTestSuite",
 			Handler:    _Sp80022TestService_RunTestSuite_Handler,
 		},
+		{
+			MethodName: "SelfTest",
+			Handler:    _Sp80022TestService_SelfTest_Handler,
+		},
+		{
+			MethodName: "GenerateAndTest",
+			Handler:    _Sp80022TestService_GenerateAndTest_Handler,
+		},
+		{
+			MethodName: "TestSource",
+			Handler:    _Sp80022TestService_TestSource_Handler,
+		},
+		{
+			MethodName: "GetSourceStatus",
+			Handler:    _Sp80022TestService_GetSourceStatus_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "nist_sp800_22.proto",
@@ -3,15 +3,17 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
-	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/nist"
+	"github.com/AmmannChristian/nist-sp800-22-rev1a/internal/regression"
 )
 
 type testResult struct {
@@ -32,12 +34,17 @@ func main() {
 		outJSON    = flag.Bool("json", false, "Print JSON output instead of table")
 		tolerance  = flag.Float64("tolerance", 1e-6, "Absolute tolerance for p-value comparison")
 		encoding   = flag.String("encoding", "ascii", "Input encoding: binary or ascii")
+		freeze     = flag.String("freeze", "", "Add the reference p-values as a sample to this NIST STS file (internal/regression/testdata/sts/<source>.json)")
+		source     = flag.String("source", "", "Regression source name of the dataset (pi, e, sqrt2, sqrt3 or a generator); required with -freeze")
 	)
 	flag.Parse()
 
 	if *dataset == "" || *resultsDir == "" {
 		log.Fatalf("dataset and results are required")
 	}
+	if *freeze != "" && *source == "" {
+		log.Fatalf("source is required with freeze")
+	}
 
 	rawData, err := os.ReadFile(*dataset)
 	if err != nil {
@@ -49,147 +56,115 @@ func main() {
 		log.Fatalf("parse bitstream: %v", err)
 	}
 
-	tests := []struct {
-		name         string
-		file         string
-		compute      func([]byte) ([]float64, error)
-		transformRef func([]float64) ([]float64, error)
-		skipReason   string
-	}{
-		{"Frequency", "Frequency/results.txt", single(func(b []byte) (float64, bool) { return nist.FrequencyTest(b) }), nil, ""},
-		{"BlockFrequency", "BlockFrequency/results.txt", single(func(b []byte) (float64, bool) { return nist.BlockFrequencyTest(b, 128) }), nil, ""},
-		{"CumulativeSums", "CumulativeSums/results.txt", func(b []byte) ([]float64, error) {
-			p, _ := nist.CumulativeSumsTest(b)
-			return []float64{p}, nil // NIST logs forward+reverse; we compare on min value
-		}, func(vals []float64) ([]float64, error) {
-			if len(vals) == 0 {
-				return nil, fmt.Errorf("no ref values")
-			}
-			return []float64{minValue(vals)}, nil
-		}, ""},
-		{"Runs", "Runs/results.txt", single(func(b []byte) (float64, bool) { return nist.RunsTest(b) }), nil, ""},
-		{"LongestRun", "LongestRun/results.txt", single(func(b []byte) (float64, bool) { return nist.LongestRunOfOnesTest(b) }), nil, ""},
-		{"Rank", "Rank/results.txt", single(func(b []byte) (float64, bool) { return nist.BinaryMatrixRankTest(b) }), nil, ""},
-		{"FFT", "FFT/results.txt", single(func(b []byte) (float64, bool) { return nist.DiscreteFourierTransformTest(b) }), nil, ""},
-		{"OverlappingTemplate", "OverlappingTemplate/results.txt", single(func(b []byte) (float64, bool) { return nist.OverlappingTemplateTest(b, 9) }), nil, ""},
-		{"ApproximateEntropy", "ApproximateEntropy/results.txt", single(func(b []byte) (float64, bool) { return nist.ApproximateEntropyTest(b, 10) }), nil, ""},
-		{"Universal", "Universal/results.txt", single(func(b []byte) (float64, bool) { return nist.UniversalStatisticalTest(b) }), nil, ""},
-		{"LinearComplexity", "LinearComplexity/results.txt", single(func(b []byte) (float64, bool) { return nist.LinearComplexityTest(b, 500) }), nil, ""},
-		{"Serial", "Serial/results.txt", single(func(b []byte) (float64, bool) { return nist.SerialTest(b, 16) }), func(vals []float64) ([]float64, error) {
-			if len(vals) == 0 {
-				return nil, fmt.Errorf("no ref values")
-			}
-			return []float64{minValue(vals)}, nil
-		}, ""},
-		{"NonOverlappingTemplate", "NonOverlappingTemplate/results.txt", single(func(b []byte) (float64, bool) { return nist.NonOverlappingTemplateTest(b, 9) }), func(vals []float64) ([]float64, error) {
-			if len(vals) == 0 {
-				return nil, fmt.Errorf("no ref values")
-			}
-			return []float64{minValue(vals)}, nil
-		}, ""},
-		{"RandomExcursions", "RandomExcursions/results.txt", single(func(b []byte) (float64, bool) { return nist.RandomExcursionsTest(b) }), func(vals []float64) ([]float64, error) {
-			if len(vals) == 0 {
-				return nil, fmt.Errorf("no ref values")
-			}
-			return []float64{minValue(vals)}, nil
-		}, ""},
-		{"RandomExcursionsVariant", "RandomExcursionsVariant/results.txt", single(func(b []byte) (float64, bool) { return nist.RandomExcursionsVariantTest(b) }), func(vals []float64) ([]float64, error) {
-			if len(vals) == 0 {
-				return nil, fmt.Errorf("no ref values")
-			}
-			return []float64{minValue(vals)}, nil
-		}, ""},
-	}
+	// Every p-value of all 15 tests, sub-tests included, in results.txt order.
+	goResults := regression.Compute(data)
+	refResults := make([]regression.Result, len(regression.Tests))
 
 	var results []testResult
 	allPass := true
 
-	for _, tt := range tests {
-		res := testResult{Name: tt.name}
-		if tt.compute == nil {
-			res.Skipped = true
-			res.Reason = tt.skipReason
-			results = append(results, res)
-			continue
-		}
+	for i, tt := range regression.Tests {
+		res := testResult{Name: tt.STSDir, GoVals: goResults[i].PValues}
+		refResults[i].Test = tt.Name
 
-		refPath := filepath.Join(*resultsDir, tt.file)
-		refVals, err := readRef(refPath)
+		refVals, err := readRef(filepath.Join(*resultsDir, tt.STSDir, "results.txt"))
 		if err != nil {
 			res.Skipped = true
 			res.Reason = fmt.Sprintf("read ref: %v", err)
 			results = append(results, res)
+			allPass = false
 			continue
 		}
-
-		if tt.transformRef != nil {
-			refVals, err = tt.transformRef(refVals)
-			if err != nil {
-				res.Skipped = true
-				res.Reason = fmt.Sprintf("transform ref: %v", err)
-				results = append(results, res)
-				continue
-			}
-		}
-
-		goVals, err := tt.compute(data)
-		if err != nil {
-			res.Skipped = true
-			res.Reason = fmt.Sprintf("compute go: %v", err)
-			results = append(results, res)
-			continue
+		res.Ref = refVals
+		refResults[i].PValues = refVals
+		if len(refVals) == 0 {
+			refResults[i].Skipped = "no p-values in results.txt"
 		}
 
-		res.Ref = refVals
-		res.GoVals = goVals
-		res.Diffs = make([]float64, min(len(refVals), len(goVals)))
-		res.PassDiff = true
-		for i := range res.Diffs {
-			res.Diffs[i] = math.Abs(refVals[i] - goVals[i])
-			if res.Diffs[i] > *tolerance || !validRange(refVals[i]) || !validRange(goVals[i]) {
+		mismatches := regression.Compare(refResults[i:i+1], goResults[i:i+1], *tolerance)
+		res.PassDiff = len(mismatches) == 0
+		res.Diffs = make([]float64, min(len(refVals), len(res.GoVals)))
+		for j := range res.Diffs {
+			res.Diffs[j] = math.Abs(refVals[j] - res.GoVals[j])
+			if !validRange(refVals[j]) || !validRange(res.GoVals[j]) {
 				res.PassDiff = false
 			}
 		}
+		if goResults[i].Skipped != "" {
+			res.Reason = goResults[i].Skipped
+		}
 		allPass = allPass && res.PassDiff
 		results = append(results, res)
 	}
 
+	if *freeze != "" {
+		if err := freezeSample(*freeze, *source, *bits, *tolerance, refResults); err != nil {
+			log.Fatalf("freeze: %v", err)
+		}
+	}
+
 	if *outJSON {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		if err := enc.Encode(results); err != nil {
 			log.Fatalf("encode json: %v", err)
 		}
+		if !allPass {
+			os.Exit(1)
+		}
 		return
 	}
 
 	fmt.Printf("Dataset: %s (%d bits) | Reference dir: %s | tolerance: %g\n", *dataset, *bits, *resultsDir, *tolerance)
-	fmt.Println("Test                      Go p-value        Ref p-value       |diff|      Status")
+	fmt.Println("Test                      p-values  max |diff|    Status")
 	fmt.Println("--------------------------------------------------------------------------------")
 	for _, r := range results {
 		if r.Skipped {
-			fmt.Printf("%-24s %-16s %-16s %-12s SKIPPED (%s)\n", r.Name, "-", "-", "-", r.Reason)
+			fmt.Printf("%-24s %-9s %-13s SKIPPED (%s)\n", r.Name, "-", "-", r.Reason)
 			continue
 		}
-		goStr := fmtVal(r.GoVals)
-		refStr := fmtVal(r.Ref)
-		diffStr := fmtVal(r.Diffs)
 		status := "OK"
 		if !r.PassDiff {
 			status = "MISMATCH"
 		}
-		fmt.Printf("%-24s %-16s %-16s %-12s %s\n", r.Name, goStr, refStr, diffStr, status)
+		fmt.Printf("%-24s %-9d %-13s %s\n", r.Name, len(r.Ref), fmtMax(r.Diffs), status)
 	}
 	if !allPass {
+		fmt.Println()
+		mismatches := regression.Compare(refResults, goResults, *tolerance)
+		if err := regression.WriteReport(os.Stdout, *dataset, *bits, *tolerance, mismatches); err != nil {
+			log.Fatalf("write report: %v", err)
+		}
 		os.Exit(1)
 	}
 }
 
-func single(f func([]byte) (float64, bool)) func([]byte) ([]float64, error) {
-	return func(b []byte) ([]float64, error) {
-		p, _ := f(b)
-		return []float64{p}, nil
-	}
+// freezeSample stores the reference results as the sample of the given length in a
+// NIST STS file, replacing an existing sample of that length.
+func freezeSample(path, source string, bits int, tolerance float64, results []regression.Result) error {
+	ref, err := regression.Load(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		ref = &regression.Reference{Source: source}
+	case err != nil:
+		return err
+	case ref.Source != source:
+		return fmt.Errorf("%s holds source %q, not %q", path, ref.Source, source)
+	}
+	ref.Origin = regression.STSOrigin
+	ref.Tolerance = max(ref.Tolerance, tolerance)
+
+	sample := regression.Sample{Bits: bits, Results: results}
+	i := slices.IndexFunc(ref.Samples, func(s regression.Sample) bool { return s.Bits >= bits })
+	switch {
+	case i < 0:
+		ref.Samples = append(ref.Samples, sample)
+	case ref.Samples[i].Bits == bits:
+		ref.Samples[i] = sample
+	default:
+		ref.Samples = slices.Insert(ref.Samples, i, sample)
+	}
+	return ref.Save(path)
 }
 
 func readRef(path string) ([]float64, error) {
@@ -214,37 +189,17 @@ func readRef(path string) ([]float64, error) {
 	return vals, scanner.Err()
 }
 
-func fmtVal(vals []float64) string {
+func fmtMax(vals []float64) string {
 	if len(vals) == 0 {
 		return "-"
 	}
-	if len(vals) == 1 {
-		return fmt.Sprintf("%.6f", vals[0])
-	}
-	return fmt.Sprintf("%.6f…", vals[0])
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+	return fmt.Sprintf("%.3g", slices.Max(vals))
 }
 
 func validRange(v float64) bool {
 	return !math.IsNaN(v) && v >= 0 && v <= 1
 }
 
-func minValue(vals []float64) float64 {
-	min := math.Inf(1)
-	for _, v := range vals {
-		if v < min {
-			min = v
-		}
-	}
-	return min
-}
-
 func parseBitstream(data []byte, encoding string, bits int) ([]byte, error) {
 	if encoding == "binary" {
 		requiredBytes := (bits + 7) / 8